@@ -15,6 +15,7 @@ import (
 
 	"github.com/parflesh/sonarqube-operator/pkg/apis"
 	"github.com/parflesh/sonarqube-operator/pkg/controller"
+	"github.com/parflesh/sonarqube-operator/pkg/webhook"
 	"github.com/parflesh/sonarqube-operator/version"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
@@ -38,6 +39,7 @@ var (
 	metricsHost               = "0.0.0.0"
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
+	webhookPort               = 9443
 )
 var log = logf.Log.WithName("cmd")
 
@@ -96,6 +98,7 @@ func main() {
 	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               webhookPort,
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -128,6 +131,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks, the webhook server needs a serving certificate (see deploy/webhook.yaml) so it is only
+	// started when enabled
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg)
 
@@ -1,30 +1,75 @@
 package api_client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type APIProvider interface {
-	New(URL string) APIReader
+	New(URL string, auth *Auth) APIReader
+}
+
+// Auth holds credentials for SonarQube api calls
+// Token takes precedence over Username and Password, nil or empty Auth is anonymous
+type Auth struct {
+	Token    string
+	Username string
+	Password string
 }
 
 type APIReader interface {
 	Ping() error
 	Status() (*Status, error)
 	Upgrades() (*Upgrades, error)
+	MigrateDB() (*DBMigrationStatus, error)
+	DBMigrationStatus() (*DBMigrationStatus, error)
+	Validate() (bool, error)
+	ChangePassword(login, previousPassword, password string) error
+	GenerateToken(name string) (*UserToken, error)
+	RevokeToken(name string) error
+	GetProject(key string) (*Project, error)
+	CreateProject(key, name, visibility string) (*Project, error)
+	UpdateProjectVisibility(key, visibility string) error
+	ProjectBranches(key string) ([]ProjectBranch, error)
+	RenameMainBranch(key, name string) error
+	ProjectQualityGate(key string) (*QualityGate, error)
+	SelectQualityGate(key, gateName string) error
+	ProjectQualityProfiles(key string) ([]QualityProfile, error)
+	AddProjectQualityProfile(key, language, profileName string) error
+	GetQualityGate(name string) (*QualityGate, error)
+	CreateQualityGate(name string) (*QualityGate, error)
+	CreateQualityGateCondition(gateName string, condition QualityGateCondition) error
+	UpdateQualityGateCondition(condition QualityGateCondition) error
+	DeleteQualityGateCondition(id ID) error
+	SetDefaultQualityGate(name string) error
+	QualityGateProjects(gateName string) ([]QualityGateProject, error)
+	DeselectQualityGate(key string) error
+	GetQualityProfile(language, name string) (*QualityProfile, error)
+	CreateQualityProfile(language, name string) (*QualityProfile, error)
+	RestoreQualityProfile(backup string) (*QualityProfile, error)
+	BackupQualityProfile(language, name string) (string, error)
+	ChangeQualityProfileParent(language, name, parentName string) error
+	ActivateRule(profileKey, rule, severity string, params map[string]string) error
 }
 
 type APIClient struct {
 	URL    string
+	Auth   *Auth
 	Client *http.Client
 }
 
-func (r *APIClient) New(URL string) APIReader {
+func (r *APIClient) New(URL string, auth *Auth) APIReader {
 	var netTransport = &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout: 5 * time.Second,
@@ -33,7 +78,8 @@ func (r *APIClient) New(URL string) APIReader {
 	}
 
 	return &APIClient{
-		URL: URL,
+		URL:  URL,
+		Auth: auth,
 		Client: &http.Client{
 			Timeout:   time.Second * 10,
 			Transport: netTransport,
@@ -42,7 +88,7 @@ func (r *APIClient) New(URL string) APIReader {
 }
 
 func (r *APIClient) Ping() error {
-	res, err := r.get("system", "ping")
+	res, err := r.get("system", "ping", nil)
 	if err != nil {
 		return err
 	}
@@ -66,7 +112,7 @@ func (r *APIClient) Ping() error {
 
 func (r *APIClient) Status() (*Status, error) {
 	output := &Status{}
-	res, err := r.get("system", "status")
+	res, err := r.get("system", "status", nil)
 	if err != nil {
 		return output, err
 	}
@@ -91,7 +137,7 @@ func (r *APIClient) Status() (*Status, error) {
 
 func (r *APIClient) Upgrades() (*Upgrades, error) {
 	output := &Upgrades{}
-	res, err := r.get("system", "upgrades")
+	res, err := r.get("system", "upgrades", nil)
 	if err != nil {
 		return output, err
 	}
@@ -114,7 +160,546 @@ func (r *APIClient) Upgrades() (*Upgrades, error) {
 	return output, nil
 }
 
-func (r *APIClient) get(domain, object string) (*http.Response, error) {
-	url := fmt.Sprintf("%s/api/%s/%s", r.URL, domain, object)
-	return r.Client.Get(url)
+func (r *APIClient) MigrateDB() (*DBMigrationStatus, error) {
+	output := &DBMigrationStatus{}
+	res, err := r.post("system", "migrate_db", nil)
+	if err != nil {
+		return output, err
+	}
+
+	return output, r.decode(res, output)
+}
+
+func (r *APIClient) DBMigrationStatus() (*DBMigrationStatus, error) {
+	output := &DBMigrationStatus{}
+	res, err := r.get("system", "db_migration_status", nil)
+	if err != nil {
+		return output, err
+	}
+
+	return output, r.decode(res, output)
+}
+
+func (r *APIClient) Validate() (bool, error) {
+	output := &Validation{}
+	res, err := r.get("authentication", "validate", nil)
+	if err != nil {
+		return false, err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+		return false, nil
+	}
+
+	err = r.decode(res, output)
+	return output.Valid, err
+}
+
+func (r *APIClient) ChangePassword(login, previousPassword, password string) error {
+	res, err := r.post("users", "change_password", url.Values{
+		"login":            {login},
+		"previousPassword": {previousPassword},
+		"password":         {password},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) GenerateToken(name string) (*UserToken, error) {
+	output := &UserToken{}
+	res, err := r.post("user_tokens", "generate", url.Values{
+		"name": {name},
+	})
+	if err != nil {
+		return output, err
+	}
+
+	return output, r.decode(res, output)
+}
+
+// RevokeToken revokes the token name of the authenticated user, tokens that do not exist are ignored by SonarQube
+func (r *APIClient) RevokeToken(name string) error {
+	res, err := r.post("user_tokens", "revoke", url.Values{
+		"name": {name},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+// GetProject returns nil if no project with key exists
+func (r *APIClient) GetProject(key string) (*Project, error) {
+	output := &ProjectSearch{}
+	res, err := r.get("projects", "search", url.Values{
+		"projects": {key},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.decode(res, output)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range output.Components {
+		if v.Key == key {
+			return &v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *APIClient) CreateProject(key, name, visibility string) (*Project, error) {
+	output := &ProjectCreate{}
+	params := url.Values{
+		"project": {key},
+		"name":    {name},
+	}
+	if visibility != "" {
+		params.Set("visibility", visibility)
+	}
+	res, err := r.post("projects", "create", params)
+	if err != nil {
+		return &output.Project, err
+	}
+
+	return &output.Project, r.decode(res, output)
+}
+
+func (r *APIClient) UpdateProjectVisibility(key, visibility string) error {
+	res, err := r.post("projects", "update_visibility", url.Values{
+		"project":    {key},
+		"visibility": {visibility},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) ProjectBranches(key string) ([]ProjectBranch, error) {
+	output := &ProjectBranches{}
+	res, err := r.get("project_branches", "list", url.Values{
+		"project": {key},
+	})
+	if err != nil {
+		return output.Branches, err
+	}
+
+	err = r.decode(res, output)
+	return output.Branches, err
+}
+
+func (r *APIClient) RenameMainBranch(key, name string) error {
+	res, err := r.post("project_branches", "rename", url.Values{
+		"project": {key},
+		"name":    {name},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) ProjectQualityGate(key string) (*QualityGate, error) {
+	output := &ProjectQualityGate{}
+	res, err := r.get("qualitygates", "get_by_project", url.Values{
+		"project": {key},
+	})
+	if err != nil {
+		return &output.QualityGate, err
+	}
+
+	return &output.QualityGate, r.decode(res, output)
+}
+
+func (r *APIClient) SelectQualityGate(key, gateName string) error {
+	res, err := r.post("qualitygates", "select", url.Values{
+		"projectKey": {key},
+		"gateName":   {gateName},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) ProjectQualityProfiles(key string) ([]QualityProfile, error) {
+	output := &QualityProfileSearch{}
+	res, err := r.get("qualityprofiles", "search", url.Values{
+		"project": {key},
+	})
+	if err != nil {
+		return output.Profiles, err
+	}
+
+	err = r.decode(res, output)
+	return output.Profiles, err
+}
+
+func (r *APIClient) AddProjectQualityProfile(key, language, profileName string) error {
+	res, err := r.post("qualityprofiles", "add_project", url.Values{
+		"project":        {key},
+		"language":       {language},
+		"qualityProfile": {profileName},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+// GetQualityGate returns nil if no quality gate with name exists
+func (r *APIClient) GetQualityGate(name string) (*QualityGate, error) {
+	list := &QualityGateList{}
+	res, err := r.get("qualitygates", "list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.decode(res, list)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *QualityGate
+	for i, v := range list.QualityGates {
+		if v.Name == name {
+			found = &list.QualityGates[i]
+			break
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+
+	output := &QualityGate{}
+	res, err = r.get("qualitygates", "show", url.Values{
+		"name": {name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.decode(res, output)
+	if err != nil {
+		return nil, err
+	}
+	output.IsDefault = found.IsDefault
+
+	return output, nil
+}
+
+func (r *APIClient) CreateQualityGate(name string) (*QualityGate, error) {
+	output := &QualityGate{}
+	res, err := r.post("qualitygates", "create", url.Values{
+		"name": {name},
+	})
+	if err != nil {
+		return output, err
+	}
+
+	return output, r.decode(res, output)
+}
+
+func (r *APIClient) CreateQualityGateCondition(gateName string, condition QualityGateCondition) error {
+	res, err := r.post("qualitygates", "create_condition", url.Values{
+		"gateName": {gateName},
+		"metric":   {condition.Metric},
+		"op":       {condition.Op},
+		"error":    {condition.Error},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) UpdateQualityGateCondition(condition QualityGateCondition) error {
+	res, err := r.post("qualitygates", "update_condition", url.Values{
+		"id":     {string(condition.ID)},
+		"metric": {condition.Metric},
+		"op":     {condition.Op},
+		"error":  {condition.Error},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) DeleteQualityGateCondition(id ID) error {
+	res, err := r.post("qualitygates", "delete_condition", url.Values{
+		"id": {string(id)},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) SetDefaultQualityGate(name string) error {
+	res, err := r.post("qualitygates", "set_as_default", url.Values{
+		"name": {name},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+// QualityGateProjects pages through the projects associated with the quality gate named gateName
+func (r *APIClient) QualityGateProjects(gateName string) ([]QualityGateProject, error) {
+	var projects []QualityGateProject
+	for page := 1; ; page++ {
+		output := &QualityGateProjects{}
+		res, err := r.get("qualitygates", "search", url.Values{
+			"gateName": {gateName},
+			"selected": {"selected"},
+			"p":        {strconv.Itoa(page)},
+			"ps":       {strconv.Itoa(QualityGateProjectsPageSize)},
+		})
+		if err != nil {
+			return projects, err
+		}
+
+		err = r.decode(res, output)
+		if err != nil {
+			return projects, err
+		}
+
+		projects = append(projects, output.Results...)
+		if len(output.Results) < QualityGateProjectsPageSize {
+			return projects, nil
+		}
+	}
+}
+
+func (r *APIClient) DeselectQualityGate(key string) error {
+	res, err := r.post("qualitygates", "deselect", url.Values{
+		"projectKey": {key},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+// GetQualityProfile returns nil if no quality profile with name exists for language
+func (r *APIClient) GetQualityProfile(language, name string) (*QualityProfile, error) {
+	output := &QualityProfileSearch{}
+	res, err := r.get("qualityprofiles", "search", url.Values{
+		"language":       {language},
+		"qualityProfile": {name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.decode(res, output)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range output.Profiles {
+		if v.Name == name && v.Language == language {
+			return &v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *APIClient) CreateQualityProfile(language, name string) (*QualityProfile, error) {
+	output := &QualityProfileCreate{}
+	res, err := r.post("qualityprofiles", "create", url.Values{
+		"language": {language},
+		"name":     {name},
+	})
+	if err != nil {
+		return &output.Profile, err
+	}
+
+	return &output.Profile, r.decode(res, output)
+}
+
+func (r *APIClient) RestoreQualityProfile(backup string) (*QualityProfile, error) {
+	output := &QualityProfileCreate{}
+	res, err := r.upload("qualityprofiles", "restore", "backup", "backup.xml", []byte(backup))
+	if err != nil {
+		return &output.Profile, err
+	}
+
+	return &output.Profile, r.decode(res, output)
+}
+
+func (r *APIClient) BackupQualityProfile(language, name string) (string, error) {
+	res, err := r.get("qualityprofiles", "backup", url.Values{
+		"language":       {language},
+		"qualityProfile": {name},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := r.read(res)
+	return string(body), err
+}
+
+// ChangeQualityProfileParent changes the parent of quality profile name, the parent is removed when parentName is empty
+func (r *APIClient) ChangeQualityProfileParent(language, name, parentName string) error {
+	values := url.Values{
+		"language":       {language},
+		"qualityProfile": {name},
+	}
+	if parentName != "" {
+		values.Set("parentQualityProfile", parentName)
+	}
+	res, err := r.post("qualityprofiles", "change_parent", values)
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) ActivateRule(profileKey, rule, severity string, params map[string]string) error {
+	values := url.Values{
+		"key":  {profileKey},
+		"rule": {rule},
+	}
+	if severity != "" {
+		values.Set("severity", severity)
+	}
+	if len(params) > 0 {
+		var pairs []string
+		for k, v := range params {
+			// Values containing a separator are double quoted, SonarQube reads quoted values as is
+			if strings.ContainsAny(v, ";=") {
+				v = fmt.Sprintf(`"%s"`, v)
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(pairs)
+		values.Set("params", strings.Join(pairs, ";"))
+	}
+	res, err := r.post("qualityprofiles", "activate_rule", values)
+	if err != nil {
+		return err
+	}
+
+	return r.decode(res, nil)
+}
+
+func (r *APIClient) get(domain, object string, params url.Values) (*http.Response, error) {
+	return r.request(http.MethodGet, domain, object, params)
+}
+
+func (r *APIClient) post(domain, object string, params url.Values) (*http.Response, error) {
+	return r.request(http.MethodPost, domain, object, params)
+}
+
+func (r *APIClient) request(method, domain, object string, params url.Values) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s/api/%s/%s", r.URL, domain, object)
+
+	var body io.Reader
+	if method == http.MethodGet && len(params) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	} else if len(params) > 0 {
+		body = strings.NewReader(params.Encode())
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	r.authorize(req)
+
+	return r.Client.Do(req)
+}
+
+// upload posts content as a multipart file field
+func (r *APIClient) upload(domain, object, field, filename string, content []byte) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s/api/%s/%s", r.URL, domain, object)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		return nil, err
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	r.authorize(req)
+
+	return r.Client.Do(req)
+}
+
+func (r *APIClient) authorize(req *http.Request) {
+	if r.Auth != nil && r.Auth.Token != "" {
+		req.SetBasicAuth(r.Auth.Token, "")
+	} else if r.Auth != nil && r.Auth.Username != "" {
+		req.SetBasicAuth(r.Auth.Username, r.Auth.Password)
+	}
+}
+
+// decode reads the response body into output, output may be nil for endpoints without content
+func (r *APIClient) decode(res *http.Response, output interface{}) error {
+	body, err := r.read(res)
+	if err != nil {
+		return err
+	}
+
+	if output == nil || len(body) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(body, output)
+}
+
+// read returns the response body, non 2xx responses are returned as error
+func (r *APIClient) read(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return body, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return body, fmt.Errorf("%s %s returned %d: %s", res.Request.Method, res.Request.URL.Path, res.StatusCode, string(body))
+	}
+
+	return body, nil
 }
@@ -1,14 +1,80 @@
 package api_client
 
 type APIClientMock struct {
+	Auth *Auth
+
 	PingError      error
 	InfoOutput     *Status
 	InfoError      error
 	UpgradesOutput *Upgrades
 	UpgradesError  error
+
+	MigrateDBOutput         *DBMigrationStatus
+	MigrateDBError          error
+	DBMigrationStatusOutput *DBMigrationStatus
+	DBMigrationStatusError  error
+
+	ValidateOutput       bool
+	ValidateError        error
+	ChangePasswordError  error
+	GenerateTokenOutput  *UserToken
+	GenerateTokenError   error
+	RevokeTokenError     error
+	ChangePasswordCalled bool
+	RevokedTokens        []string
+
+	GetProjectOutput               *Project
+	GetProjectError                error
+	CreateProjectError             error
+	UpdateProjectVisibilityError   error
+	ProjectBranchesOutput          []ProjectBranch
+	ProjectBranchesError           error
+	RenameMainBranchError          error
+	ProjectQualityGateOutput       *QualityGate
+	ProjectQualityGateError        error
+	SelectQualityGateError         error
+	ProjectQualityProfilesOutput   []QualityProfile
+	ProjectQualityProfilesError    error
+	AddProjectQualityProfileError  error
+	CreateProjectCalled            bool
+	UpdateProjectVisibilityCalled  bool
+	RenameMainBranchCalled         bool
+	SelectQualityGateCalled        bool
+	AddProjectQualityProfileCalled bool
+
+	GetQualityGateOutput             *QualityGate
+	GetQualityGateError              error
+	CreateQualityGateError           error
+	CreateQualityGateConditionError  error
+	UpdateQualityGateConditionError  error
+	DeleteQualityGateConditionError  error
+	SetDefaultQualityGateError       error
+	QualityGateProjectsOutput        []QualityGateProject
+	QualityGateProjectsError         error
+	DeselectQualityGateError         error
+	CreateQualityGateCalled          bool
+	CreateQualityGateConditionCalled bool
+	UpdateQualityGateConditionCalled bool
+	DeleteQualityGateConditionCalled bool
+	SetDefaultQualityGateCalled      bool
+	DeselectQualityGateCalled        bool
+
+	GetQualityProfileOutput          *QualityProfile
+	GetQualityProfileError           error
+	CreateQualityProfileError        error
+	RestoreQualityProfileError       error
+	BackupQualityProfileOutput       string
+	BackupQualityProfileError        error
+	ChangeQualityProfileParentError  error
+	ActivateRuleError                error
+	CreateQualityProfileCalled       bool
+	RestoreQualityProfileCalled      bool
+	ChangeQualityProfileParentCalled bool
+	ActivatedRules                   []string
 }
 
-func (r *APIClientMock) New(string) APIReader {
+func (r *APIClientMock) New(_ string, auth *Auth) APIReader {
+	r.Auth = auth
 	return r
 }
 
@@ -23,3 +89,136 @@ func (r *APIClientMock) Status() (*Status, error) {
 func (r *APIClientMock) Upgrades() (*Upgrades, error) {
 	return r.UpgradesOutput, r.UpgradesError
 }
+
+func (r *APIClientMock) MigrateDB() (*DBMigrationStatus, error) {
+	return r.MigrateDBOutput, r.MigrateDBError
+}
+
+func (r *APIClientMock) DBMigrationStatus() (*DBMigrationStatus, error) {
+	return r.DBMigrationStatusOutput, r.DBMigrationStatusError
+}
+
+func (r *APIClientMock) Validate() (bool, error) {
+	return r.ValidateOutput, r.ValidateError
+}
+
+func (r *APIClientMock) ChangePassword(string, string, string) error {
+	r.ChangePasswordCalled = true
+	return r.ChangePasswordError
+}
+
+func (r *APIClientMock) GenerateToken(string) (*UserToken, error) {
+	return r.GenerateTokenOutput, r.GenerateTokenError
+}
+
+func (r *APIClientMock) RevokeToken(name string) error {
+	r.RevokedTokens = append(r.RevokedTokens, name)
+	return r.RevokeTokenError
+}
+
+func (r *APIClientMock) GetProject(string) (*Project, error) {
+	return r.GetProjectOutput, r.GetProjectError
+}
+
+func (r *APIClientMock) CreateProject(key, name, visibility string) (*Project, error) {
+	r.CreateProjectCalled = true
+	return &Project{Key: key, Name: name, Visibility: visibility}, r.CreateProjectError
+}
+
+func (r *APIClientMock) UpdateProjectVisibility(string, string) error {
+	r.UpdateProjectVisibilityCalled = true
+	return r.UpdateProjectVisibilityError
+}
+
+func (r *APIClientMock) ProjectBranches(string) ([]ProjectBranch, error) {
+	return r.ProjectBranchesOutput, r.ProjectBranchesError
+}
+
+func (r *APIClientMock) RenameMainBranch(string, string) error {
+	r.RenameMainBranchCalled = true
+	return r.RenameMainBranchError
+}
+
+func (r *APIClientMock) ProjectQualityGate(string) (*QualityGate, error) {
+	return r.ProjectQualityGateOutput, r.ProjectQualityGateError
+}
+
+func (r *APIClientMock) SelectQualityGate(string, string) error {
+	r.SelectQualityGateCalled = true
+	return r.SelectQualityGateError
+}
+
+func (r *APIClientMock) ProjectQualityProfiles(string) ([]QualityProfile, error) {
+	return r.ProjectQualityProfilesOutput, r.ProjectQualityProfilesError
+}
+
+func (r *APIClientMock) AddProjectQualityProfile(string, string, string) error {
+	r.AddProjectQualityProfileCalled = true
+	return r.AddProjectQualityProfileError
+}
+
+func (r *APIClientMock) GetQualityGate(string) (*QualityGate, error) {
+	return r.GetQualityGateOutput, r.GetQualityGateError
+}
+
+func (r *APIClientMock) CreateQualityGate(name string) (*QualityGate, error) {
+	r.CreateQualityGateCalled = true
+	return &QualityGate{Name: name}, r.CreateQualityGateError
+}
+
+func (r *APIClientMock) CreateQualityGateCondition(string, QualityGateCondition) error {
+	r.CreateQualityGateConditionCalled = true
+	return r.CreateQualityGateConditionError
+}
+
+func (r *APIClientMock) UpdateQualityGateCondition(QualityGateCondition) error {
+	r.UpdateQualityGateConditionCalled = true
+	return r.UpdateQualityGateConditionError
+}
+
+func (r *APIClientMock) DeleteQualityGateCondition(ID) error {
+	r.DeleteQualityGateConditionCalled = true
+	return r.DeleteQualityGateConditionError
+}
+
+func (r *APIClientMock) SetDefaultQualityGate(string) error {
+	r.SetDefaultQualityGateCalled = true
+	return r.SetDefaultQualityGateError
+}
+
+func (r *APIClientMock) QualityGateProjects(string) ([]QualityGateProject, error) {
+	return r.QualityGateProjectsOutput, r.QualityGateProjectsError
+}
+
+func (r *APIClientMock) DeselectQualityGate(string) error {
+	r.DeselectQualityGateCalled = true
+	return r.DeselectQualityGateError
+}
+
+func (r *APIClientMock) GetQualityProfile(string, string) (*QualityProfile, error) {
+	return r.GetQualityProfileOutput, r.GetQualityProfileError
+}
+
+func (r *APIClientMock) CreateQualityProfile(language, name string) (*QualityProfile, error) {
+	r.CreateQualityProfileCalled = true
+	return &QualityProfile{Name: name, Language: language}, r.CreateQualityProfileError
+}
+
+func (r *APIClientMock) RestoreQualityProfile(string) (*QualityProfile, error) {
+	r.RestoreQualityProfileCalled = true
+	return r.GetQualityProfileOutput, r.RestoreQualityProfileError
+}
+
+func (r *APIClientMock) BackupQualityProfile(string, string) (string, error) {
+	return r.BackupQualityProfileOutput, r.BackupQualityProfileError
+}
+
+func (r *APIClientMock) ChangeQualityProfileParent(string, string, string) error {
+	r.ChangeQualityProfileParentCalled = true
+	return r.ChangeQualityProfileParentError
+}
+
+func (r *APIClientMock) ActivateRule(_, rule, _ string, _ map[string]string) error {
+	r.ActivatedRules = append(r.ActivatedRules, rule)
+	return r.ActivateRuleError
+}
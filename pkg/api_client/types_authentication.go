@@ -0,0 +1,12 @@
+package api_client
+
+type Validation struct {
+	Valid bool `json:"valid"`
+}
+
+type UserToken struct {
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Token     string `json:"token"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
@@ -0,0 +1,18 @@
+package api_client
+
+type DBMigrationStatus struct {
+	State     DBMigrationState `json:"state"`
+	Message   string           `json:"message,omitempty"`
+	StartedAt string           `json:"startedAt,omitempty"`
+}
+
+type DBMigrationState string
+
+const (
+	DBMigrationNone         DBMigrationState = "NO_MIGRATION"
+	DBMigrationNotSupported DBMigrationState = "NOT_SUPPORTED"
+	DBMigrationRequired     DBMigrationState = "MIGRATION_REQUIRED"
+	DBMigrationRunning      DBMigrationState = "MIGRATION_RUNNING"
+	DBMigrationSucceeded    DBMigrationState = "MIGRATION_SUCCEEDED"
+	DBMigrationFailed       DBMigrationState = "MIGRATION_FAILED"
+)
@@ -0,0 +1,27 @@
+package api_client
+
+type Project struct {
+	Key              string `json:"key"`
+	Name             string `json:"name"`
+	Qualifier        string `json:"qualifier,omitempty"`
+	Visibility       string `json:"visibility,omitempty"`
+	LastAnalysisDate string `json:"lastAnalysisDate,omitempty"`
+}
+
+type ProjectSearch struct {
+	Components []Project `json:"components"`
+}
+
+type ProjectCreate struct {
+	Project Project `json:"project"`
+}
+
+type ProjectBranch struct {
+	Name   string `json:"name"`
+	IsMain bool   `json:"isMain"`
+	Type   string `json:"type,omitempty"`
+}
+
+type ProjectBranches struct {
+	Branches []ProjectBranch `json:"branches"`
+}
@@ -0,0 +1,53 @@
+package api_client
+
+import (
+	"strings"
+)
+
+// ID is an identifier returned by the api, older SonarQube versions return numbers and newer strings
+type ID string
+
+func (r *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	*r = ID(strings.Trim(string(data), "\""))
+	return nil
+}
+
+type QualityGate struct {
+	ID         ID                     `json:"id,omitempty"`
+	Name       string                 `json:"name"`
+	Default    bool                   `json:"default,omitempty"`
+	IsDefault  bool                   `json:"isDefault,omitempty"`
+	IsBuiltIn  bool                   `json:"isBuiltIn,omitempty"`
+	Conditions []QualityGateCondition `json:"conditions,omitempty"`
+}
+
+type QualityGateCondition struct {
+	ID     ID     `json:"id,omitempty"`
+	Metric string `json:"metric"`
+	Op     string `json:"op"`
+	Error  string `json:"error"`
+}
+
+type QualityGateList struct {
+	QualityGates []QualityGate `json:"qualitygates"`
+}
+
+type ProjectQualityGate struct {
+	QualityGate QualityGate `json:"qualityGate"`
+}
+
+// QualityGateProjectsPageSize is the maximum page size of api/qualitygates/search
+const QualityGateProjectsPageSize = 500
+
+type QualityGateProject struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+}
+
+type QualityGateProjects struct {
+	Results []QualityGateProject `json:"results"`
+}
@@ -0,0 +1,62 @@
+package api_client
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type QualityProfile struct {
+	Key             string `json:"key"`
+	Name            string `json:"name"`
+	Language        string `json:"language"`
+	ParentKey       string `json:"parentKey,omitempty"`
+	ParentName      string `json:"parentName,omitempty"`
+	IsDefault       bool   `json:"isDefault,omitempty"`
+	IsBuiltIn       bool   `json:"isBuiltIn,omitempty"`
+	ActiveRuleCount int    `json:"activeRuleCount,omitempty"`
+}
+
+type QualityProfileSearch struct {
+	Profiles []QualityProfile `json:"profiles"`
+}
+
+type QualityProfileCreate struct {
+	Profile QualityProfile `json:"profile"`
+}
+
+// QualityProfileBackup is the xml document returned by api/qualityprofiles/backup
+type QualityProfileBackup struct {
+	XMLName  xml.Name                   `xml:"profile"`
+	Name     string                     `xml:"name"`
+	Language string                     `xml:"language"`
+	Rules    []QualityProfileBackupRule `xml:"rules>rule"`
+}
+
+type QualityProfileBackupRule struct {
+	RepositoryKey string                          `xml:"repositoryKey"`
+	Key           string                          `xml:"key"`
+	Priority      string                          `xml:"priority"`
+	Parameters    []QualityProfileBackupParameter `xml:"parameters>parameter"`
+}
+
+type QualityProfileBackupParameter struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+// RuleKey returns the key used by the api for the rule (repository:key)
+func (r QualityProfileBackupRule) RuleKey() string {
+	return fmt.Sprintf("%s:%s", r.RepositoryKey, r.Key)
+}
+
+func ParseQualityProfileBackup(data string) (*QualityProfileBackup, error) {
+	output := &QualityProfileBackup{}
+	err := xml.Unmarshal([]byte(data), output)
+	if err != nil {
+		return output, err
+	}
+	if output.Name == "" || output.Language == "" {
+		return output, fmt.Errorf("quality profile backup requires name and language")
+	}
+	return output, nil
+}
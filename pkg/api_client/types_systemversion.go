@@ -28,6 +28,9 @@ func (r *SystemVersion) UnmarshalJSON(data []byte) error {
 	}
 
 	parts := strings.Split(value, ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid version %s expected at least major.minor", value)
+	}
 
 	var major, minor, patch int64
 	var build string
@@ -63,6 +66,15 @@ func (r *SystemVersion) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func ParseSystemVersion(value string) (*SystemVersion, error) {
+	output := &SystemVersion{}
+	if value == "" {
+		return output, fmt.Errorf("empty version")
+	}
+	err := output.UnmarshalJSON([]byte(value))
+	return output, err
+}
+
 func (r *SystemVersion) MajorMinorPatch() string {
 	return fmt.Sprintf("%d.%d.%d", r.Major, r.Minor, r.Patch)
 }
@@ -15,6 +15,18 @@ const (
 	ConditionShutdown status.ConditionType = "Shutdown"
 	// ConditionUnavailable means that the application is not available.
 	ConditionUnavailable status.ConditionType = "Unavailable"
+	// ConditionDBMigration means that a database migration is required, running, or has failed.
+	ConditionDBMigration status.ConditionType = "DBMigration"
+	// ConditionServerStopped means that the server of a restore has been shutdown.
+	ConditionServerStopped status.ConditionType = "ServerStopped"
+	// ConditionDataRestored means that the database and extensions of a restore have been restored.
+	ConditionDataRestored status.ConditionType = "DataRestored"
+	// ConditionServerStarted means that the server of a restore has been started and is up.
+	ConditionServerStarted status.ConditionType = "ServerStarted"
+	// ConditionReady means that the spec was applied and the application reports it is up.
+	ConditionReady status.ConditionType = "Ready"
+	// ConditionAvailable means that pods of the application are available.
+	ConditionAvailable status.ConditionType = "Available"
 )
 
 // Condition Reasons
@@ -29,11 +41,29 @@ const (
 	ConditionSpecInvalid status.ConditionReason = "SpecInvalid"
 	// ConditionConfigured means that the current spec specified meeting this condition
 	ConditionConfigured status.ConditionReason = "Configured"
+	// ConditionDBMigrationRunning means that the database is being migrated to the current version
+	ConditionDBMigrationRunning status.ConditionReason = "DBMigrationRunning"
+	// ConditionDBMigrationFailed means that the database migration failed and requires manual intervention
+	ConditionDBMigrationFailed status.ConditionReason = "DBMigrationFailed"
+	// ConditionPhaseCompleted means that the phase of a restore reported by the condition has completed
+	ConditionPhaseCompleted status.ConditionReason = "PhaseCompleted"
+	// ConditionServerUp means that api/system/status reports the server as UP
+	ConditionServerUp status.ConditionReason = "ServerUp"
+	// ConditionDeploymentAvailable means that the pods of the Deployment are available
+	ConditionDeploymentAvailable status.ConditionReason = "DeploymentAvailable"
+	// ConditionDeploymentUnavailable means that no pod of the Deployment is available
+	ConditionDeploymentUnavailable status.ConditionReason = "DeploymentUnavailable"
 )
 
 const (
 	SecretAnnotation       = "sonarqube.sonarsource.parflesh.github.io/database"
 	ServerSecretAnnotation = "sonarqubeserver.sonarsource.parflesh.github.io/database"
+	// PropertiesAnnotation lists the sonar.properties entries rendered into a config secret by the operator
+	PropertiesAnnotation = "sonarsource.parflesh.github.io/properties"
+	// SecretsRevisionAnnotation is the hash of referenced secret keys on the pod template, a new hash rolls the pods
+	SecretsRevisionAnnotation = "sonarsource.parflesh.github.io/secrets-revision"
+	// BackupRevisionAnnotation is the hash of the job template of a backup CronJob, a new hash replaces the template
+	BackupRevisionAnnotation = "sonarsource.parflesh.github.io/backup-revision"
 )
 
 const (
@@ -45,6 +75,22 @@ const (
 	KubeAppName      = "app.kubernetes.io/name"
 	TypeLabel        = "sonarsource.parflesh.github.io/SonarQube"
 	ServerTypeLabel  = "sonarsource.parflesh.github.io/SonarQubeServer"
+	BackupTypeLabel  = "sonarsource.parflesh.github.io/SonarQubeBackup"
+	RestoreTypeLabel = "sonarsource.parflesh.github.io/SonarQubeRestore"
+	// SnapshotLabel is set to the name of the upgrade snapshot on its Jobs and VolumeSnapshot
+	SnapshotLabel = "sonarsource.parflesh.github.io/snapshot"
+)
+
+const (
+	ServerKindSonarQube       = "SonarQube"
+	ServerKindSonarQubeServer = "SonarQubeServer"
+)
+
+// Keys of the admin Secret
+const (
+	AdminSecretToken    = "token"
+	AdminSecretUsername = "username"
+	AdminSecretPassword = "password"
 )
 
 type ServerType string
@@ -60,6 +106,68 @@ const (
 	ApplicationPort    int32 = 9003
 	ApplicationCEPort  int32 = 9004
 	SearchPort         int32 = 9001
+	DatabasePort       int32 = 5432
+)
+
+// Keys of the managed database Secret
+const (
+	DatabaseSecretDatabase = "database"
+	DatabaseSecretUsername = "username"
+	DatabaseSecretPassword = "password"
+)
+
+// Files of a backup, each backup is a directory named after the Job that took it
+const (
+	BackupDatabaseFile   = "database.dump"
+	BackupExtensionsFile = "extensions.tar.gz"
+	BackupManifestFile   = "manifest.json"
+	// SnapshotVolumeFile is the archive of the PersistentVolumeClaim of an upgrade snapshot
+	SnapshotVolumeFile = "volume.tar.gz"
+)
+
+type ClusterPhase string
+
+const (
+	ClusterPhaseStartingDatabase        ClusterPhase = "StartingDatabase"
+	ClusterPhaseStartingSearch          ClusterPhase = "StartingSearch"
+	ClusterPhaseStartingApplication     ClusterPhase = "StartingApplication"
+	ClusterPhaseRunning                 ClusterPhase = "Running"
+	ClusterPhaseShuttingDownApplication ClusterPhase = "ShuttingDownApplication"
+	ClusterPhaseShuttingDownSearch      ClusterPhase = "ShuttingDownSearch"
+	ClusterPhaseShuttingDownDatabase    ClusterPhase = "ShuttingDownDatabase"
+	ClusterPhaseShutdown                ClusterPhase = "Shutdown"
+	ClusterPhaseUpgradeStopApplication  ClusterPhase = "UpgradeStoppingApplication"
+	ClusterPhaseUpgradeSearch           ClusterPhase = "UpgradingSearch"
+	ClusterPhaseUpgradeMigrateDatabase  ClusterPhase = "MigratingDatabase"
+	ClusterPhaseUpgradeApplication      ClusterPhase = "UpgradingApplication"
+)
+
+type RestorePhase string
+
+const (
+	RestorePhaseShuttingDown RestorePhase = "ShuttingDown"
+	RestorePhaseRestoring    RestorePhase = "Restoring"
+	RestorePhaseStarting     RestorePhase = "Starting"
+	RestorePhaseCompleted    RestorePhase = "Completed"
+)
+
+type ServerPhase string
+
+const (
+	ServerPhaseProgressing ServerPhase = "Progressing"
+	ServerPhaseMigrating   ServerPhase = "Migrating"
+	ServerPhaseRunning     ServerPhase = "Running"
+	ServerPhaseShutdown    ServerPhase = "Shutdown"
+	ServerPhaseInvalid     ServerPhase = "Invalid"
+)
+
+type SnapshotPhase string
+
+const (
+	SnapshotPhasePending     SnapshotPhase = "Pending"
+	SnapshotPhaseReady       SnapshotPhase = "Ready"
+	SnapshotPhaseRollingBack SnapshotPhase = "RollingBack"
+	SnapshotPhaseRolledBack  SnapshotPhase = "RolledBack"
 )
 
 type DeploymentStatuses map[DeploymentStatus][]string
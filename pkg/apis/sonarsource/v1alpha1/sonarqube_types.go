@@ -15,6 +15,13 @@ type SonarQubeSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:number"
 	Size int32 `json:"size"`
 
+	// Number of SonarQube search nodes, must be an odd number of at least 3 (default is 3)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Search Size"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:number,urn:alm:descriptor:com.tectonic.ui:advanced"
+	SearchSize *int32 `json:"searchSize,omitempty"`
+
 	// if empty operator will start latest version of selected edition
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
@@ -22,7 +29,7 @@ type SonarQubeSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
 	Version *string `json:"version,omitempty"`
 
-	// community, developer, or enterprise (default is community)
+	// datacenter, clustering requires the Data Center edition (default is datacenter)
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Edition"
@@ -53,6 +60,26 @@ type SonarQubeSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes:Secret"
 	Secret *string `json:"secret,omitempty"`
 
+	// sonar.properties entries rendered into the config secret, properties managed by the operator
+	// (sonar.cluster.*, sonar.web.port, sonar.path.*, sonar.search.host, sonar.search.port) are rejected
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Properties map[string]string `json:"properties,omitempty"`
+
+	// ConfigMaps and Secrets whose entries are rendered into the config secret as sonar.properties entries.
+	// Later sources override earlier sources and properties override all sources
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	PropertiesFrom []PropertiesSource `json:"propertiesFrom,omitempty"`
+
+	// Secret with admin credentials (token, or username and password) used for admin only api calls.
+	// If the secret does not exist the operator creates it, changes the default admin password, and stores a generated token
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Admin Secret"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes:Secret,urn:alm:descriptor:com.tectonic.ui:advanced"
+	AdminSecret *string `json:"adminSecret,omitempty"`
+
 	// Shutdown SonarQube cluster
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
@@ -74,6 +101,67 @@ type SonarQubeSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Service Account"
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
 	ServiceAccount *string `json:"serviceAccount,omitempty"`
+
+	// Plugins installed on application nodes
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Plugins []Plugin `json:"plugins,omitempty"`
+
+	// Expose SonarQube outside of the cluster with an Ingress or OpenShift Route.
+	// The resulting url is used as External URL (sonar.core.serverBaseURL) of application nodes
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Expose *Expose `json:"expose,omitempty"`
+
+	// Database of the SonarQube cluster, pods are restarted when referenced secret keys change
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Database *Database `json:"database,omitempty"`
+
+	// Snapshot of the database and the PersistentVolumeClaim of the application node migrating the database,
+	// taken before the database is migrated. With rollback the cluster returns to the previous version when the
+	// migration fails. Requires a PostgreSQL database
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Snapshot *UpgradeSnapshot `json:"snapshot,omitempty"`
+}
+
+// Database describes the database used by SonarQube
+type Database struct {
+	// PostgreSQL database deployed by the operator, sonar.jdbc.* properties are set to connect to it
+	// +optional
+	Managed *ManagedDatabase `json:"managed,omitempty"`
+
+	// Secret key with the JDBC url, set as SONAR_JDBC_URL
+	// +optional
+	URLFrom *corev1.SecretKeySelector `json:"urlFrom,omitempty"`
+
+	// Secret key with the JDBC username, set as SONAR_JDBC_USERNAME
+	// +optional
+	UsernameFrom *corev1.SecretKeySelector `json:"usernameFrom,omitempty"`
+
+	// Secret key with the JDBC password, set as SONAR_JDBC_PASSWORD
+	// +optional
+	PasswordFrom *corev1.SecretKeySelector `json:"passwordFrom,omitempty"`
+}
+
+// ManagedDatabase describes a PostgreSQL StatefulSet deployed by the operator
+type ManagedDatabase struct {
+	// PostgreSQL image (default is postgres:12)
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Storage class of the database volume
+	// +optional
+	StorageClass *string `json:"storageClass,omitempty"`
+
+	// Size of the database volume (default is 1Gi)
+	// +optional
+	StorageSize *string `json:"storageSize,omitempty"`
+
+	// Resource requirements of the database
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 type ClusterNodeConfig struct {
@@ -154,6 +242,10 @@ type SonarQubeStatus struct {
 	// +optional
 	Conditions status.Conditions `json:"conditions,omitempty"`
 
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Kubernetes service that can be used to expose SonarQube
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Service"
@@ -177,6 +269,73 @@ type SonarQubeStatus struct {
 
 	// Hash of latest revision for tracking
 	Revision string `json:"revision,omitempty"`
+
+	// External URL of SonarQube
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="URL"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:org.w3:link"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	URL string `json:"url,omitempty"`
+
+	// Version of SonarQube all nodes of the cluster run
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Version"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Version string `json:"version,omitempty"`
+
+	// Spec.Version the version was last resolved with, an empty string when Spec.Version was not set.
+	// A changed Spec.Version replaces the resolved version even when it is older
+	// +optional
+	SpecVersion *string `json:"specVersion,omitempty"`
+
+	// Version upgrade in progress, including automatic upgrades which leave Spec.Version unchanged
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=false
+	Upgrade *ClusterUpgrade `json:"upgrade,omitempty"`
+
+	// Snapshot the application node migrating the database rolled back to, the version the upgrade failed for is
+	// not upgraded to again until Spec.Version changes
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=false
+	Snapshot *UpgradeSnapshotStatus `json:"snapshot,omitempty"`
+
+	// Startup, shutdown, or upgrade phase of the cluster
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Phase"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	// Scale down operation in progress
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=false
+	Scale *ScaleStatus `json:"scale,omitempty"`
+}
+
+// ClusterUpgrade describes a rolling version upgrade of a SonarQube cluster
+type ClusterUpgrade struct {
+	// Version before the upgrade
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// Version after the upgrade
+	To string `json:"to"`
+}
+
+// ScaleStatus describes the removal of surplus SonarQube nodes
+type ScaleStatus struct {
+	// Type of nodes being removed (application or search)
+	Type ServerType `json:"type"`
+
+	// Number of nodes that currently exist
+	Nodes int32 `json:"nodes"`
+
+	// Number of nodes requested by spec
+	Size int32 `json:"size"`
+
+	// Node that is being drained and deleted
+	Node string `json:"node"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -184,6 +343,12 @@ type SonarQubeStatus struct {
 // SonarQube is the Schema for the sonarqubes API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=sonarqubes,scope=Namespaced
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Edition",type=string,JSONPath=`.spec.edition`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Cluster"
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="SonarQube,v1alpha1,\"\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Service,v1,\"\""
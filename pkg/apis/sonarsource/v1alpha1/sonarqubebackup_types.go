@@ -0,0 +1,126 @@
+package v1alpha1
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SonarQubeBackupSpec defines the desired state of SonarQubeBackup
+type SonarQubeBackupSpec struct {
+	// SonarQubeServer or SonarQube that is backed up
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Server"
+	ServerRef ServerReference `json:"serverRef"`
+
+	// Storage backups are written to
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Storage BackupStorage `json:"storage"`
+
+	// Cron schedule of backups, a single backup is taken when not set (ex 0 2 * * *)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Schedule"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Schedule *string `json:"schedule,omitempty"`
+
+	// Number of backups kept in storage, older backups are removed (default is 7)
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Retention"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:number"
+	Retention *int32 `json:"retention,omitempty"`
+
+	// PostgreSQL image used to dump the database, pg_dump must not be older than the database (default is postgres:12)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
+	Image *string `json:"image,omitempty"`
+}
+
+// BackupStorage is a PersistentVolumeClaim or an S3 compatible object store, exactly one must be set
+type BackupStorage struct {
+	// Name of a PersistentVolumeClaim backups are written to
+	// +optional
+	PersistentVolumeClaim *string `json:"persistentVolumeClaim,omitempty"`
+
+	// S3 compatible object store backups are uploaded to
+	// +optional
+	S3 *S3Storage `json:"s3,omitempty"`
+}
+
+type S3Storage struct {
+	// Endpoint of the object store (ex http://minio:9000)
+	Endpoint string `json:"endpoint"`
+
+	// Bucket backups are uploaded to
+	Bucket string `json:"bucket"`
+
+	// Prefix of the backups in the bucket
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+
+	// Secret with the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY of the object store
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// MinIO client image used to upload backups (default is minio/mc)
+	// +optional
+	Image *string `json:"image,omitempty"`
+}
+
+// SonarQubeBackupStatus defines the observed state of SonarQubeBackup
+type SonarQubeBackupStatus struct {
+	// Conditions represent the latest available observations of an object's state
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Location backups are written to
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Location"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Location string `json:"location,omitempty"`
+
+	// Name of the last completed backup in Location
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Last Backup"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	LastBackup string `json:"lastBackup,omitempty"`
+
+	// Completion time of the last backup
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeBackup is the Schema for the sonarqubebackups API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sonarqubebackups,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Backup"
+type SonarQubeBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SonarQubeBackupSpec   `json:"spec,omitempty"`
+	Status SonarQubeBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeBackupList contains a list of SonarQubeBackup
+type SonarQubeBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SonarQubeBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SonarQubeBackup{}, &SonarQubeBackupList{})
+}
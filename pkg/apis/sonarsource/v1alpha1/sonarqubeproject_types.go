@@ -0,0 +1,130 @@
+package v1alpha1
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SonarQubeProjectSpec defines the desired state of SonarQubeProject
+type SonarQubeProjectSpec struct {
+	// SonarQubeServer or SonarQube the project is provisioned on
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Server"
+	ServerRef ServerReference `json:"serverRef"`
+
+	// Project key
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Key"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Key string `json:"key"`
+
+	// Project name (default is key)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Name *string `json:"name,omitempty"`
+
+	// public or private (default is server default)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Visibility"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:select:public,urn:alm:descriptor:com.tectonic.ui:select:private"
+	Visibility *ProjectVisibility `json:"visibility,omitempty"`
+
+	// Name of the main branch (default is master)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Main Branch"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
+	MainBranch *string `json:"mainBranch,omitempty"`
+
+	// Name of the quality gate assigned to the project (default is server default)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Quality Gate"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
+	QualityGate *string `json:"qualityGate,omitempty"`
+
+	// Quality profiles assigned to the project, one per language (default is server default)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	QualityProfiles []ProjectQualityProfile `json:"qualityProfiles,omitempty"`
+}
+
+// ServerReference points to a SonarQubeServer or SonarQube in the same namespace
+type ServerReference struct {
+	// SonarQubeServer or SonarQube (default is SonarQubeServer)
+	// +optional
+	Kind *string `json:"kind,omitempty"`
+
+	// Name of the SonarQubeServer or SonarQube
+	Name string `json:"name"`
+}
+
+type ProjectQualityProfile struct {
+	// Language key (ex java)
+	Language string `json:"language"`
+
+	// Name of the quality profile
+	Name string `json:"name"`
+}
+
+type ProjectVisibility string
+
+const (
+	ProjectPublic  ProjectVisibility = "public"
+	ProjectPrivate ProjectVisibility = "private"
+)
+
+// SonarQubeProjectStatus defines the observed state of SonarQubeProject
+type SonarQubeProjectStatus struct {
+	// Conditions represent the latest available observations of an object's state
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// URL of the project dashboard
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="URL"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:org.w3:link"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	URL string `json:"url,omitempty"`
+
+	// Date of the last analysis reported by the server
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Last Analysis"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	LastAnalysisDate string `json:"lastAnalysisDate,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeProject is the Schema for the sonarqubeprojects API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sonarqubeprojects,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Project"
+type SonarQubeProject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SonarQubeProjectSpec   `json:"spec,omitempty"`
+	Status SonarQubeProjectStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeProjectList contains a list of SonarQubeProject
+type SonarQubeProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SonarQubeProject `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SonarQubeProject{}, &SonarQubeProjectList{})
+}
@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SonarQubeQualityGateSpec defines the desired state of SonarQubeQualityGate
+type SonarQubeQualityGateSpec struct {
+	// SonarQubeServer or SonarQube the quality gate is provisioned on
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Server"
+	ServerRef ServerReference `json:"serverRef"`
+
+	// Quality gate name (default is resource name)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Name *string `json:"name,omitempty"`
+
+	// Metric conditions, conditions on the server that are not listed are removed
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Conditions []QualityGateCondition `json:"conditions,omitempty"`
+
+	// Use as default quality gate of the server
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Default"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:booleanSwitch"
+	Default *bool `json:"default,omitempty"`
+
+	// Keys of projects using the quality gate, if set projects that are not listed are removed from the quality gate
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Projects []string `json:"projects,omitempty"`
+}
+
+type QualityGateCondition struct {
+	// Metric key (ex coverage)
+	Metric string `json:"metric"`
+
+	// GT (greater than) or LT (less than)
+	Operator QualityGateOperator `json:"operator"`
+
+	// Value that fails the quality gate
+	Threshold string `json:"threshold"`
+}
+
+type QualityGateOperator string
+
+const (
+	QualityGateGreaterThan QualityGateOperator = "GT"
+	QualityGateLessThan    QualityGateOperator = "LT"
+)
+
+// SonarQubeQualityGateStatus defines the observed state of SonarQubeQualityGate
+type SonarQubeQualityGateStatus struct {
+	// Conditions represent the latest available observations of an object's state
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeQualityGate is the Schema for the sonarqubequalitygates API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sonarqubequalitygates,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Quality Gate"
+type SonarQubeQualityGate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SonarQubeQualityGateSpec   `json:"spec,omitempty"`
+	Status SonarQubeQualityGateStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeQualityGateList contains a list of SonarQubeQualityGate
+type SonarQubeQualityGateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SonarQubeQualityGate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SonarQubeQualityGate{}, &SonarQubeQualityGateList{})
+}
@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SonarQubeQualityProfileSpec defines the desired state of SonarQubeQualityProfile
+type SonarQubeQualityProfileSpec struct {
+	// SonarQubeServer or SonarQube the quality profile is provisioned on
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Server"
+	ServerRef ServerReference `json:"serverRef"`
+
+	// Quality profile name (default is resource name)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Name"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Name *string `json:"name,omitempty"`
+
+	// Language key (ex java), required unless backup is set
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Language"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Language *string `json:"language,omitempty"`
+
+	// Quality profile backup xml (as exported by SonarQube), restored whenever the active rules drift.
+	// Parent and rules are ignored when set
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Backup"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
+	Backup *string `json:"backup,omitempty"`
+
+	// Name of the parent quality profile to inherit rules from, the parent is removed when not set
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Parent"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Parent *string `json:"parent,omitempty"`
+
+	// Rules activated in the quality profile
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Rules []QualityProfileRule `json:"rules,omitempty"`
+}
+
+type QualityProfileRule struct {
+	// Rule key (ex java:S1067)
+	Key string `json:"key"`
+
+	// INFO, MINOR, MAJOR, CRITICAL, or BLOCKER (default is rule default)
+	// +optional
+	Severity *string `json:"severity,omitempty"`
+
+	// Rule parameters
+	// +optional
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// SonarQubeQualityProfileStatus defines the observed state of SonarQubeQualityProfile
+type SonarQubeQualityProfileStatus struct {
+	// Conditions represent the latest available observations of an object's state
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Key of the quality profile on the server
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Key"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Key string `json:"key,omitempty"`
+
+	// Differences between spec and server found by the last drift correction, cleared once the server matches the spec
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=false
+	Drift []string `json:"drift,omitempty"`
+
+	// Time of the last drift correction
+	// +optional
+	DriftCorrected *metav1.Time `json:"driftCorrected,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeQualityProfile is the Schema for the sonarqubequalityprofiles API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sonarqubequalityprofiles,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Quality Profile"
+type SonarQubeQualityProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SonarQubeQualityProfileSpec   `json:"spec,omitempty"`
+	Status SonarQubeQualityProfileStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeQualityProfileList contains a list of SonarQubeQualityProfile
+type SonarQubeQualityProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SonarQubeQualityProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SonarQubeQualityProfile{}, &SonarQubeQualityProfileList{})
+}
@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SonarQubeRestoreSpec defines the desired state of SonarQubeRestore
+type SonarQubeRestoreSpec struct {
+	// SonarQubeServer that is restored, servers of a SonarQube cluster can not be restored
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Server"
+	ServerRef ServerReference `json:"serverRef"`
+
+	// Name of the SonarQubeBackup whose storage the backup is read from
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Backup"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	Backup string `json:"backup"`
+
+	// Name of the backup in storage that is restored (default is the last backup of the SonarQubeBackup)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Backup Name"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
+	BackupName *string `json:"backupName,omitempty"`
+
+	// PostgreSQL image used to restore the database, pg_restore must not be older than the dump (default is postgres:12)
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Image"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
+	Image *string `json:"image,omitempty"`
+}
+
+// SonarQubeRestoreStatus defines the observed state of SonarQubeRestore
+type SonarQubeRestoreStatus struct {
+	// Conditions represent the latest available observations of an object's state
+	// +optional
+	Conditions status.Conditions `json:"conditions,omitempty"`
+
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Current phase of the restore
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Phase"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Phase RestorePhase `json:"phase,omitempty"`
+
+	// Name of the backup that is restored, set once the restore started
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Backup Name"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	BackupName string `json:"backupName,omitempty"`
+
+	// Spec.Shutdown of the server before the restore, the server is set back to it once the data was restored
+	// +optional
+	ServerShutdown *bool `json:"serverShutdown,omitempty"`
+
+	// Time the server reported it is up after the restore
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeRestore is the Schema for the sonarquberestores API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sonarquberestores,scope=Namespaced
+// +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Restore"
+type SonarQubeRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SonarQubeRestoreSpec   `json:"spec,omitempty"`
+	Status SonarQubeRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SonarQubeRestoreList contains a list of SonarQubeRestore
+type SonarQubeRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SonarQubeRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SonarQubeRestore{}, &SonarQubeRestoreList{})
+}
@@ -22,12 +22,12 @@ type SonarQubeServerSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
 	Version *string `json:"version,omitempty"`
 
-	// community, developer, or enterprise (default is community)
+	// community, developer, enterprise, or datacenter (default is community, datacenter for application and search nodes)
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Edition"
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text,urn:alm:descriptor:com.tectonic.ui:advanced"
-	// +kubebuilder:validation:Enum=community;developer;enterprise
+	// +kubebuilder:validation:Enum=community;developer;enterprise;datacenter
 	Edition *string `json:"edition,omitempty"`
 
 	// Automatically apply minor version updates
@@ -44,6 +44,12 @@ type SonarQubeServerSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:checkbox,urn:alm:descriptor:com.tectonic.ui:advanced,urn:alm:descriptor:com.tectonic.ui:fieldGroup:updates"
 	UpdatesMajor *bool `json:"updatesMajor,omitempty"`
 
+	// Snapshot of the database and PersistentVolumeClaim taken before the version of the image changes,
+	// the upgrade waits for the snapshot to succeed. Requires a PostgreSQL database
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Snapshot *UpgradeSnapshot `json:"snapshot,omitempty"`
+
 	// Secret with sonar configuration files (sonar.properties, wrapper.properties).
 	// Don't add cluster properties to configuration files as this could cause unexpected results
 	// +optional
@@ -54,6 +60,26 @@ type SonarQubeServerSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes:Secret"
 	Secret *string `json:"secret,omitempty"`
 
+	// sonar.properties entries rendered into the config secret, properties managed by the operator
+	// (sonar.cluster.*, sonar.web.port, sonar.path.*, sonar.search.host, sonar.search.port) are rejected
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Properties map[string]string `json:"properties,omitempty"`
+
+	// ConfigMaps and Secrets whose entries are rendered into the config secret as sonar.properties entries.
+	// Later sources override earlier sources and properties override all sources
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	PropertiesFrom []PropertiesSource `json:"propertiesFrom,omitempty"`
+
+	// Secret with admin credentials (token, or username and password) used for admin only api calls.
+	// If the secret does not exist the operator creates it, changes the default admin password, and stores a generated token
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.displayName="Admin Secret"
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes:Secret,urn:alm:descriptor:com.tectonic.ui:advanced"
+	AdminSecret *string `json:"adminSecret,omitempty"`
+
 	// Sonar Node Type application or search when clustering is enabled otherwise aio (all-in-one)
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=true
@@ -90,9 +116,50 @@ type SonarQubeServerSpec struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
 	ExternalURL *string `json:"externalURL,omitempty"`
 
+	// Public base URL set as sonar.core.serverBaseURL and reported in status when externalURL is not set.
+	// Unlike externalURL it is not used to reach the server api, SonarQube sets it on application nodes
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	ServerBaseURL *string `json:"serverBaseURL,omitempty"`
+
 	// Node Configuration
 	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
 	NodeConfig NodeConfig `json:"nodeConfig,omitempty"`
+
+	// Plugins installed in extensions/plugins before SonarQube starts.
+	// Plugins installed by the operator that are no longer listed are removed from extensions/plugins
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Plugins []Plugin `json:"plugins,omitempty"`
+
+	// Expose SonarQube outside of the cluster with an Ingress or OpenShift Route.
+	// The resulting url is used as External URL (sonar.core.serverBaseURL) when externalURL and serverBaseURL are not set
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Expose *Expose `json:"expose,omitempty"`
+
+	// Database of the server, pods are restarted when referenced secret keys change.
+	// Managed databases are only supported by SonarQube clusters
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.specDescriptors=false
+	Database *Database `json:"database,omitempty"`
+}
+
+type Plugin struct {
+	// Plugin key (ex java)
+	Key string `json:"key"`
+
+	// Plugin version, downloaded from the SonarQube update center when url is not set
+	// +optional
+	Version *string `json:"version,omitempty"`
+
+	// Plugin jar download url
+	// +optional
+	URL *string `json:"url,omitempty"`
+
+	// SHA-256 checksum of the plugin jar
+	// +optional
+	Checksum *string `json:"checksum,omitempty"`
 }
 
 type NodeConfig struct {
@@ -153,6 +220,65 @@ type NodeConfig struct {
 	StorageSize *string `json:"storageSize,omitempty"`
 }
 
+type Expose struct {
+	// Host name SonarQube is exposed on
+	Host string `json:"host"`
+
+	// Serve SonarQube over https (default is true when tlsSecret is set)
+	// +optional
+	TLS *bool `json:"tls,omitempty"`
+
+	// Secret with tls.crt and tls.key, the ingress controller or router default certificate is used when not set
+	// +optional
+	TLSSecret *string `json:"tlsSecret,omitempty"`
+
+	// Annotations added to the Ingress or Route
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Ingress class (kubernetes.io/ingress.class annotation)
+	// +optional
+	IngressClass *string `json:"ingressClass,omitempty"`
+
+	// Create an OpenShift Route instead of an Ingress
+	// +optional
+	Route *ExposeRoute `json:"route,omitempty"`
+}
+
+type ExposeRoute struct {
+	// Policy for http traffic when tls is enabled, None, Allow, or Redirect (default is Redirect)
+	// +optional
+	InsecureEdgeTerminationPolicy *string `json:"insecureEdgeTerminationPolicy,omitempty"`
+}
+
+type PropertiesSource struct {
+	// ConfigMap whose entries are rendered as sonar.properties entries
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// Secret whose entries are rendered as sonar.properties entries
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// UpgradeSnapshot configures the snapshot taken before upgrades.
+// The PersistentVolumeClaim is snapshot with a VolumeSnapshot when a VolumeSnapshotClass supports its StorageClass,
+// otherwise it is archived by the Job dumping the database. Dumps and archives are kept in the claim
+type UpgradeSnapshot struct {
+	// VolumeSnapshotClass used for the PersistentVolumeClaim (default is the class with the driver of the StorageClass)
+	// +optional
+	VolumeSnapshotClass *string `json:"volumeSnapshotClass,omitempty"`
+
+	// PostgreSQL image used to dump and restore the database (default is postgres:12)
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Restore the database and volume of the snapshot and return to the previous version when the
+	// database migration fails
+	// +optional
+	Rollback *bool `json:"rollback,omitempty"`
+}
+
 // SonarQubeServerStatus defines the observed state of SonarQubeServer
 type SonarQubeServerStatus struct {
 	// Conditions represent the latest available observations of an object's state
@@ -164,6 +290,13 @@ type SonarQubeServerStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	Service string `json:"service,omitempty"`
 
+	// Stable DNS name of the node used by other cluster members, resolved through a headless service
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Host"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Host string `json:"host,omitempty"`
+
 	// Status of pods
 	// +optional
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Pod Statuses"
@@ -174,18 +307,93 @@ type SonarQubeServerStatus struct {
 	// Hash of latest spec & controller version for revision tracking
 	Revision string `json:"revision,omitempty"`
 
+	// Generation of the spec that was last reconciled successfully
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase of the server derived from the last reconcile
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Phase"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:text"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	Phase ServerPhase `json:"phase,omitempty"`
+
 	// Current observed version of SonarQube
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Observed Version"
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:com.tectonic.ui:text"
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=false
 	ObservedVersion string `json:"observedVersion,omitempty"`
 
+	// Version the image is built from, Spec.Version or the version reported by the server when not set.
+	// Automatic upgrades and rollbacks are recorded here without changing Spec.Version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Spec.Version the version was last resolved with, an empty string when Spec.Version was not set.
+	// A changed Spec.Version replaces the resolved version even when it is older
+	// +optional
+	SpecVersion *string `json:"specVersion,omitempty"`
+
 	Upgrades Upgrades `json:"upgrades,omitempty"`
+
+	// Snapshot taken before the last upgrade
+	// +optional
+	Snapshot *UpgradeSnapshotStatus `json:"snapshot,omitempty"`
+
+	// External URL of SonarQubeServer
+	// +optional
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="URL"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:org.w3:link"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	URL string `json:"url,omitempty"`
 }
 
 type Upgrades struct {
 	Compatible   []string `json:"compatible,omitempty"`
 	Incompatible []string `json:"incompatible,omitempty"`
+
+	// Upgrades applied automatically by the operator, oldest first
+	// +optional
+	History []UpgradeStep `json:"history,omitempty"`
+}
+
+type UpgradeStep struct {
+	// Version before the upgrade
+	From string `json:"from"`
+
+	// Version after the upgrade
+	To string `json:"to"`
+
+	// Time the upgrade was applied
+	Time metav1.Time `json:"time"`
+}
+
+// UpgradeSnapshotStatus references the snapshot taken before an upgrade
+type UpgradeSnapshotStatus struct {
+	// Name of the snapshot, the Job and the directory in snapshots of the PersistentVolumeClaim are named after it
+	Name string `json:"name"`
+
+	// Version before the upgrade
+	From string `json:"from"`
+
+	// Version after the upgrade
+	To string `json:"to"`
+
+	// VolumeSnapshot of the PersistentVolumeClaim taken once the Job dumped the database, the claim is restored from
+	// it on rollback. The volume is archived by the Job when not set
+	// +optional
+	VolumeSnapshot string `json:"volumeSnapshot,omitempty"`
+
+	// VolumeSnapshotClass of VolumeSnapshot
+	// +optional
+	VolumeSnapshotClass string `json:"volumeSnapshotClass,omitempty"`
+
+	// Phase of the snapshot
+	Phase SnapshotPhase `json:"phase"`
+
+	// Time the snapshot succeeded
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -193,6 +401,12 @@ type Upgrades struct {
 // SonarQubeServer is the Schema for the sonarqubeservers API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=sonarqubeservers,scope=Namespaced
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Edition",type=string,JSONPath=`.spec.edition`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +operator-sdk:gen-csv:customresourcedefinitions.displayName="SonarQube Server"
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Service,v1,\"\""
 // +operator-sdk:gen-csv:customresourcedefinitions.resources="Secret,v1,\"\""
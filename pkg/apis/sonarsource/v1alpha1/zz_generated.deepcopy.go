@@ -10,6 +10,32 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorage) DeepCopyInto(out *BackupStorage) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(string)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3Storage)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorage.
+func (in *BackupStorage) DeepCopy() *BackupStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterNodeConfig) DeepCopyInto(out *ClusterNodeConfig) {
 	*out = *in
@@ -88,6 +114,58 @@ func (in *ClusterNodeConfigAdvanced) DeepCopy() *ClusterNodeConfigAdvanced {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterUpgrade) DeepCopyInto(out *ClusterUpgrade) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterUpgrade.
+func (in *ClusterUpgrade) DeepCopy() *ClusterUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Database) DeepCopyInto(out *Database) {
+	*out = *in
+	if in.Managed != nil {
+		in, out := &in.Managed, &out.Managed
+		*out = new(ManagedDatabase)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.URLFrom != nil {
+		in, out := &in.URLFrom, &out.URLFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UsernameFrom != nil {
+		in, out := &in.UsernameFrom, &out.UsernameFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PasswordFrom != nil {
+		in, out := &in.PasswordFrom, &out.PasswordFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
+func (in *Database) DeepCopy() *Database {
+	if in == nil {
+		return nil
+	}
+	out := new(Database)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in DeploymentStatuses) DeepCopyInto(out *DeploymentStatuses) {
 	{
@@ -104,114 +182,960 @@ func (in DeploymentStatuses) DeepCopyInto(out *DeploymentStatuses) {
 			}
 			(*out)[key] = outVal
 		}
-		return
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentStatuses.
+func (in DeploymentStatuses) DeepCopy() DeploymentStatuses {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentStatuses)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Expose) DeepCopyInto(out *Expose) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TLSSecret != nil {
+		in, out := &in.TLSSecret, &out.TLSSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IngressClass != nil {
+		in, out := &in.IngressClass, &out.IngressClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(ExposeRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Expose.
+func (in *Expose) DeepCopy() *Expose {
+	if in == nil {
+		return nil
+	}
+	out := new(Expose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposeRoute) DeepCopyInto(out *ExposeRoute) {
+	*out = *in
+	if in.InsecureEdgeTerminationPolicy != nil {
+		in, out := &in.InsecureEdgeTerminationPolicy, &out.InsecureEdgeTerminationPolicy
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeRoute.
+func (in *ExposeRoute) DeepCopy() *ExposeRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedDatabase) DeepCopyInto(out *ManagedDatabase) {
+	*out = *in
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	if in.StorageClass != nil {
+		in, out := &in.StorageClass, &out.StorageClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.StorageSize != nil {
+		in, out := &in.StorageSize, &out.StorageSize
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedDatabase.
+func (in *ManagedDatabase) DeepCopy() *ManagedDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfig) DeepCopyInto(out *NodeConfig) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(map[string]string)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]string, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.NodeAffinity != nil {
+		in, out := &in.NodeAffinity, &out.NodeAffinity
+		*out = new(v1.NodeAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodAffinity != nil {
+		in, out := &in.PodAffinity, &out.PodAffinity
+		*out = new(v1.PodAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodAntiAffinity != nil {
+		in, out := &in.PodAntiAffinity, &out.PodAntiAffinity
+		*out = new(v1.PodAntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PriorityClass != nil {
+		in, out := &in.PriorityClass, &out.PriorityClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageClass != nil {
+		in, out := &in.StorageClass, &out.StorageClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.StorageSize != nil {
+		in, out := &in.StorageSize, &out.StorageSize
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfig.
+func (in *NodeConfig) DeepCopy() *NodeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Plugin) DeepCopyInto(out *Plugin) {
+	*out = *in
+	if in.Version != nil {
+		in, out := &in.Version, &out.Version
+		*out = new(string)
+		**out = **in
+	}
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(string)
+		**out = **in
+	}
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugin.
+func (in *Plugin) DeepCopy() *Plugin {
+	if in == nil {
+		return nil
+	}
+	out := new(Plugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in PodStatuses) DeepCopyInto(out *PodStatuses) {
+	{
+		in := &in
+		*out = make(PodStatuses, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodStatuses.
+func (in PodStatuses) DeepCopy() PodStatuses {
+	if in == nil {
+		return nil
+	}
+	out := new(PodStatuses)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectQualityProfile) DeepCopyInto(out *ProjectQualityProfile) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectQualityProfile.
+func (in *ProjectQualityProfile) DeepCopy() *ProjectQualityProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectQualityProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropertiesSource) DeepCopyInto(out *PropertiesSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropertiesSource.
+func (in *PropertiesSource) DeepCopy() *PropertiesSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PropertiesSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QualityGateCondition) DeepCopyInto(out *QualityGateCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QualityGateCondition.
+func (in *QualityGateCondition) DeepCopy() *QualityGateCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(QualityGateCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QualityProfileRule) DeepCopyInto(out *QualityProfileRule) {
+	*out = *in
+	if in.Severity != nil {
+		in, out := &in.Severity, &out.Severity
+		*out = new(string)
+		**out = **in
+	}
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QualityProfileRule.
+func (in *QualityProfileRule) DeepCopy() *QualityProfileRule {
+	if in == nil {
+		return nil
+	}
+	out := new(QualityProfileRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Storage) DeepCopyInto(out *S3Storage) {
+	*out = *in
+	if in.Prefix != nil {
+		in, out := &in.Prefix, &out.Prefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Storage.
+func (in *S3Storage) DeepCopy() *S3Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleStatus) DeepCopyInto(out *ScaleStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleStatus.
+func (in *ScaleStatus) DeepCopy() *ScaleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerReference) DeepCopyInto(out *ServerReference) {
+	*out = *in
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerReference.
+func (in *ServerReference) DeepCopy() *ServerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQube) DeepCopyInto(out *SonarQube) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQube.
+func (in *SonarQube) DeepCopy() *SonarQube {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQube)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQube) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeBackup) DeepCopyInto(out *SonarQubeBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeBackup.
+func (in *SonarQubeBackup) DeepCopy() *SonarQubeBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeBackupList) DeepCopyInto(out *SonarQubeBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SonarQubeBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeBackupList.
+func (in *SonarQubeBackupList) DeepCopy() *SonarQubeBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeBackupSpec) DeepCopyInto(out *SonarQubeBackupSpec) {
+	*out = *in
+	in.ServerRef.DeepCopyInto(&out.ServerRef)
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(string)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeBackupSpec.
+func (in *SonarQubeBackupSpec) DeepCopy() *SonarQubeBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeBackupStatus) DeepCopyInto(out *SonarQubeBackupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastBackupTime != nil {
+		in, out := &in.LastBackupTime, &out.LastBackupTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeBackupStatus.
+func (in *SonarQubeBackupStatus) DeepCopy() *SonarQubeBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeList) DeepCopyInto(out *SonarQubeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SonarQube, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeList.
+func (in *SonarQubeList) DeepCopy() *SonarQubeList {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeProject) DeepCopyInto(out *SonarQubeProject) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeProject.
+func (in *SonarQubeProject) DeepCopy() *SonarQubeProject {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeProject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeProject) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeProjectList) DeepCopyInto(out *SonarQubeProjectList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SonarQubeProject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeProjectList.
+func (in *SonarQubeProjectList) DeepCopy() *SonarQubeProjectList {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeProjectList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeProjectList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeProjectSpec) DeepCopyInto(out *SonarQubeProjectSpec) {
+	*out = *in
+	in.ServerRef.DeepCopyInto(&out.ServerRef)
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Visibility != nil {
+		in, out := &in.Visibility, &out.Visibility
+		*out = new(ProjectVisibility)
+		**out = **in
+	}
+	if in.MainBranch != nil {
+		in, out := &in.MainBranch, &out.MainBranch
+		*out = new(string)
+		**out = **in
+	}
+	if in.QualityGate != nil {
+		in, out := &in.QualityGate, &out.QualityGate
+		*out = new(string)
+		**out = **in
+	}
+	if in.QualityProfiles != nil {
+		in, out := &in.QualityProfiles, &out.QualityProfiles
+		*out = make([]ProjectQualityProfile, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeProjectSpec.
+func (in *SonarQubeProjectSpec) DeepCopy() *SonarQubeProjectSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeProjectSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeProjectStatus) DeepCopyInto(out *SonarQubeProjectStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeProjectStatus.
+func (in *SonarQubeProjectStatus) DeepCopy() *SonarQubeProjectStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeProjectStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityGate) DeepCopyInto(out *SonarQubeQualityGate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityGate.
+func (in *SonarQubeQualityGate) DeepCopy() *SonarQubeQualityGate {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeQualityGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeQualityGate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityGateList) DeepCopyInto(out *SonarQubeQualityGateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SonarQubeQualityGate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityGateList.
+func (in *SonarQubeQualityGateList) DeepCopy() *SonarQubeQualityGateList {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeQualityGateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeQualityGateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityGateSpec) DeepCopyInto(out *SonarQubeQualityGateSpec) {
+	*out = *in
+	in.ServerRef.DeepCopyInto(&out.ServerRef)
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]QualityGateCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Projects != nil {
+		in, out := &in.Projects, &out.Projects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityGateSpec.
+func (in *SonarQubeQualityGateSpec) DeepCopy() *SonarQubeQualityGateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeQualityGateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityGateStatus) DeepCopyInto(out *SonarQubeQualityGateStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityGateStatus.
+func (in *SonarQubeQualityGateStatus) DeepCopy() *SonarQubeQualityGateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeQualityGateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityProfile) DeepCopyInto(out *SonarQubeQualityProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityProfile.
+func (in *SonarQubeQualityProfile) DeepCopy() *SonarQubeQualityProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeQualityProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeQualityProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeQualityProfileList) DeepCopyInto(out *SonarQubeQualityProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SonarQubeQualityProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
+	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentStatuses.
-func (in DeploymentStatuses) DeepCopy() DeploymentStatuses {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityProfileList.
+func (in *SonarQubeQualityProfileList) DeepCopy() *SonarQubeQualityProfileList {
 	if in == nil {
 		return nil
 	}
-	out := new(DeploymentStatuses)
+	out := new(SonarQubeQualityProfileList)
 	in.DeepCopyInto(out)
-	return *out
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SonarQubeQualityProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeConfig) DeepCopyInto(out *NodeConfig) {
+func (in *SonarQubeQualityProfileSpec) DeepCopyInto(out *SonarQubeQualityProfileSpec) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = new(map[string]string)
-		if **in != nil {
-			in, out := *in, *out
-			*out = make(map[string]string, len(*in))
-			for key, val := range *in {
-				(*out)[key] = val
-			}
-		}
-	}
-	if in.NodeAffinity != nil {
-		in, out := &in.NodeAffinity, &out.NodeAffinity
-		*out = new(v1.NodeAffinity)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PodAffinity != nil {
-		in, out := &in.PodAffinity, &out.PodAffinity
-		*out = new(v1.PodAffinity)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PodAntiAffinity != nil {
-		in, out := &in.PodAntiAffinity, &out.PodAntiAffinity
-		*out = new(v1.PodAntiAffinity)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PriorityClass != nil {
-		in, out := &in.PriorityClass, &out.PriorityClass
+	in.ServerRef.DeepCopyInto(&out.ServerRef)
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
 		*out = new(string)
 		**out = **in
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	if in.Language != nil {
+		in, out := &in.Language, &out.Language
+		*out = new(string)
+		**out = **in
 	}
-	if in.StorageClass != nil {
-		in, out := &in.StorageClass, &out.StorageClass
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
 		*out = new(string)
 		**out = **in
 	}
-	if in.StorageSize != nil {
-		in, out := &in.StorageSize, &out.StorageSize
+	if in.Parent != nil {
+		in, out := &in.Parent, &out.Parent
 		*out = new(string)
 		**out = **in
 	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]QualityProfileRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfig.
-func (in *NodeConfig) DeepCopy() *NodeConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityProfileSpec.
+func (in *SonarQubeQualityProfileSpec) DeepCopy() *SonarQubeQualityProfileSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeConfig)
+	out := new(SonarQubeQualityProfileSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in PodStatuses) DeepCopyInto(out *PodStatuses) {
-	{
-		in := &in
-		*out = make(PodStatuses, len(*in))
-		for key, val := range *in {
-			var outVal []string
-			if val == nil {
-				(*out)[key] = nil
-			} else {
-				in, out := &val, &outVal
-				*out = make([]string, len(*in))
-				copy(*out, *in)
-			}
-			(*out)[key] = outVal
+func (in *SonarQubeQualityProfileStatus) DeepCopyInto(out *SonarQubeQualityProfileStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
-		return
 	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DriftCorrected != nil {
+		in, out := &in.DriftCorrected, &out.DriftCorrected
+		*out = (*in).DeepCopy()
+	}
+	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodStatuses.
-func (in PodStatuses) DeepCopy() PodStatuses {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeQualityProfileStatus.
+func (in *SonarQubeQualityProfileStatus) DeepCopy() *SonarQubeQualityProfileStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PodStatuses)
+	out := new(SonarQubeQualityProfileStatus)
 	in.DeepCopyInto(out)
-	return *out
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SonarQube) DeepCopyInto(out *SonarQube) {
+func (in *SonarQubeRestore) DeepCopyInto(out *SonarQubeRestore) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -220,18 +1144,18 @@ func (in *SonarQube) DeepCopyInto(out *SonarQube) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQube.
-func (in *SonarQube) DeepCopy() *SonarQube {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeRestore.
+func (in *SonarQubeRestore) DeepCopy() *SonarQubeRestore {
 	if in == nil {
 		return nil
 	}
-	out := new(SonarQube)
+	out := new(SonarQubeRestore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SonarQube) DeepCopyObject() runtime.Object {
+func (in *SonarQubeRestore) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -239,13 +1163,13 @@ func (in *SonarQube) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SonarQubeList) DeepCopyInto(out *SonarQubeList) {
+func (in *SonarQubeRestoreList) DeepCopyInto(out *SonarQubeRestoreList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]SonarQube, len(*in))
+		*out = make([]SonarQubeRestore, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -253,24 +1177,83 @@ func (in *SonarQubeList) DeepCopyInto(out *SonarQubeList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeList.
-func (in *SonarQubeList) DeepCopy() *SonarQubeList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeRestoreList.
+func (in *SonarQubeRestoreList) DeepCopy() *SonarQubeRestoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(SonarQubeList)
+	out := new(SonarQubeRestoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SonarQubeList) DeepCopyObject() runtime.Object {
+func (in *SonarQubeRestoreList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeRestoreSpec) DeepCopyInto(out *SonarQubeRestoreSpec) {
+	*out = *in
+	in.ServerRef.DeepCopyInto(&out.ServerRef)
+	if in.BackupName != nil {
+		in, out := &in.BackupName, &out.BackupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeRestoreSpec.
+func (in *SonarQubeRestoreSpec) DeepCopy() *SonarQubeRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SonarQubeRestoreStatus) DeepCopyInto(out *SonarQubeRestoreStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerShutdown != nil {
+		in, out := &in.ServerShutdown, &out.ServerShutdown
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SonarQubeRestoreStatus.
+func (in *SonarQubeRestoreStatus) DeepCopy() *SonarQubeRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SonarQubeRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SonarQubeServer) DeepCopyInto(out *SonarQubeServer) {
 	*out = *in
@@ -360,11 +1343,35 @@ func (in *SonarQubeServerSpec) DeepCopyInto(out *SonarQubeServerSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(UpgradeSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Secret != nil {
 		in, out := &in.Secret, &out.Secret
 		*out = new(string)
 		**out = **in
 	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PropertiesFrom != nil {
+		in, out := &in.PropertiesFrom, &out.PropertiesFrom
+		*out = make([]PropertiesSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdminSecret != nil {
+		in, out := &in.AdminSecret, &out.AdminSecret
+		*out = new(string)
+		**out = **in
+	}
 	if in.Type != nil {
 		in, out := &in.Type, &out.Type
 		*out = new(ServerType)
@@ -390,7 +1397,29 @@ func (in *SonarQubeServerSpec) DeepCopyInto(out *SonarQubeServerSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ServerBaseURL != nil {
+		in, out := &in.ServerBaseURL, &out.ServerBaseURL
+		*out = new(string)
+		**out = **in
+	}
 	in.NodeConfig.DeepCopyInto(&out.NodeConfig)
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]Plugin, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(Expose)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(Database)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -429,7 +1458,17 @@ func (in *SonarQubeServerStatus) DeepCopyInto(out *SonarQubeServerStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.SpecVersion != nil {
+		in, out := &in.SpecVersion, &out.SpecVersion
+		*out = new(string)
+		**out = **in
+	}
 	in.Upgrades.DeepCopyInto(&out.Upgrades)
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(UpgradeSnapshotStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -446,6 +1485,11 @@ func (in *SonarQubeServerStatus) DeepCopy() *SonarQubeServerStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SonarQubeSpec) DeepCopyInto(out *SonarQubeSpec) {
 	*out = *in
+	if in.SearchSize != nil {
+		in, out := &in.SearchSize, &out.SearchSize
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Version != nil {
 		in, out := &in.Version, &out.Version
 		*out = new(string)
@@ -471,6 +1515,25 @@ func (in *SonarQubeSpec) DeepCopyInto(out *SonarQubeSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PropertiesFrom != nil {
+		in, out := &in.PropertiesFrom, &out.PropertiesFrom
+		*out = make([]PropertiesSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdminSecret != nil {
+		in, out := &in.AdminSecret, &out.AdminSecret
+		*out = new(string)
+		**out = **in
+	}
 	if in.Shutdown != nil {
 		in, out := &in.Shutdown, &out.Shutdown
 		*out = new(bool)
@@ -495,6 +1558,28 @@ func (in *SonarQubeSpec) DeepCopyInto(out *SonarQubeSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]Plugin, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(Expose)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(Database)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(UpgradeSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -548,6 +1633,26 @@ func (in *SonarQubeStatus) DeepCopyInto(out *SonarQubeStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.SpecVersion != nil {
+		in, out := &in.SpecVersion, &out.SpecVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(ClusterUpgrade)
+		**out = **in
+	}
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(UpgradeSnapshotStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scale != nil {
+		in, out := &in.Scale, &out.Scale
+		*out = new(ScaleStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -561,6 +1666,74 @@ func (in *SonarQubeStatus) DeepCopy() *SonarQubeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSnapshot) DeepCopyInto(out *UpgradeSnapshot) {
+	*out = *in
+	if in.VolumeSnapshotClass != nil {
+		in, out := &in.VolumeSnapshotClass, &out.VolumeSnapshotClass
+		*out = new(string)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSnapshot.
+func (in *UpgradeSnapshot) DeepCopy() *UpgradeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSnapshotStatus) DeepCopyInto(out *UpgradeSnapshotStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSnapshotStatus.
+func (in *UpgradeSnapshotStatus) DeepCopy() *UpgradeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStep) DeepCopyInto(out *UpgradeStep) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStep.
+func (in *UpgradeStep) DeepCopy() *UpgradeStep {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Upgrades) DeepCopyInto(out *Upgrades) {
 	*out = *in
@@ -574,6 +1747,13 @@ func (in *Upgrades) DeepCopyInto(out *Upgrades) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]UpgradeStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
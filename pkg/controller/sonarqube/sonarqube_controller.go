@@ -6,9 +6,12 @@ import (
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
 	"github.com/parflesh/sonarqube-operator/version"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -33,7 +36,11 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileSonarQube{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileSonarQube{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("sonarqube-controller"),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -68,6 +75,24 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to secondary resource StatefulSet and requeue the owner SonarQube
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQube{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Ingress and requeue the owner SonarQube
+	err = c.Watch(&source.Kind{Type: &networkingv1beta1.Ingress{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQube{},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to secondary resource PersistentVolumeClaim and requeue the owner SonarQube
 	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
 		IsController: true,
@@ -85,6 +110,14 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to ConfigMaps of propertiesFrom and requeue the watcher
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &utils.SecretMapper{Annotation: sonarsourcev1alpha1.SecretAnnotation},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -95,8 +128,9 @@ var _ reconcile.Reconciler = &ReconcileSonarQube{}
 type ReconcileSonarQube struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a SonarQube object and makes changes based on the state read
@@ -130,10 +164,15 @@ func (r *ReconcileSonarQube) Reconcile(request reconcile.Request) (reconcile.Res
 
 	secret, err := r.ReconcileSecret(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	databaseSecrets, err := utils.GetDatabaseSecrets(r.client, instance.Namespace, sonarsourcev1alpha1.SecretAnnotation, instance.Name, instance.Spec.Database)
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
-	revisionHash, err := utils.GenVersion(instance.Spec, secret.Data["sonar.properties"])
+	revisionHash, err := utils.GenVersion(instance.Spec, secret.Data["sonar.properties"], databaseSecrets...)
 
 	newStatus = instance.DeepCopy()
 	if revisionHash != newStatus.Status.Revision {
@@ -144,28 +183,71 @@ func (r *ReconcileSonarQube) Reconcile(request reconcile.Request) (reconcile.Res
 
 	_, err = r.ReconcileServiceAccount(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
 	_, err = r.ReconcileService(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
-	_, err = r.ReconcileSonarQubeServers(instance)
+	err = r.ReconcileExpose(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
+	err = r.ReconcileDatabase(instance)
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	servers, err := r.ReconcileSonarQubeServers(instance)
+	if servers != nil {
+		r.updateAvailable(instance, servers)
+	}
+	if err != nil && utils.ReasonForError(err) == utils.ErrorReasonResourceShutdown {
+		newStatus = instance.DeepCopy()
+		newStatus.Status.ObservedGeneration = instance.Generation
+		utils.UpdateStatus(r.client, newStatus, instance)
+	}
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	utils.RecordEventForReconciled(r.recorder, instance, instance.Status.Conditions, "sonarqube cluster is up")
+
 	newStatus = instance.DeepCopy()
 
 	newStatus.Status.Conditions = utils.ClearConditions(newStatus.Status.Conditions)
+	utils.SetReadyCondition(&newStatus.Status.Conditions, sonarsourcev1alpha1.ConditionServerUp, "application nodes are up")
+	newStatus.Status.ObservedGeneration = instance.Generation
 
 	utils.UpdateStatus(r.client, newStatus, instance)
 
 	return reconcile.Result{}, nil
 }
 
+// updateAvailable reports the cluster as available while one of its application nodes is available
+func (r *ReconcileSonarQube) updateAvailable(cr *sonarsourcev1alpha1.SonarQube, servers map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) {
+	var available int
+	for _, v := range servers[sonarsourcev1alpha1.Application] {
+		if v.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionAvailable) {
+			available++
+		}
+	}
+
+	newStatus := cr.DeepCopy()
+	utils.SetAvailableCondition(&newStatus.Status.Conditions, available > 0,
+		fmt.Sprintf("%d of %d application nodes are available", available, len(servers[sonarsourcev1alpha1.Application])))
+	utils.UpdateStatus(r.client, newStatus, cr)
+}
+
+// parseErrorForReconcileResult records an event for err and reports it in the status of cr
+func (r *ReconcileSonarQube) parseErrorForReconcileResult(cr *sonarsourcev1alpha1.SonarQube, err error) (reconcile.Result, error) {
+	utils.RecordEventForError(r.recorder, cr, err)
+	return utils.ParseErrorForReconcileResult(r.client, cr, err)
+}
+
 func (r *ReconcileSonarQube) Labels(cr *sonarsourcev1alpha1.SonarQube) map[string]string {
 	labels := make(map[string]string)
 
@@ -9,11 +9,13 @@ import (
 	"testing"
 
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -51,11 +53,12 @@ func TestSonarQubeController(t *testing.T) {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{})
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
 	// Create a fake client to mock API calls.
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 	// Create a ReconcileSonarQube object with the scheme and fake client.
-	r := &ReconcileSonarQube{client: cl, scheme: s}
+	recorder := record.NewFakeRecorder(100)
+	r := &ReconcileSonarQube{client: cl, scheme: s, recorder: recorder}
 
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
@@ -74,15 +77,6 @@ func TestSonarQubeController(t *testing.T) {
 	if !res.Requeue {
 		t.Error("reconcile did not requeue")
 	}
-
-	res, err = r.Reconcile(req)
-	if err != nil {
-		t.Fatalf(ReconcileErrorFormat, err)
-	}
-	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue")
-	}
 	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqube)
 	if err != nil {
 		t.Fatalf(ReconcileErrorFormat, err)
@@ -90,8 +84,11 @@ func TestSonarQubeController(t *testing.T) {
 	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionProgressing) {
 		t.Errorf("condition progressing not set")
 	}
+	if event := <-recorder.Events; event != fmt.Sprintf("Normal ResourceCreate created Secret %s", utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret)) {
+		t.Errorf("reconcile: expected event for created secret got %s", event)
+	}
 	secret := &corev1.Secret{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.Secret, Namespace: sonarqube.Namespace}, secret)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
 	if err != nil && errors.IsNotFound(err) {
 		t.Error("reconcile: secret not created")
 	} else if err != nil {
@@ -299,22 +296,9 @@ func TestSonarQubeController(t *testing.T) {
 		if err != nil {
 			t.Fatalf(ReconcileErrorFormat, err)
 		}
-		if sonarQubeServer.Status.Service == "" {
-			sonarQubeServer.Status.Service = sonarQubeServer.Name
-			service := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      sonarQubeServer.Name,
-					Namespace: sonarQubeServer.Namespace,
-				},
-				Spec: corev1.ServiceSpec{
-					ClusterIP: "127.0.0.1",
-				},
-			}
-			err := r.client.Create(context.TODO(), service)
-			if err != nil {
-				t.Fatalf("reconcileSonarQubeServers: (%v)", err)
-			}
-			err = r.client.Update(context.TODO(), sonarQubeServer)
+		if sonarQubeServer.Status.Host == "" {
+			sonarQubeServer.Status.Host = fmt.Sprintf("%s-headless.%s.svc", sonarQubeServer.Name, sonarQubeServer.Namespace)
+			err := r.client.Update(context.TODO(), sonarQubeServer)
 			if err != nil {
 				t.Fatalf("reconcileSonarQubeServers: (%v)", err)
 			}
@@ -326,22 +310,9 @@ func TestSonarQubeController(t *testing.T) {
 	if err != nil {
 		t.Fatalf(ReconcileErrorFormat, err)
 	}
-	if sonarQubeServer.Status.Service == "" {
-		sonarQubeServer.Status.Service = sonarQubeServer.Name
-		service := &corev1.Service{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      sonarQubeServer.Name,
-				Namespace: sonarQubeServer.Namespace,
-			},
-			Spec: corev1.ServiceSpec{
-				ClusterIP: "127.0.0.1",
-			},
-		}
-		err := r.client.Create(context.TODO(), service)
-		if err != nil {
-			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
-		}
-		err = r.client.Update(context.TODO(), sonarQubeServer)
+	if sonarQubeServer.Status.Host == "" {
+		sonarQubeServer.Status.Host = fmt.Sprintf("%s-headless.%s.svc", sonarQubeServer.Name, sonarQubeServer.Namespace)
+		err := r.client.Update(context.TODO(), sonarQubeServer)
 		if err != nil {
 			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
 		}
@@ -374,4 +345,11 @@ func TestSonarQubeController(t *testing.T) {
 	if res.Requeue {
 		t.Error("reconcile requeued even though everything should be good")
 	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionReady) {
+		t.Error("reconcile: cluster not reported ready when application nodes are up")
+	}
 }
@@ -0,0 +1,420 @@
+package sonarqube
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"github.com/parflesh/sonarqube-operator/version"
+	"github.com/thanhpk/randstr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Reconciles the managed PostgreSQL database for SonarQube
+// Returns: Error
+// If Error is non-nil, the database is not in expected state
+// Errors:
+//   ErrorReasonSpecInvalid: returned when the database storage size can not be parsed
+//   ErrorReasonResourceCreate: returned when Secret, PersistentVolumeClaim, Service, or StatefulSet does not exists
+//   ErrorReasonResourceUpdate: returned when Service or StatefulSet was updated to meet expected state
+//   ErrorReasonResourceWaiting: returned when the database is not ready
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQube) ReconcileDatabase(cr *sonarsourcev1alpha1.SonarQube) error {
+	if !r.databaseManaged(cr) {
+		return nil
+	}
+
+	_, err := r.findDatabaseSecret(cr)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.findDatabasePVC(cr)
+	if err != nil {
+		return err
+	}
+
+	service, err := r.findDatabaseService(cr)
+	if err != nil {
+		return err
+	}
+	newService, err := r.newDatabaseService(cr)
+	if err != nil {
+		return err
+	}
+	if err := utils.VerifyService(r.client, service, newService); err != nil {
+		return err
+	}
+
+	statefulSet, err := r.findDatabaseStatefulSet(cr)
+	if err != nil {
+		return err
+	}
+
+	return r.verifyDatabaseStatefulSet(cr, statefulSet)
+}
+
+// databaseManaged returns true when the database of SonarQube is deployed by the operator
+func (r *ReconcileSonarQube) databaseManaged(cr *sonarsourcev1alpha1.SonarQube) bool {
+	return cr.Spec.Database != nil && cr.Spec.Database.Managed != nil
+}
+
+func (r *ReconcileSonarQube) databaseName(cr *sonarsourcev1alpha1.SonarQube) string {
+	return fmt.Sprintf("%s-database", cr.Name)
+}
+
+// databaseLabels are distinct from Labels so the database is not selected by the SonarQube service
+func (r *ReconcileSonarQube) databaseLabels(cr *sonarsourcev1alpha1.SonarQube) map[string]string {
+	labels := make(map[string]string)
+
+	labels[sonarsourcev1alpha1.KubeAppName] = "PostgreSQL"
+	labels[sonarsourcev1alpha1.KubeAppInstance] = r.databaseName(cr)
+	labels[sonarsourcev1alpha1.KubeAppComponent] = "database"
+	labels[sonarsourcev1alpha1.KubeAppPartof] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppManagedby] = fmt.Sprintf("sonarqube-operator.v%s", version.Version)
+
+	return labels
+}
+
+// databaseProperties returns the sonar.jdbc.* properties connecting SonarQube to the managed database
+// Errors:
+//   ErrorReasonSpecInvalid: returned when the database Secret is missing credentials
+//   ErrorReasonResourceCreate: returned when the database Secret does not exists
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQube) databaseProperties(cr *sonarsourcev1alpha1.SonarQube) (map[string]string, error) {
+	secret, err := r.findDatabaseSecret(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range []string{sonarsourcev1alpha1.DatabaseSecretDatabase, sonarsourcev1alpha1.DatabaseSecretUsername, sonarsourcev1alpha1.DatabaseSecretPassword} {
+		if len(secret.Data[v]) == 0 {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("database secret %s is missing %s", secret.Name, v),
+			}
+		}
+	}
+
+	return map[string]string{
+		"sonar.jdbc.url":      fmt.Sprintf("jdbc:postgresql://%s:%d/%s", r.databaseName(cr), sonarsourcev1alpha1.DatabasePort, secret.Data[sonarsourcev1alpha1.DatabaseSecretDatabase]),
+		"sonar.jdbc.username": string(secret.Data[sonarsourcev1alpha1.DatabaseSecretUsername]),
+		"sonar.jdbc.password": string(secret.Data[sonarsourcev1alpha1.DatabaseSecretPassword]),
+	}, nil
+}
+
+// shutdownDatabase scales the managed database down and waits for it to stop
+// Errors:
+//   ErrorReasonResourceUpdate: returned when the StatefulSet was scaled down
+//   ErrorReasonResourceWaiting: returned when database pods are still running
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQube) shutdownDatabase(cr *sonarsourcev1alpha1.SonarQube) error {
+	if !r.databaseManaged(cr) {
+		return nil
+	}
+
+	statefulSet, err := r.findDatabaseStatefulSet(cr)
+	if err != nil {
+		return err
+	}
+
+	if statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas != 0 {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseShuttingDownDatabase)
+		statefulSet.Spec.Replicas = &[]int32{0}[0]
+		return utils.UpdateResource(r.client, statefulSet, utils.ErrorReasonResourceUpdate, fmt.Sprintf("shutting down database %s", statefulSet.Name))
+	}
+
+	if statefulSet.Status.Replicas != 0 {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseShuttingDownDatabase)
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for database %s to finish shutting down", statefulSet.Name),
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQube) findDatabaseSecret(cr *sonarsourcev1alpha1.SonarQube) (*corev1.Secret, error) {
+	newSecret, err := r.newDatabaseSecret(cr)
+	if err != nil {
+		return newSecret, err
+	}
+
+	foundSecret := &corev1.Secret{}
+
+	return foundSecret, utils.CreateResourceIfNotFound(r.client, newSecret, foundSecret)
+}
+
+func (r *ReconcileSonarQube) newDatabaseSecret(cr *sonarsourcev1alpha1.SonarQube) (*corev1.Secret, error) {
+	dep := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      r.databaseName(cr),
+			Labels:    r.databaseLabels(cr),
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.DatabaseSecretDatabase: []byte("sonar"),
+			sonarsourcev1alpha1.DatabaseSecretUsername: []byte("sonar"),
+			sonarsourcev1alpha1.DatabaseSecretPassword: []byte(randstr.String(32)),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQube) findDatabasePVC(cr *sonarsourcev1alpha1.SonarQube) (*corev1.PersistentVolumeClaim, error) {
+	newPVC, err := r.newDatabasePVC(cr)
+	if err != nil {
+		return newPVC, err
+	}
+
+	foundPVC := &corev1.PersistentVolumeClaim{}
+
+	return foundPVC, utils.CreateResourceIfNotFound(r.client, newPVC, foundPVC)
+}
+
+func (r *ReconcileSonarQube) newDatabasePVC(cr *sonarsourcev1alpha1.SonarQube) (*corev1.PersistentVolumeClaim, error) {
+	dep := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      r.databaseName(cr),
+			Labels:    r.databaseLabels(cr),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{},
+			},
+			VolumeMode:       &[]corev1.PersistentVolumeMode{corev1.PersistentVolumeFilesystem}[0],
+			StorageClassName: cr.Spec.Database.Managed.StorageClass,
+		},
+	}
+
+	storageSize := utils.DefaultVolumeSize
+	if cr.Spec.Database.Managed.StorageSize != nil {
+		storageSize = *cr.Spec.Database.Managed.StorageSize
+	}
+
+	size, err := resource.ParseQuantity(storageSize)
+	if err != nil {
+		return dep, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("database storageSize %s is not a valid quantity (%s)", storageSize, err.Error()),
+		}
+	}
+	dep.Spec.Resources.Requests[corev1.ResourceStorage] = size
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQube) findDatabaseService(cr *sonarsourcev1alpha1.SonarQube) (*corev1.Service, error) {
+	newService, err := r.newDatabaseService(cr)
+	if err != nil {
+		return newService, err
+	}
+
+	foundService := &corev1.Service{}
+
+	return foundService, utils.CreateResourceIfNotFound(r.client, newService, foundService)
+}
+
+func (r *ReconcileSonarQube) newDatabaseService(cr *sonarsourcev1alpha1.SonarQube) (*corev1.Service, error) {
+	labels := r.databaseLabels(cr)
+
+	dep := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      r.databaseName(cr),
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Type:     corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:     "postgresql",
+					Protocol: corev1.ProtocolTCP,
+					Port:     sonarsourcev1alpha1.DatabasePort,
+				},
+			},
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQube) findDatabaseStatefulSet(cr *sonarsourcev1alpha1.SonarQube) (*appsv1.StatefulSet, error) {
+	newStatefulSet, err := r.newDatabaseStatefulSet(cr)
+	if err != nil {
+		return newStatefulSet, err
+	}
+
+	foundStatefulSet := &appsv1.StatefulSet{}
+
+	return foundStatefulSet, utils.CreateResourceIfNotFound(r.client, newStatefulSet, foundStatefulSet)
+}
+
+func (r *ReconcileSonarQube) newDatabaseStatefulSet(cr *sonarsourcev1alpha1.SonarQube) (*appsv1.StatefulSet, error) {
+	labels := r.databaseLabels(cr)
+	name := r.databaseName(cr)
+
+	secretEnv := func(env, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	// A shutdown cluster creates the database stopped
+	replicas := int32(1)
+	if cr.Spec.Shutdown != nil && *cr.Spec.Shutdown {
+		replicas = 0
+	}
+
+	dep := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: name,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: name,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "postgresql",
+							Image: r.databaseImage(cr),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "postgresql",
+									ContainerPort: sonarsourcev1alpha1.DatabasePort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name:  "PGDATA",
+									Value: "/var/lib/postgresql/data/pgdata",
+								},
+								secretEnv("POSTGRES_DB", sonarsourcev1alpha1.DatabaseSecretDatabase),
+								secretEnv("POSTGRES_USER", sonarsourcev1alpha1.DatabaseSecretUsername),
+								secretEnv("POSTGRES_PASSWORD", sonarsourcev1alpha1.DatabaseSecretPassword),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/var/lib/postgresql/data",
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", "pg_isready -U \"$POSTGRES_USER\" -d \"$POSTGRES_DB\""},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if cr.Spec.Database.Managed.Resources != nil {
+		dep.Spec.Template.Spec.Containers[0].Resources = *cr.Spec.Database.Managed.Resources
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+// verifyDatabaseStatefulSet starts the database unless the cluster is shutdown and waits for it to be ready
+// Scaling down is left to shutdownDatabase so the database outlives the SonarQube servers
+func (r *ReconcileSonarQube) verifyDatabaseStatefulSet(cr *sonarsourcev1alpha1.SonarQube, statefulSet *appsv1.StatefulSet) error {
+	newStatefulSet, err := r.newDatabaseStatefulSet(cr)
+	if err != nil {
+		return err
+	}
+
+	container := &statefulSet.Spec.Template.Spec.Containers[0]
+	newContainer := newStatefulSet.Spec.Template.Spec.Containers[0]
+	if container.Image != newContainer.Image {
+		container.Image = newContainer.Image
+		return utils.UpdateResource(r.client, statefulSet, utils.ErrorReasonResourceUpdate, "updated database image")
+	}
+	if !reflect.DeepEqual(container.Resources, newContainer.Resources) {
+		container.Resources = newContainer.Resources
+		return utils.UpdateResource(r.client, statefulSet, utils.ErrorReasonResourceUpdate, "updated database resources")
+	}
+
+	if cr.Spec.Shutdown != nil && *cr.Spec.Shutdown {
+		return nil
+	}
+
+	if statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas != 1 {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseStartingDatabase)
+		statefulSet.Spec.Replicas = &[]int32{1}[0]
+		return utils.UpdateResource(r.client, statefulSet, utils.ErrorReasonResourceUpdate, fmt.Sprintf("starting database %s", statefulSet.Name))
+	}
+
+	if statefulSet.Status.ReadyReplicas < 1 {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseStartingDatabase)
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for database %s to be ready", statefulSet.Name),
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQube) databaseImage(cr *sonarsourcev1alpha1.SonarQube) string {
+	if cr.Spec.Database.Managed.Image != nil {
+		return *cr.Spec.Database.Managed.Image
+	}
+	return utils.DefaultDatabaseImage
+}
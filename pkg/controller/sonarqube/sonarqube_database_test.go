@@ -0,0 +1,176 @@
+package sonarqube
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeDatabase runs ReconcileSonarQube.ReconcileDatabase() against a
+// fake client
+func TestSonarQubeDatabase(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Database: &sonarsourcev1alpha1.Database{
+				Managed: &sonarsourcev1alpha1.ManagedDatabase{},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	for _, v := range []string{"Secret", "PersistentVolumeClaim", "Service", "StatefulSet"} {
+		err := r.ReconcileDatabase(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+			t.Errorf("reconcileDatabase: resource created error not thrown when creating %s", v)
+		}
+	}
+
+	err := r.ReconcileDatabase(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileDatabase: resource waiting error not returned when database is not ready")
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: r.databaseName(sonarqube), Namespace: sonarqube.Namespace}, statefulSet)
+	if err != nil {
+		t.Fatalf("reconcileDatabase: (%v)", err)
+	}
+	if statefulSet.Spec.Template.Spec.Containers[0].Image != utils.DefaultDatabaseImage {
+		t.Errorf("reconcileDatabase: expected image %s got %s", utils.DefaultDatabaseImage, statefulSet.Spec.Template.Spec.Containers[0].Image)
+	}
+	statefulSet.Status.Replicas = 1
+	statefulSet.Status.ReadyReplicas = 1
+	err = r.client.Update(context.TODO(), statefulSet)
+	if err != nil {
+		t.Fatalf("reconcileDatabase: (%v)", err)
+	}
+
+	err = r.ReconcileDatabase(sonarqube)
+	if err != nil {
+		t.Errorf("reconcileDatabase: returned error even though database is in expected state (%v)", err)
+	}
+
+	// sonar.jdbc.* is rendered into the config secret after create, render, and jwt secret updates
+	for i := 0; i < 4; i++ {
+		_, err = r.ReconcileSecret(sonarqube)
+	}
+	if err != nil {
+		t.Errorf("reconcileSecret: returned error even though secret is in expected state (%v)", err)
+	}
+	databaseSecret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: r.databaseName(sonarqube), Namespace: sonarqube.Namespace}, databaseSecret)
+	if err != nil {
+		t.Fatalf("reconcileDatabase: (%v)", err)
+	}
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	sonarProperties, err := utils.GetProperties(secret, utils.SonarPropertiesFile)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	if v, _ := sonarProperties.Get("sonar.jdbc.url"); v != fmt.Sprintf("jdbc:postgresql://%s-database:5432/sonar", name) {
+		t.Errorf("reconcileSecret: unexpected sonar.jdbc.url %s", v)
+	}
+	if v, _ := sonarProperties.Get("sonar.jdbc.password"); v != string(databaseSecret.Data[sonarsourcev1alpha1.DatabaseSecretPassword]) {
+		t.Error("reconcileSecret: sonar.jdbc.password does not match database secret")
+	}
+
+	// A SonarQubeBackup of the cluster dumps the managed database with its credentials
+	target, err := utils.GetBackupTarget(r.client, namespace, sonarsourcev1alpha1.ServerReference{
+		Kind: &[]string{sonarsourcev1alpha1.ServerKindSonarQube}[0],
+		Name: name,
+	})
+	if err != nil {
+		t.Fatalf("getBackupTarget: (%v)", err)
+	}
+	if target.Database.Host != r.databaseName(sonarqube) || target.Database.Port != "5432" || target.Database.Database != "sonar" {
+		t.Errorf("getBackupTarget: unexpected database %s:%s/%s", target.Database.Host, target.Database.Port, target.Database.Database)
+	}
+	if target.Database.Username != string(databaseSecret.Data[sonarsourcev1alpha1.DatabaseSecretUsername]) ||
+		target.Database.Password != string(databaseSecret.Data[sonarsourcev1alpha1.DatabaseSecretPassword]) {
+		t.Error("getBackupTarget: credentials do not match database secret")
+	}
+
+	// Database is scaled down on shutdown and started with the cluster
+	err = r.shutdownDatabase(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("shutdownDatabase: resource update error not returned when scaling down database")
+	}
+	err = r.shutdownDatabase(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("shutdownDatabase: resource waiting error not returned when database is running")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, statefulSet)
+	if err != nil {
+		t.Fatalf("shutdownDatabase: (%v)", err)
+	}
+	statefulSet.Status.Replicas = 0
+	statefulSet.Status.ReadyReplicas = 0
+	err = r.client.Update(context.TODO(), statefulSet)
+	if err != nil {
+		t.Fatalf("shutdownDatabase: (%v)", err)
+	}
+	err = r.shutdownDatabase(sonarqube)
+	if err != nil {
+		t.Errorf("shutdownDatabase: returned error even though database is shutdown (%v)", err)
+	}
+
+	sonarqube.Spec.Shutdown = &[]bool{true}[0]
+	err = r.ReconcileDatabase(sonarqube)
+	if err != nil {
+		t.Errorf("reconcileDatabase: returned error even though database is shutdown (%v)", err)
+	}
+
+	sonarqube.Spec.Shutdown = &[]bool{false}[0]
+	err = r.ReconcileDatabase(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileDatabase: resource update error not returned when starting database")
+	}
+
+	// A database created for a shutdown cluster is not started
+	sonarqube.Spec.Shutdown = &[]bool{true}[0]
+	newStatefulSet, err := r.newDatabaseStatefulSet(sonarqube)
+	if err != nil {
+		t.Fatalf("newDatabaseStatefulSet: (%v)", err)
+	}
+	if *newStatefulSet.Spec.Replicas != 0 {
+		t.Errorf("newDatabaseStatefulSet: expected 0 replicas for shutdown cluster got %d", *newStatefulSet.Spec.Replicas)
+	}
+}
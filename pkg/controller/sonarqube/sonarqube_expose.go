@@ -0,0 +1,157 @@
+package sonarqube
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Reconciles Ingress or Route for SonarQube
+// Returns: Error
+// If Error is non-nil, Ingress or Route is not in expected state
+// Errors:
+//   ErrorReasonSpecInvalid: returned when expose can not be applied
+//   ErrorReasonResourceCreate: returned when Ingress or Route does not exists
+//   ErrorReasonResourceUpdate: returned when Ingress or Route was updated or deleted to meet expected state
+//   ErrorReasonResourceWaiting: returned when tls secret does not exist
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQube) ReconcileExpose(cr *sonarsourcev1alpha1.SonarQube) error {
+	name := types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}
+
+	if cr.Spec.Expose == nil || cr.Spec.Expose.Route != nil {
+		err := utils.DeleteResourceIfOwned(r.client, cr, "Ingress", name, &networkingv1beta1.Ingress{})
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.Expose == nil || cr.Spec.Expose.Route == nil {
+		err := utils.DeleteResourceIfOwned(r.client, cr, "Route", name, utils.NewRouteObject())
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.Expose != nil {
+		err := utils.ValidateExpose(cr.Spec.Expose)
+		if err != nil {
+			return err
+		}
+
+		if cr.Spec.Expose.Route != nil {
+			_, err = r.reconcileRoute(cr)
+		} else {
+			_, err = r.reconcileIngress(cr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	url := r.externalURL(cr)
+	if cr.Status.URL != url {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.URL = url
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
+	return nil
+}
+
+// externalURL returns the url derived from Spec.Expose
+func (r *ReconcileSonarQube) externalURL(cr *sonarsourcev1alpha1.SonarQube) string {
+	if cr.Spec.Expose != nil && cr.Spec.Expose.Host != "" {
+		return utils.ExposeURL(cr.Spec.Expose)
+	}
+	return ""
+}
+
+func (r *ReconcileSonarQube) reconcileIngress(cr *sonarsourcev1alpha1.SonarQube) (*networkingv1beta1.Ingress, error) {
+	newIngress, err := r.newIngress(cr)
+	if err != nil {
+		return newIngress, err
+	}
+
+	ingress := &networkingv1beta1.Ingress{}
+	err = utils.CreateResourceIfNotFound(r.client, newIngress, ingress)
+	if err != nil {
+		return ingress, err
+	}
+
+	return ingress, utils.VerifyIngress(r.client, ingress, newIngress)
+}
+
+func (r *ReconcileSonarQube) newIngress(cr *sonarsourcev1alpha1.SonarQube) (*networkingv1beta1.Ingress, error) {
+	service, err := r.ReconcileService(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := r.Labels(cr)
+	labels[sonarsourcev1alpha1.KubeAppPartof] = cr.Name
+
+	dep := utils.NewIngress(metav1.ObjectMeta{
+		Namespace: cr.Namespace,
+		Name:      cr.Name,
+		Labels:    labels,
+	}, cr.Spec.Expose, service)
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQube) reconcileRoute(cr *sonarsourcev1alpha1.SonarQube) (*unstructured.Unstructured, error) {
+	newRoute, err := r.newRoute(cr)
+	if err != nil {
+		return newRoute, err
+	}
+
+	route := utils.NewRouteObject()
+	err = utils.CreateResourceIfNotFound(r.client, newRoute, route)
+	if err != nil && meta.IsNoMatchError(err) {
+		return route, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("routes are not available in this cluster (%s)", err.Error()),
+		}
+	} else if err != nil {
+		return route, err
+	}
+
+	return route, utils.VerifyRoute(r.client, route, newRoute)
+}
+
+func (r *ReconcileSonarQube) newRoute(cr *sonarsourcev1alpha1.SonarQube) (*unstructured.Unstructured, error) {
+	service, err := r.ReconcileService(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsSecret, err := utils.GetExposeTLSSecret(r.client, cr.Namespace, sonarsourcev1alpha1.SecretAnnotation, cr.Name, cr.Spec.Expose)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := r.Labels(cr)
+	labels[sonarsourcev1alpha1.KubeAppPartof] = cr.Name
+
+	dep := utils.NewRoute(metav1.ObjectMeta{
+		Namespace: cr.Namespace,
+		Name:      cr.Name,
+		Labels:    labels,
+	}, cr.Spec.Expose, service, tlsSecret)
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
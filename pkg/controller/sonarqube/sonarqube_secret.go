@@ -18,8 +18,9 @@ import (
 // Returns: Secret, Error
 // If Error is non-nil, Service is not in expected state
 // Errors:
-//   ErrorReasonSpecUpdate: returned when spec does not have secret name
-//   ErrorReasonResourceCreate: returned when secret does not exists
+//   ErrorReasonSpecInvalid: returned when properties can not be rendered into secret
+//   ErrorReasonResourceCreate: returned when secret or the managed database secret does not exists
+//   ErrorReasonResourceWaiting: returned when a ConfigMap or Secret of propertiesFrom does not exist
 //   ErrorReasonResourceUpdate: returned when secret was updated to meet expected state
 //   ErrorReasonUnknown: returned when unhandled error from client occurs
 func (r *ReconcileSonarQube) ReconcileSecret(cr *sonarsourcev1alpha1.SonarQube) (*corev1.Secret, error) {
@@ -79,12 +80,7 @@ func (r *ReconcileSonarQube) newSecret(cr *sonarsourcev1alpha1.SonarQube) (*core
 		Type: corev1.SecretTypeOpaque,
 	}
 
-	if cr.Spec.Secret == nil {
-		cr.Spec.Secret = &[]string{fmt.Sprintf("%s-config", cr.Name)}[0]
-		return dep, utils.UpdateResource(r.client, cr, utils.ErrorReasonSpecUpdate, "updated secret")
-	}
-
-	dep.Name = *cr.Spec.Secret
+	dep.Name = utils.SecretName(cr.Name, cr.Spec.Secret)
 
 	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
 		return dep, err
@@ -94,12 +90,32 @@ func (r *ReconcileSonarQube) newSecret(cr *sonarsourcev1alpha1.SonarQube) (*core
 }
 
 func (r *ReconcileSonarQube) verifySecret(cr *sonarsourcev1alpha1.SonarQube, s *corev1.Secret) error {
+	rendered, err := utils.GetPropertiesFrom(r.client, cr.Namespace, sonarsourcev1alpha1.SecretAnnotation, cr.Name, cr.Spec.PropertiesFrom, cr.Spec.Properties)
+	if err != nil {
+		return err
+	}
+	if r.databaseManaged(cr) {
+		databaseProperties, err := r.databaseProperties(cr)
+		if err != nil {
+			return err
+		}
+		for k, v := range databaseProperties {
+			rendered[k] = v
+		}
+	}
+	err = utils.RenderProperties(r.client, cr, s, rendered)
+	if err != nil {
+		return err
+	}
+
 	sonarProperties, err := utils.GetProperties(s, "sonar.properties")
 	if err != nil {
 		return err
 	}
 
-	if _, ok := sonarProperties.Get("sonar.jdbc.url"); !ok {
+	// The url is set as SONAR_JDBC_URL on application nodes when urlFrom is set
+	urlFrom := cr.Spec.Database != nil && cr.Spec.Database.URLFrom != nil
+	if _, ok := sonarProperties.Get("sonar.jdbc.url"); !ok && !urlFrom {
 		return &utils.Error{
 			Reason:  utils.ErrorReasonSpecInvalid,
 			Message: "sonar.jdbc.url not set",
@@ -24,12 +24,8 @@ func TestSonarQubeSecret(t *testing.T) {
 	logf.SetLogger(logf.ZapLogger(true))
 
 	var (
-		name           = "sonarqube-operator"
-		namespace      = "sonarqube"
-		namespacedName = types.NamespacedName{
-			Name:      name,
-			Namespace: namespace,
-		}
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
 	)
 
 	// A SonarQube resource with metadata and spec.
@@ -54,23 +50,11 @@ func TestSonarQubeSecret(t *testing.T) {
 	r := &ReconcileSonarQube{client: cl, scheme: s}
 
 	_, err := r.ReconcileSecret(sonarqube)
-	if utils.ReasonForError(err) != utils.ErrorReasonSpecUpdate {
-		t.Error("reconcileSecret: spec update error not thrown when secret not set in spec")
-	}
-	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
-	if err != nil {
-		t.Fatalf("reconcileSecret: (%v)", err)
-	}
-	if sonarqube.Spec.Secret == nil {
-		t.Error("reconcileSecret: spec not updated with secret name")
-	}
-
-	_, err = r.ReconcileSecret(sonarqube)
 	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
 		t.Error("reconcileSecret: resource created error not thrown when creating secret")
 	}
 	secret := &corev1.Secret{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.Secret, Namespace: sonarqube.Namespace}, secret)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
 	if err != nil && errors.IsNotFound(err) {
 		t.Error("reconcileSecret: secret not created")
 	} else if err != nil {
@@ -145,7 +129,7 @@ func TestSonarQubeSecretUnowned(t *testing.T) {
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: sonarqube.Namespace,
-			Name:      *sonarqube.Spec.Secret,
+			Name:      utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret),
 		},
 	}
 	err := r.client.Create(context.TODO(), secret)
@@ -177,3 +161,74 @@ func TestSonarQubeSecretUnowned(t *testing.T) {
 		t.Error("reconcileSecret: sonarqube2 name not appended to secret annotation")
 	}
 }
+
+// TestSonarQubeSecretProperties runs ReconcileSonarQube.ReconcileSecret() against a
+// fake client with properties
+func TestSonarQubeSecretProperties(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Properties: map[string]string{"sonar.jdbc.url": "jdbc:postgresql://postgres/sonar"},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	_, err := r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileSecret: resource created error not thrown when creating secret")
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: resource update error not returned when rendering properties")
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: did not set sonar.auth.jwtBase64Hs256Secret")
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if err != nil {
+		t.Error("reconcileSecret: returned error even though secret is in expected state")
+	}
+
+	sonarqube.Spec.Properties["sonar.jdbc.url"] = "jdbc:postgresql://postgres/sonarqube"
+	secret, err := r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: resource update error not returned when changing properties")
+	}
+	sonarProperties, err := utils.GetProperties(secret, utils.SonarPropertiesFile)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	if v, _ := sonarProperties.Get("sonar.jdbc.url"); v != sonarqube.Spec.Properties["sonar.jdbc.url"] {
+		t.Errorf("reconcileSecret: sonar.jdbc.url not updated, got %s", v)
+	}
+	if _, ok := sonarProperties.Get("sonar.auth.jwtBase64Hs256Secret"); !ok {
+		t.Error("reconcileSecret: sonar.auth.jwtBase64Hs256Secret removed when rendering properties")
+	}
+}
@@ -5,35 +5,144 @@ import (
 	"fmt"
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strconv"
+	"strings"
 )
 
-// Reconciles Service for SonarQube
-// Returns: Service, Error
-// If Error is non-nil, Service is not in expected state
+// Reconciles SonarQubeServers for SonarQube
+// Returns: SonarQubeServers, Error
+// If Error is non-nil, SonarQubeServers are not in expected state
 // Errors:
-//   ErrorReasonResourceCreate: returned when Service does not exists
-//   ErrorReasonResourceUpdate: returned when Service was updated to meet expected state
+//   ErrorReasonSpecInvalid: returned when search size is not an odd number of at least 3
+//   ErrorReasonResourceCreate: returned when SonarQubeServer does not exists
+//   ErrorReasonResourceUpdate: returned when SonarQubeServer was updated or deleted to meet expected state
+//   ErrorReasonResourceWaiting: returned when waiting for SonarQubeServer to apply an update
+//   ErrorReasonResourceInvalid: returned when SonarQubeServer is invalid
 //   ErrorReasonUnknown: returned when unhandled error from client occurs
 func (r *ReconcileSonarQube) ReconcileSonarQubeServers(cr *sonarsourcev1alpha1.SonarQube) (map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer, error) {
+	if size := r.searchSize(cr); size < 3 || size%2 == 0 {
+		return nil, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("search size must be an odd number of at least 3 for data center edition, got %v", size),
+		}
+	}
+
 	sonarQubeServers, err := r.findSonarQubeServers(cr)
 	if err != nil {
 		return sonarQubeServers, err
 	}
 
+	// Surplus application nodes are removed before hosts are updated so remaining members never point to a
+	// node that is still serving, surplus search nodes are removed after the remaining members stopped using them
+	err = r.removeSurplusSonarQubeServers(cr, sonarsourcev1alpha1.Application, cr.Spec.Size)
+	if err != nil {
+		return sonarQubeServers, err
+	}
+
 	err = r.verifySonarQubeServers(cr, sonarQubeServers)
 	if err != nil {
 		return sonarQubeServers, err
 	}
 
+	err = r.removeSurplusSonarQubeServers(cr, sonarsourcev1alpha1.Search, r.searchSize(cr))
+	if err != nil {
+		return sonarQubeServers, err
+	}
+
+	if cr.Status.Scale != nil {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Scale = nil
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
 	return sonarQubeServers, nil
 }
 
+// searchSize returns Spec.SearchSize or the default of 3 search nodes
+func (r *ReconcileSonarQube) searchSize(cr *sonarsourcev1alpha1.SonarQube) int32 {
+	if cr.Spec.SearchSize != nil {
+		return *cr.Spec.SearchSize
+	}
+	return 3
+}
+
+// removeSurplusSonarQubeServers drains and deletes SonarQubeServers of component with an index of size or higher
+// Servers are removed one at a time starting with the highest index, the running operation is reported in
+// Status.Scale
+func (r *ReconcileSonarQube) removeSurplusSonarQubeServers(cr *sonarsourcev1alpha1.SonarQube, component sonarsourcev1alpha1.ServerType, size int32) error {
+	list := &sonarsourcev1alpha1.SonarQubeServerList{}
+	err := r.client.List(context.TODO(), list, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		sonarsourcev1alpha1.KubeAppPartof:    cr.Name,
+		sonarsourcev1alpha1.KubeAppComponent: string(component),
+	})
+	if err != nil {
+		return err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", cr.Name, component)
+	var surplus *sonarsourcev1alpha1.SonarQubeServer
+	var surplusIndex int
+	var nodes int32
+	for i := range list.Items {
+		v := &list.Items[i]
+		if !utils.IsOwner(cr, v) || !strings.HasPrefix(v.Name, prefix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(v.Name, prefix))
+		if err != nil {
+			continue
+		}
+		nodes++
+		if index >= int(size) && (surplus == nil || index > surplusIndex) {
+			surplus = v
+			surplusIndex = index
+		}
+	}
+
+	if surplus == nil {
+		return nil
+	}
+
+	scale := &sonarsourcev1alpha1.ScaleStatus{
+		Type:  component,
+		Nodes: nodes,
+		Size:  size,
+		Node:  surplus.Name,
+	}
+	if !reflect.DeepEqual(cr.Status.Scale, scale) {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Scale = scale
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
+	if surplus.Spec.Shutdown == nil || !*surplus.Spec.Shutdown {
+		surplus.Spec.Shutdown = &[]bool{true}[0]
+		return utils.UpdateResource(r.client, surplus, utils.ErrorReasonResourceUpdate, fmt.Sprintf("draining surplus %s node %s", component, surplus.Name))
+	}
+
+	if surplus.Status.ObservedGeneration != surplus.Generation || !surplus.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionShutdown) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for surplus %s node %s to drain", component, surplus.Name),
+		}
+	}
+
+	err = r.client.Delete(context.TODO(), surplus)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("deleted surplus %s node %s", component, surplus.Name),
+	}
+}
+
 func (r *ReconcileSonarQube) findSonarQubeServers(cr *sonarsourcev1alpha1.SonarQube) (map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer, error) {
 	sonarQubeServers := make(map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer)
 	newSonarQubeServers, err := r.newSonarQubeServers(cr)
@@ -59,7 +168,7 @@ func (r *ReconcileSonarQube) newSonarQubeServers(cr *sonarsourcev1alpha1.SonarQu
 	sonarQubeServers := make(map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer)
 
 	var i int32
-	for i = 0; i < 3; i++ {
+	for i = 0; i < r.searchSize(cr); i++ {
 		dep, err := r.newSonarQubeServer(cr, sonarsourcev1alpha1.Search, i)
 		if err != nil {
 			return sonarQubeServers, err
@@ -79,27 +188,46 @@ func (r *ReconcileSonarQube) newSonarQubeServers(cr *sonarsourcev1alpha1.SonarQu
 }
 
 func (r *ReconcileSonarQube) newSonarQubeServer(cr *sonarsourcev1alpha1.SonarQube, component sonarsourcev1alpha1.ServerType, i int32) (*sonarsourcev1alpha1.SonarQubeServer, error) {
+	nodeConfig, err := r.nodeConfig(cr, component)
+	if err != nil {
+		return nil, err
+	}
+
 	labels := r.Labels(cr)
 	labels[sonarsourcev1alpha1.KubeAppComponent] = string(component)
 	labels[sonarsourcev1alpha1.KubeAppPartof] = cr.Name
 
 	dep := &sonarsourcev1alpha1.SonarQubeServer{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s-%v", cr.Name, component, i),
+			Name:      utils.ServerName(cr.Name, component, i),
 			Namespace: cr.Namespace,
 			Labels:    labels,
 		},
 		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
 			Shutdown:       &[]bool{true}[0],
-			Version:        cr.Spec.Version,
-			Secret:         cr.Spec.Secret,
+			Version:        r.version(cr),
+			Edition:        r.edition(cr),
+			Secret:         &[]string{utils.SecretName(cr.Name, cr.Spec.Secret)}[0],
+			AdminSecret:    cr.Spec.AdminSecret,
 			Type:           &component,
 			Hosts:          nil,
 			SearchHosts:    nil,
 			ServiceAccount: cr.Spec.ServiceAccount,
+			NodeConfig:     nodeConfig,
 		},
 	}
 
+	if component == sonarsourcev1alpha1.Application {
+		dep.Spec.Plugins = cr.Spec.Plugins
+		dep.Spec.Database = r.serverDatabase(cr)
+		if url := r.externalURL(cr); url != "" {
+			dep.Spec.ServerBaseURL = &url
+		}
+		if i == 0 {
+			dep.Spec.Snapshot = cr.Spec.Snapshot
+		}
+	}
+
 	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
 		return dep, err
 	}
@@ -126,7 +254,42 @@ func (r *ReconcileSonarQube) verifySonarQubeServers(cr *sonarsourcev1alpha1.Sona
 		}
 	}
 
-	err := r.verifySonarQubeServersSearchHosts(cr, s)
+	err := r.verifySonarQubeServersEdition(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersSearchHosts(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersPlugins(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersServerBaseURL(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersDatabase(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersSnapshot(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersNodeConfig(cr, s)
+	if err != nil {
+		return err
+	}
+
+	err = r.verifySonarQubeServersUpgrade(cr, s)
 	if err != nil {
 		return err
 	}
@@ -146,87 +309,323 @@ func (r *ReconcileSonarQube) verifySonarQubeServers(cr *sonarsourcev1alpha1.Sona
 	return nil
 }
 
-func (r *ReconcileSonarQube) shutdownCluster(_ *sonarsourcev1alpha1.SonarQube, _ map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
-	return nil
+// shutdownCluster stops application nodes followed by search nodes and the managed database
+// Every node of a type has to report ConditionShutdown before the next type is stopped
+func (r *ReconcileSonarQube) shutdownCluster(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	err := r.setSonarQubeServersShutdown(cr, s[sonarsourcev1alpha1.Application], true, sonarsourcev1alpha1.ClusterPhaseShuttingDownApplication)
+	if err != nil {
+		return err
+	}
+
+	err = r.setSonarQubeServersShutdown(cr, s[sonarsourcev1alpha1.Search], true, sonarsourcev1alpha1.ClusterPhaseShuttingDownSearch)
+	if err != nil {
+		return err
+	}
+
+	err = r.shutdownDatabase(cr)
+	if err != nil {
+		return err
+	}
+
+	r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseShutdown)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceShutdown,
+		Message: fmt.Sprintf("sonarqube cluster %s is shutdown", cr.Name),
+	}
 }
 
-func (r *ReconcileSonarQube) startupCluster(_ *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
-	for _, v := range s[sonarsourcev1alpha1.Search] {
-		if v.Spec.Shutdown == nil || *v.Spec.Shutdown != false {
-			v.Spec.Shutdown = &[]bool{false}[0]
-			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("starting sonarqube server %s", v.Name))
-		}
+// startupCluster starts search nodes followed by application nodes
+// Every node of a type has to finish startup before the next type is started
+func (r *ReconcileSonarQube) startupCluster(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	err := r.setSonarQubeServersShutdown(cr, s[sonarsourcev1alpha1.Search], false, sonarsourcev1alpha1.ClusterPhaseStartingSearch)
+	if err != nil {
+		return err
 	}
 
-	for _, v := range s[sonarsourcev1alpha1.Application] {
-		if v.Spec.Shutdown == nil || *v.Spec.Shutdown != false {
-			v.Spec.Shutdown = &[]bool{false}[0]
-			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("starting sonarqube server %s", v.Name))
-		}
+	err = r.setSonarQubeServersShutdown(cr, s[sonarsourcev1alpha1.Application], false, sonarsourcev1alpha1.ClusterPhaseStartingApplication)
+	if err != nil {
+		return err
 	}
 
+	r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseRunning)
+
 	return nil
 }
 
-func (r *ReconcileSonarQube) getSonarQubeServersClusterIP(s []*sonarsourcev1alpha1.SonarQubeServer) ([]string, error) {
-	var ips []string
+// setSonarQubeServersShutdown sets Spec.Shutdown of servers and waits for every server to apply it
+// phase is reported in status while servers are updated or waited on
+func (r *ReconcileSonarQube) setSonarQubeServersShutdown(cr *sonarsourcev1alpha1.SonarQube, servers []*sonarsourcev1alpha1.SonarQubeServer, shutdown bool, phase sonarsourcev1alpha1.ClusterPhase) error {
+	action := "starting"
+	if shutdown {
+		action = "shutting down"
+	}
 
-	for _, v := range s {
-		if v.Status.Service == "" {
-			return ips, &utils.Error{
-				Reason:  utils.ErrorReasonResourceWaiting,
-				Message: fmt.Sprintf("Waiting on service for %s", v.Name),
-			}
+	for _, v := range servers {
+		if v.Spec.Shutdown == nil || *v.Spec.Shutdown != shutdown {
+			r.updatePhase(cr, phase)
+			v.Spec.Shutdown = &shutdown
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("%s sonarqube server %s", action, v.Name))
 		}
-		service := &corev1.Service{}
-		err := r.client.Get(context.TODO(), types.NamespacedName{Name: v.Status.Service, Namespace: v.Namespace}, service)
-		if err != nil && errors.IsNotFound(err) {
-			return ips, &utils.Error{
+	}
+
+	for _, v := range servers {
+		if v.Status.ObservedGeneration != v.Generation || v.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionShutdown) != shutdown {
+			r.updatePhase(cr, phase)
+			return &utils.Error{
 				Reason:  utils.ErrorReasonResourceWaiting,
-				Message: fmt.Sprintf("Waiting on service for %s", v.Name),
+				Message: fmt.Sprintf("waiting for sonarqube server %s to finish %s", v.Name, action),
 			}
-		} else if err != nil {
-			return ips, err
 		}
-		if service.Spec.ClusterIP == "" {
-			return ips, &utils.Error{
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQube) updatePhase(cr *sonarsourcev1alpha1.SonarQube, phase sonarsourcev1alpha1.ClusterPhase) {
+	if cr.Status.Phase != phase {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Phase = phase
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+}
+
+// getSonarQubeServersHosts returns the stable DNS names published by servers
+func (r *ReconcileSonarQube) getSonarQubeServersHosts(s []*sonarsourcev1alpha1.SonarQubeServer) ([]string, error) {
+	var hosts []string
+
+	for _, v := range s {
+		if v.Status.Host == "" {
+			return hosts, &utils.Error{
 				Reason:  utils.ErrorReasonResourceWaiting,
-				Message: fmt.Sprintf("Waiting on service for %s", v.Name),
+				Message: fmt.Sprintf("Waiting on host for %s", v.Name),
 			}
 		}
-		ips = append(ips, service.Spec.ClusterIP)
+		hosts = append(hosts, v.Status.Host)
 	}
 
-	return ips, nil
+	return hosts, nil
 }
 
+// verifySonarQubeServersSearchHosts updates search and application hosts of one SonarQubeServer at a time
+// Search nodes are updated first in index order followed by application nodes, each server has to apply its
+// update before the next one is changed so the cluster never loses all of its members at once.
+// Clusters created with service cluster ips are migrated to stable host names the same way
 func (r *ReconcileSonarQube) verifySonarQubeServersSearchHosts(_ *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
-	searchServiceIPS, err := r.getSonarQubeServersClusterIP(s[sonarsourcev1alpha1.Search])
+	searchHosts, err := r.getSonarQubeServersHosts(s[sonarsourcev1alpha1.Search])
 	if err != nil {
 		return err
 	}
 
-	applicationServiceIPS, err := r.getSonarQubeServersClusterIP(s[sonarsourcev1alpha1.Application])
+	applicationHosts, err := r.getSonarQubeServersHosts(s[sonarsourcev1alpha1.Application])
 	if err != nil {
 		return err
 	}
 
-	for t, l := range s {
-		for _, v := range l {
+	for _, t := range []sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search, sonarsourcev1alpha1.Application} {
+		for _, v := range s[t] {
 			var update bool
-			if !reflect.DeepEqual(v.Spec.SearchHosts, searchServiceIPS) {
-				v.Spec.SearchHosts = searchServiceIPS
+			if !reflect.DeepEqual(v.Spec.SearchHosts, searchHosts) {
+				v.Spec.SearchHosts = searchHosts
 				update = true
 			}
-			if t == sonarsourcev1alpha1.Application && !reflect.DeepEqual(v.Spec.Hosts, applicationServiceIPS) {
-				v.Spec.Hosts = applicationServiceIPS
+			if t == sonarsourcev1alpha1.Application && !reflect.DeepEqual(v.Spec.Hosts, applicationHosts) {
+				v.Spec.Hosts = applicationHosts
 				update = true
 			}
 			if update {
-				return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updates sonarqube server %s", v.Name))
+				return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated hosts of sonarqube server %s", v.Name))
+			}
+			if v.Status.ObservedGeneration != v.Generation {
+				return &utils.Error{
+					Reason:  utils.ErrorReasonResourceWaiting,
+					Message: fmt.Sprintf("waiting for sonarqube server %s to apply hosts", v.Name),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQube) verifySonarQubeServersEdition(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	edition := r.edition(cr)
+	for _, t := range []sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search, sonarsourcev1alpha1.Application} {
+		for _, v := range s[t] {
+			if !reflect.DeepEqual(v.Spec.Edition, edition) {
+				v.Spec.Edition = edition
+				return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated edition of sonarqube server %s", v.Name))
+			}
+		}
+	}
+
+	return nil
+}
+
+// edition returns Spec.Edition or the datacenter edition clusters default to
+func (r *ReconcileSonarQube) edition(cr *sonarsourcev1alpha1.SonarQube) *string {
+	if cr.Spec.Edition != nil {
+		return cr.Spec.Edition
+	}
+	return &[]string{utils.EditionDatacenter}[0]
+}
+
+func (r *ReconcileSonarQube) verifySonarQubeServersPlugins(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	for _, v := range s[sonarsourcev1alpha1.Application] {
+		if len(v.Spec.Plugins) == 0 && len(cr.Spec.Plugins) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(v.Spec.Plugins, cr.Spec.Plugins) {
+			v.Spec.Plugins = cr.Spec.Plugins
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated plugins of sonarqube server %s", v.Name))
+		}
+	}
+
+	return nil
+}
+
+// verifySonarQubeServersServerBaseURL passes the exposed url on to application nodes as sonar.core.serverBaseURL
+// The url is not set as externalURL, api calls to the nodes go through their own service
+func (r *ReconcileSonarQube) verifySonarQubeServersServerBaseURL(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	var serverBaseURL *string
+	if url := r.externalURL(cr); url != "" {
+		serverBaseURL = &url
+	}
+
+	for _, v := range s[sonarsourcev1alpha1.Application] {
+		if !reflect.DeepEqual(v.Spec.ServerBaseURL, serverBaseURL) || v.Spec.ExternalURL != nil {
+			v.Spec.ServerBaseURL = serverBaseURL
+			v.Spec.ExternalURL = nil
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated server base url of sonarqube server %s", v.Name))
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQube) verifySonarQubeServersDatabase(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	database := r.serverDatabase(cr)
+
+	for _, v := range s[sonarsourcev1alpha1.Application] {
+		if !reflect.DeepEqual(v.Spec.Database, database) {
+			v.Spec.Database = database
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated database of sonarqube server %s", v.Name))
+		}
+	}
+
+	return nil
+}
+
+// verifySonarQubeServersSnapshot passes the snapshot on to the first application node, which migrates the database
+// during upgrades and takes the snapshot before its version changes
+func (r *ReconcileSonarQube) verifySonarQubeServersSnapshot(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	for i, v := range s[sonarsourcev1alpha1.Application] {
+		snapshot := cr.Spec.Snapshot
+		if i > 0 {
+			snapshot = nil
+		}
+		if !reflect.DeepEqual(v.Spec.Snapshot, snapshot) {
+			v.Spec.Snapshot = snapshot
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated snapshot of sonarqube server %s", v.Name))
+		}
+	}
+
+	return nil
+}
+
+// serverDatabase returns the database secret key references passed on to application nodes
+// The managed database is connected through the config secret and is not passed on
+func (r *ReconcileSonarQube) serverDatabase(cr *sonarsourcev1alpha1.SonarQube) *sonarsourcev1alpha1.Database {
+	if !utils.HasDatabaseSecretRefs(cr.Spec.Database) {
+		return nil
+	}
+	return &sonarsourcev1alpha1.Database{
+		URLFrom:      cr.Spec.Database.URLFrom,
+		UsernameFrom: cr.Spec.Database.UsernameFrom,
+		PasswordFrom: cr.Spec.Database.PasswordFrom,
+	}
+}
+
+func (r *ReconcileSonarQube) verifySonarQubeServersNodeConfig(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	for _, t := range []sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search, sonarsourcev1alpha1.Application} {
+		nodeConfig, err := r.nodeConfig(cr, t)
+		if err != nil {
+			return err
+		}
+		for _, v := range s[t] {
+			if !reflect.DeepEqual(v.Spec.NodeConfig, nodeConfig) {
+				v.Spec.NodeConfig = nodeConfig
+				return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated node config of sonarqube server %s", v.Name))
 			}
 		}
 	}
 
 	return nil
 }
+
+// nodeConfig merges NodeConfig and NodeConfigAdvanced entries for component
+// Entries of type all are applied first and overridden by entries of the component type
+func (r *ReconcileSonarQube) nodeConfig(cr *sonarsourcev1alpha1.SonarQube, component sonarsourcev1alpha1.ServerType) (sonarsourcev1alpha1.NodeConfig, error) {
+	var nodeConfig sonarsourcev1alpha1.NodeConfig
+
+	for _, t := range []string{"all", string(component)} {
+		for _, v := range cr.Spec.NodeConfig {
+			if err := r.validateNodeConfigType(v.Type); err != nil {
+				return nodeConfig, err
+			}
+			if v.Type != t {
+				continue
+			}
+			if v.StorageClass != nil {
+				nodeConfig.StorageClass = v.StorageClass
+			}
+			if v.StorageSize != nil {
+				nodeConfig.StorageSize = v.StorageSize
+			}
+		}
+
+		for _, v := range cr.Spec.NodeConfigAdvanced {
+			if err := r.validateNodeConfigType(v.Type); err != nil {
+				return nodeConfig, err
+			}
+			if v.Type != t {
+				continue
+			}
+			if v.NodeSelector != nil {
+				nodeConfig.NodeSelector = v.NodeSelector
+			}
+			if v.NodeAffinity != nil {
+				nodeConfig.NodeAffinity = v.NodeAffinity
+			}
+			if v.PodAffinity != nil {
+				nodeConfig.PodAffinity = v.PodAffinity
+			}
+			if v.PodAntiAffinity != nil {
+				nodeConfig.PodAntiAffinity = v.PodAntiAffinity
+			}
+			if v.PriorityClass != nil {
+				nodeConfig.PriorityClass = v.PriorityClass
+			}
+			if v.Resources != nil {
+				nodeConfig.Resources = v.Resources
+			}
+		}
+	}
+
+	// Storage size is set explicitly as SonarQubeServers are defaulted to it on admission
+	if nodeConfig.StorageSize == nil {
+		nodeConfig.StorageSize = &[]string{utils.DefaultVolumeSize}[0]
+	}
+
+	return *nodeConfig.DeepCopy(), nil
+}
+
+func (r *ReconcileSonarQube) validateNodeConfigType(t string) error {
+	if !utils.ContainsString([]string{"all", string(sonarsourcev1alpha1.Application), string(sonarsourcev1alpha1.Search)}, t) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("node config type %s is invalid, must be all, application, or search", t),
+		}
+	}
+	return nil
+}
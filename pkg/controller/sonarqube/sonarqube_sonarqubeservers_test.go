@@ -45,7 +45,7 @@ func TestSonarQubeSonarQubeServers(t *testing.T) {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{})
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
 	// Create a fake client to mock API calls.
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 	// Create a ReconcileSonarQube object with the scheme and fake client.
@@ -65,25 +65,12 @@ func TestSonarQubeSonarQubeServers(t *testing.T) {
 	for {
 		servers, err := r.ReconcileSonarQubeServers(sonarqube)
 		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonResourceWaiting {
-			// Create service and set cluster ip
+			// Publish host as the headless service is created
 			for _, l := range servers {
 				for _, v := range l {
-					if v.Status.Service == "" {
-						v.Status.Service = v.Name
-						service := &corev1.Service{
-							ObjectMeta: metav1.ObjectMeta{
-								Name:      v.Name,
-								Namespace: v.Namespace,
-							},
-							Spec: corev1.ServiceSpec{
-								ClusterIP: "127.0.0.1",
-							},
-						}
-						err := r.client.Create(context.TODO(), service)
-						if err != nil {
-							t.Fatalf("reconcileSonarQubeServers: (%v)", err)
-						}
-						err = r.client.Update(context.TODO(), v)
+					if v.Status.Host == "" {
+						v.Status.Host = fmt.Sprintf("%s-headless.%s.svc", v.Name, v.Namespace)
+						err := r.client.Update(context.TODO(), v)
 						if err != nil {
 							t.Fatalf("reconcileSonarQubeServers: (%v)", err)
 						}
@@ -126,5 +113,469 @@ func TestSonarQubeSonarQubeServers(t *testing.T) {
 		} else if err != nil {
 			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
 		}
+		if sonarQubeServer.Spec.Edition == nil || *sonarQubeServer.Spec.Edition != utils.EditionDatacenter {
+			t.Errorf("reconcileSonarQubeServers: %s SonarQubeServers not set to datacenter edition", v)
+		}
+	}
+}
+
+// TestSonarQubeSonarQubeServersScale runs ReconcileSonarQube.ReconcileSonarQubeServers() against a
+// fake client while scaling search and application nodes
+func TestSonarQubeSonarQubeServersScale(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size:       1,
+			SearchSize: &[]int32{4}[0],
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	_, err := r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("reconcileSonarQubeServers: spec invalid error not thrown for even search size")
+	}
+
+	sonarqube.Spec.SearchSize = &[]int32{5}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	list := &sonarsourcev1alpha1.SonarQubeServerList{}
+	if err := r.client.List(context.TODO(), list); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if len(list.Items) != 6 {
+		t.Errorf("reconcileSonarQubeServers: expected 6 SonarQubeServers got %v", len(list.Items))
+	}
+
+	// Member that has not applied its update blocks the next member
+	server := &sonarsourcev1alpha1.SonarQubeServer{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-search-0", name), Namespace: namespace}, server)
+	if err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	server.Generation = server.Status.ObservedGeneration + 1
+	if err := r.client.Update(context.TODO(), server); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSonarQubeServers: resource waiting error not thrown for server applying update")
+	}
+	server.Status.ObservedGeneration = server.Generation
+	if err := r.client.Update(context.TODO(), server); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+
+	sonarqube.Spec.SearchSize = &[]int32{3}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when search size decreased")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-search-3", name), Namespace: namespace}, server)
+	if err != nil {
+		t.Error("reconcileSonarQubeServers: search node deleted before hosts were updated")
+	}
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	for _, v := range []string{"search-3", "search-4"} {
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-%s", name, v), Namespace: namespace}, server)
+		if err == nil || !errors.IsNotFound(err) {
+			t.Errorf("reconcileSonarQubeServers: surplus search node %s not deleted", v)
+		}
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-0", name), Namespace: namespace}, server)
+	if err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if len(server.Spec.SearchHosts) != 3 {
+		t.Errorf("reconcileSonarQubeServers: expected 3 search hosts got %v", server.Spec.SearchHosts)
+	}
+
+	sonarqube.Spec.Size = 2
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	sonarqube.Spec.Size = 1
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when size decreased")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-1", name), Namespace: namespace}, server)
+	if err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if server.Spec.Shutdown == nil || !*server.Spec.Shutdown {
+		t.Error("reconcileSonarQubeServers: surplus application node not drained")
+	}
+	if sonarqube.Status.Scale == nil || sonarqube.Status.Scale.Node != server.Name || sonarqube.Status.Scale.Nodes != 2 || sonarqube.Status.Scale.Size != 1 {
+		t.Errorf("reconcileSonarQubeServers: scale operation not reported in status (%v)", sonarqube.Status.Scale)
+	}
+	// Spec change is not yet observed by the SonarQubeServer controller
+	server.Generation++
+	if err := r.client.Update(context.TODO(), server); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSonarQubeServers: resource waiting error not thrown while application node drains")
+	}
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-1", name), Namespace: namespace}, server)
+	if err == nil || !errors.IsNotFound(err) {
+		t.Error("reconcileSonarQubeServers: surplus application node not deleted")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-0", name), Namespace: namespace}, server)
+	if err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if len(server.Spec.Hosts) != 1 {
+		t.Errorf("reconcileSonarQubeServers: expected 1 application host got %v", server.Spec.Hosts)
+	}
+	if sonarqube.Status.Scale != nil {
+		t.Error("reconcileSonarQubeServers: scale operation not cleared from status")
+	}
+}
+
+// TestSonarQubeSonarQubeServersShutdown runs ReconcileSonarQube.ReconcileSonarQubeServers() against a
+// fake client while shutting down and starting the cluster
+func TestSonarQubeSonarQubeServersShutdown(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size: 1,
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+	if sonarqube.Status.Phase != sonarsourcev1alpha1.ClusterPhaseRunning {
+		t.Errorf("reconcileSonarQubeServers: expected phase %s got %s", sonarsourcev1alpha1.ClusterPhaseRunning, sonarqube.Status.Phase)
+	}
+
+	application := &sonarsourcev1alpha1.SonarQubeServer{}
+	search := &sonarsourcev1alpha1.SonarQubeServer{}
+	getServers := func() {
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-0", name), Namespace: namespace}, application)
+		if err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-search-0", name), Namespace: namespace}, search)
+		if err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+	}
+
+	sonarqube.Spec.Shutdown = &[]bool{true}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err := r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when shutting down")
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSonarQubeServers: resource waiting error not thrown while application node shuts down")
+	}
+	getServers()
+	if !*application.Spec.Shutdown || *search.Spec.Shutdown {
+		t.Error("reconcileSonarQubeServers: search node shutdown before application node")
+	}
+	if sonarqube.Status.Phase != sonarsourcev1alpha1.ClusterPhaseShuttingDownApplication {
+		t.Errorf("reconcileSonarQubeServers: expected phase %s got %s", sonarsourcev1alpha1.ClusterPhaseShuttingDownApplication, sonarqube.Status.Phase)
+	}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+	getServers()
+	if !*search.Spec.Shutdown {
+		t.Error("reconcileSonarQubeServers: search node not shutdown")
+	}
+	if sonarqube.Status.Phase != sonarsourcev1alpha1.ClusterPhaseShutdown {
+		t.Errorf("reconcileSonarQubeServers: expected phase %s got %s", sonarsourcev1alpha1.ClusterPhaseShutdown, sonarqube.Status.Phase)
+	}
+
+	sonarqube.Spec.Shutdown = &[]bool{false}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when starting")
+	}
+	getServers()
+	if *search.Spec.Shutdown || !*application.Spec.Shutdown {
+		t.Error("reconcileSonarQubeServers: application node started before search node")
+	}
+	if sonarqube.Status.Phase != sonarsourcev1alpha1.ClusterPhaseStartingSearch {
+		t.Errorf("reconcileSonarQubeServers: expected phase %s got %s", sonarsourcev1alpha1.ClusterPhaseStartingSearch, sonarqube.Status.Phase)
+	}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+	getServers()
+	if *application.Spec.Shutdown {
+		t.Error("reconcileSonarQubeServers: application node not started")
+	}
+	if sonarqube.Status.Phase != sonarsourcev1alpha1.ClusterPhaseRunning {
+		t.Errorf("reconcileSonarQubeServers: expected phase %s got %s", sonarsourcev1alpha1.ClusterPhaseRunning, sonarqube.Status.Phase)
+	}
+}
+
+// TestSonarQubeSonarQubeServersNodeConfig runs ReconcileSonarQube.ReconcileSonarQubeServers() against a
+// fake client with node config for all and specific node types
+func TestSonarQubeSonarQubeServersNodeConfig(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size: 1,
+			NodeConfig: []sonarsourcev1alpha1.ClusterNodeConfig{
+				{Type: "search", StorageSize: &[]string{"10Gi"}[0]},
+				{Type: "all", StorageClass: &[]string{"standard"}[0], StorageSize: &[]string{"5Gi"}[0]},
+			},
+			NodeConfigAdvanced: []sonarsourcev1alpha1.ClusterNodeConfigAdvanced{
+				{Type: "all", PriorityClass: &[]string{"low"}[0], NodeSelector: &map[string]string{"role": "sonarqube"}},
+				{Type: "application", PriorityClass: &[]string{"high"}[0]},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	search := &sonarsourcev1alpha1.SonarQubeServer{}
+	application := &sonarsourcev1alpha1.SonarQubeServer{}
+	getServers := func() {
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-search-0", name), Namespace: namespace}, search)
+		if err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-0", name), Namespace: namespace}, application)
+		if err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+	}
+	getServers()
+
+	if *search.Spec.NodeConfig.StorageSize != "10Gi" || *application.Spec.NodeConfig.StorageSize != "5Gi" {
+		t.Error("reconcileSonarQubeServers: storage size of node type did not override all")
+	}
+	if *search.Spec.NodeConfig.StorageClass != "standard" || *application.Spec.NodeConfig.StorageClass != "standard" {
+		t.Error("reconcileSonarQubeServers: storage class of all not applied")
+	}
+	if *search.Spec.NodeConfig.PriorityClass != "low" || *application.Spec.NodeConfig.PriorityClass != "high" {
+		t.Error("reconcileSonarQubeServers: priority class of node type did not override all")
+	}
+	if (*application.Spec.NodeConfig.NodeSelector)["role"] != "sonarqube" {
+		t.Error("reconcileSonarQubeServers: node selector of all not applied")
+	}
+
+	sonarqube.Spec.NodeConfigAdvanced[1].PriorityClass = &[]string{"critical"}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err := r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when node config changed")
+	}
+	reconcileSonarQubeServers(t, r, sonarqube)
+	getServers()
+	if *application.Spec.NodeConfig.PriorityClass != "critical" || *search.Spec.NodeConfig.PriorityClass != "low" {
+		t.Error("reconcileSonarQubeServers: node config change not applied to existing servers")
+	}
+
+	sonarqube.Spec.NodeConfig[0].Type = "web"
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("reconcileSonarQubeServers: spec invalid error not thrown for unknown node config type")
+	}
+}
+
+// TestSonarQubeSonarQubeServersServerBaseURL runs ReconcileSonarQube.ReconcileSonarQubeServers() against a
+// fake client to ensure the exposed url is passed on without replacing the api url of application nodes
+func TestSonarQubeSonarQubeServersServerBaseURL(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size:   1,
+			Expose: &sonarsourcev1alpha1.Expose{Host: "sonarqube.example.com"},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	application := &sonarsourcev1alpha1.SonarQubeServer{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-application-0", name), Namespace: namespace}, application)
+	if err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if application.Spec.ExternalURL != nil {
+		t.Error("reconcileSonarQubeServers: exposed url set as api url of application node")
+	}
+	if application.Spec.ServerBaseURL == nil || *application.Spec.ServerBaseURL != utils.ExposeURL(sonarqube.Spec.Expose) {
+		t.Error("reconcileSonarQubeServers: exposed url not set as server base url of application node")
+	}
+
+	application.Spec.ExternalURL = application.Spec.ServerBaseURL
+	if err := r.client.Update(context.TODO(), application); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err = r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown when application node has external url")
+	}
+}
+
+// reconcileSonarQubeServers loops ReconcileSonarQube.ReconcileSonarQubeServers() until no more errors or
+// non-handled error, acting as the SonarQubeServer controller while waiting
+func reconcileSonarQubeServers(t *testing.T, r *ReconcileSonarQube, cr *sonarsourcev1alpha1.SonarQube) {
+	for {
+		_, err := r.ReconcileSonarQubeServers(cr)
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonResourceWaiting {
+			settleSonarQubeServers(t, r)
+		} else if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		} else if err == nil || utils.ReasonForError(err) == utils.ErrorReasonResourceShutdown {
+			break
+		}
+	}
+}
+
+// settleSonarQubeServers updates the status of every SonarQubeServer as the SonarQubeServer controller
+// would after applying its spec
+func settleSonarQubeServers(t *testing.T, r *ReconcileSonarQube) {
+	list := &sonarsourcev1alpha1.SonarQubeServerList{}
+	if err := r.client.List(context.TODO(), list); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	for i := range list.Items {
+		v := &list.Items[i]
+		if v.Status.Host == "" {
+			v.Status.Host = fmt.Sprintf("%s-headless.%s.svc", v.Name, v.Namespace)
+		}
+		v.Status.ObservedGeneration = v.Generation
+		v.Status.Conditions.SetCondition(status.Condition{
+			Type:   sonarsourcev1alpha1.ConditionProgressing,
+			Status: corev1.ConditionFalse,
+		})
+		shutdown := corev1.ConditionFalse
+		if v.Spec.Shutdown != nil && *v.Spec.Shutdown {
+			shutdown = corev1.ConditionTrue
+		}
+		v.Status.Conditions.SetCondition(status.Condition{
+			Type:   sonarsourcev1alpha1.ConditionShutdown,
+			Status: shutdown,
+		})
+		err := r.client.Update(context.TODO(), v)
+		if err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
 	}
 }
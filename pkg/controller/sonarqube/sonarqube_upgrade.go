@@ -0,0 +1,224 @@
+package sonarqube
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+)
+
+// Rolls the resolved version out to the SonarQubeServers of SonarQube
+// Application nodes are stopped, search nodes are upgraded one at a time, the first application node migrates
+// the database and the remaining application nodes are upgraded to be started by startupCluster
+// With a snapshot the first application node snapshots the database before migrating it, the cluster returns to the
+// previous version when the node rolled back a failed migration
+// Returns: Error
+// If Error is non-nil, upgrade is in progress
+// Errors:
+//   ErrorReasonSpecInvalid: returned when the resolved version can not be parsed
+//   ErrorReasonResourceUpdate: returned when an automatic upgrade or a rollback was recorded in status or
+//     SonarQubeServer was updated for the next upgrade step
+//   ErrorReasonResourceWaiting: returned when waiting for SonarQubeServer to finish an upgrade step
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQube) verifySonarQubeServersUpgrade(cr *sonarsourcev1alpha1.SonarQube, s map[sonarsourcev1alpha1.ServerType][]*sonarsourcev1alpha1.SonarQubeServer) error {
+	search := s[sonarsourcev1alpha1.Search]
+	application := s[sonarsourcev1alpha1.Application]
+
+	err := r.verifySonarQubeServersRollback(cr, application)
+	if err != nil {
+		return err
+	}
+
+	// Without a version every node runs the latest image and reports its own version
+	if r.version(cr) == nil {
+		return nil
+	}
+	target := *r.version(cr)
+
+	if r.sonarQubeServersVersion(search, target) && r.sonarQubeServersVersion(application, target) {
+		if cr.Status.Upgrade != nil || cr.Status.Version != target {
+			newStatus := cr.DeepCopy()
+			newStatus.Status.Upgrade = nil
+			newStatus.Status.Version = target
+			newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
+			utils.UpdateStatus(r.client, newStatus, cr)
+		}
+		return r.verifyAutoUpgrade(cr, application)
+	}
+
+	if cr.Status.Upgrade == nil || cr.Status.Upgrade.To != target {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Upgrade = &sonarsourcev1alpha1.ClusterUpgrade{
+			From: cr.Status.Version,
+			To:   target,
+		}
+		newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
+	// Nodes of a cluster that is shutdown are upgraded in place and started in order by startupCluster
+	if cr.Spec.Shutdown != nil && *cr.Spec.Shutdown {
+		for _, l := range [][]*sonarsourcev1alpha1.SonarQubeServer{search, application} {
+			for _, v := range l {
+				if v.Spec.Version == nil || *v.Spec.Version != target {
+					v.Spec.Version = &target
+					return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("upgraded sonarqube server %s to %s", v.Name, target))
+				}
+			}
+		}
+		return nil
+	}
+
+	if len(application) > 0 && (!r.sonarQubeServersVersion(search, target) || !r.sonarQubeServersVersion(application[:1], target)) {
+		err := r.setSonarQubeServersShutdown(cr, application, true, sonarsourcev1alpha1.ClusterPhaseUpgradeStopApplication)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range search {
+		if v.Spec.Version == nil || *v.Spec.Version != target {
+			r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseUpgradeSearch)
+			v.Spec.Version = &target
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("upgrading search node %s to %s", v.Name, target))
+		}
+		if v.Status.ObservedGeneration != v.Generation {
+			r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseUpgradeSearch)
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for search node %s to finish upgrade to %s", v.Name, target),
+			}
+		}
+	}
+
+	if len(application) == 0 {
+		return nil
+	}
+
+	// The first application node migrates the database while the other application nodes are stopped
+	migrator := application[0]
+	if migrator.Spec.Version == nil || *migrator.Spec.Version != target || migrator.Spec.Shutdown == nil || *migrator.Spec.Shutdown {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseUpgradeMigrateDatabase)
+		migrator.Spec.Version = &target
+		migrator.Spec.Shutdown = &[]bool{false}[0]
+		return utils.UpdateResource(r.client, migrator, utils.ErrorReasonResourceUpdate, fmt.Sprintf("upgrading application node %s to %s and migrating database", migrator.Name, target))
+	}
+	if migrator.Status.ObservedGeneration != migrator.Generation || migrator.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionShutdown) {
+		r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseUpgradeMigrateDatabase)
+		if snapshot := migrator.Status.Snapshot; migrator.Spec.Snapshot != nil && (snapshot == nil || snapshot.To != target || snapshot.Phase == sonarsourcev1alpha1.SnapshotPhasePending) {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for application node %s to snapshot database before migrating to %s", migrator.Name, target),
+			}
+		}
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for application node %s to migrate database to %s", migrator.Name, target),
+		}
+	}
+
+	for _, v := range application[1:] {
+		if v.Spec.Version == nil || *v.Spec.Version != target {
+			r.updatePhase(cr, sonarsourcev1alpha1.ClusterPhaseUpgradeApplication)
+			v.Spec.Version = &target
+			return utils.UpdateResource(r.client, v, utils.ErrorReasonResourceUpdate, fmt.Sprintf("upgrading application node %s to %s", v.Name, target))
+		}
+	}
+
+	return nil
+}
+
+// verifySonarQubeServersRollback abandons the upgrade in progress when the first application node rolled back the
+// database migration to its target. The snapshot is recorded in status, the cluster returns to the version of the
+// snapshot and the target is skipped by version until Spec.Version changes
+func (r *ReconcileSonarQube) verifySonarQubeServersRollback(cr *sonarsourcev1alpha1.SonarQube, application []*sonarsourcev1alpha1.SonarQubeServer) error {
+	if len(application) == 0 || cr.Status.Upgrade == nil {
+		return nil
+	}
+
+	snapshot := application[0].Status.Snapshot
+	if snapshot == nil || snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseRolledBack || snapshot.To != cr.Status.Upgrade.To {
+		return nil
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Upgrade = nil
+	newStatus.Status.Version = snapshot.From
+	newStatus.Status.Snapshot = snapshot.DeepCopy()
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("application node %s rolled back upgrade from %s to %s", application[0].Name, snapshot.From, snapshot.To),
+	}
+}
+
+// verifyAutoUpgrade records an upgrade to the newest version the application nodes report as compatible in
+// Status.Upgrade when allowed by UpdatesMinor and UpdatesMajor, Spec.Version is left as written by the user
+func (r *ReconcileSonarQube) verifyAutoUpgrade(cr *sonarsourcev1alpha1.SonarQube, application []*sonarsourcev1alpha1.SonarQubeServer) error {
+	if len(application) == 0 || cr.Status.Phase != sonarsourcev1alpha1.ClusterPhaseRunning {
+		return nil
+	}
+
+	allowMinor := cr.Spec.UpdatesMinor != nil && *cr.Spec.UpdatesMinor
+	allowMajor := cr.Spec.UpdatesMajor != nil && *cr.Spec.UpdatesMajor
+	from := *r.version(cr)
+
+	// The version of a rolled back upgrade is not upgraded to again
+	compatible := application[0].Status.Upgrades.Compatible
+	if rolledBack := r.rolledBackVersion(cr); rolledBack != "" {
+		compatible = nil
+		for _, v := range application[0].Status.Upgrades.Compatible {
+			if v != rolledBack {
+				compatible = append(compatible, v)
+			}
+		}
+	}
+
+	target, err := utils.FindUpgrade(from, compatible, allowMinor, allowMajor)
+	if err != nil || target == "" {
+		return err
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Upgrade = &sonarsourcev1alpha1.ClusterUpgrade{
+		From: from,
+		To:   target,
+	}
+	newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("upgrading from %s to %s", from, target),
+	}
+}
+
+// version returns the version the servers of the cluster run, Spec.Version or the version resolved in status when it
+// is newer and Spec.Version did not change since it was resolved. Status.Upgrade holds the target of an upgrade in progress, including automatic upgrades, and
+// Status.Version the version of the last completed upgrade. Spec.Version is skipped when the upgrade to it was rolled
+// back. nil is returned when no version is set
+func (r *ReconcileSonarQube) version(cr *sonarsourcev1alpha1.SonarQube) *string {
+	resolved := cr.Status.Version
+	if cr.Status.Upgrade != nil {
+		resolved = cr.Status.Upgrade.To
+	}
+	return utils.ResolveVersion(cr.Spec.Version, cr.Status.SpecVersion, resolved, r.rolledBackVersion(cr))
+}
+
+// rolledBackVersion returns the target of the upgrade rolled back by the first application node or an empty string
+func (r *ReconcileSonarQube) rolledBackVersion(cr *sonarsourcev1alpha1.SonarQube) string {
+	if snapshot := cr.Status.Snapshot; snapshot != nil && snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRolledBack {
+		return snapshot.To
+	}
+	return ""
+}
+
+// sonarQubeServersVersion returns true when every server is set to version
+func (r *ReconcileSonarQube) sonarQubeServersVersion(servers []*sonarsourcev1alpha1.SonarQubeServer, version string) bool {
+	for _, v := range servers {
+		if v.Spec.Version == nil || *v.Spec.Version != version {
+			return false
+		}
+	}
+	return true
+}
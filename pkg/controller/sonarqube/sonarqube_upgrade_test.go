@@ -0,0 +1,284 @@
+package sonarqube
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"strings"
+	"testing"
+)
+
+// TestSonarQubeUpgrade runs ReconcileSonarQube.ReconcileSonarQubeServers() against a fake client while
+// upgrading the cluster and checks nodes are upgraded in order
+func TestSonarQubeUpgrade(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size:    2,
+			Version: &[]string{"8.3.1"}[0],
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+	if sonarqube.Status.Version != "8.3.1" || sonarqube.Status.Upgrade != nil {
+		t.Errorf("reconcileSonarQubeServers: expected version 8.3.1 got %s", sonarqube.Status.Version)
+	}
+
+	sonarqube.Spec.Version = &[]string{"8.4.0"}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+
+	upgradePhases := map[sonarsourcev1alpha1.ClusterPhase]bool{
+		sonarsourcev1alpha1.ClusterPhaseUpgradeStopApplication: true,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeSearch:          true,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeMigrateDatabase: true,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeApplication:     true,
+	}
+	phases := make(map[sonarsourcev1alpha1.ClusterPhase]bool)
+	for i := 0; ; i++ {
+		if i > 100 {
+			t.Fatal("reconcileSonarQubeServers: upgrade did not finish")
+		}
+
+		_, err := r.ReconcileSonarQubeServers(sonarqube)
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		phases[sonarqube.Status.Phase] = true
+		if upgradePhases[sonarqube.Status.Phase] && (sonarqube.Status.Upgrade == nil || sonarqube.Status.Upgrade.From != "8.3.1" || sonarqube.Status.Upgrade.To != "8.4.0") {
+			t.Fatal("reconcileSonarQubeServers: upgrade not reported in status")
+		}
+
+		list := &sonarsourcev1alpha1.SonarQubeServerList{}
+		if err := r.client.List(context.TODO(), list); err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		servers := make(map[string]sonarsourcev1alpha1.SonarQubeServer)
+		var searchUpgraded, searchTotal int
+		for _, v := range list.Items {
+			servers[strings.TrimPrefix(v.Name, name+"-")] = v
+			if *v.Spec.Type == sonarsourcev1alpha1.Search {
+				searchTotal++
+				if *v.Spec.Version == "8.4.0" {
+					searchUpgraded++
+				}
+			}
+		}
+		application0, application1 := servers["application-0"], servers["application-1"]
+		running := func(v sonarsourcev1alpha1.SonarQubeServer) bool {
+			return v.Spec.Shutdown == nil || !*v.Spec.Shutdown
+		}
+		if searchUpgraded > 0 && searchUpgraded < searchTotal && (running(application0) || running(application1)) {
+			t.Fatal("reconcileSonarQubeServers: application node running while search nodes are upgraded")
+		}
+		if *application0.Spec.Version == "8.4.0" && searchUpgraded < searchTotal {
+			t.Fatal("reconcileSonarQubeServers: application node upgraded before search nodes")
+		}
+		if running(application1) && *application1.Spec.Version == "8.4.0" && !running(application0) {
+			t.Fatal("reconcileSonarQubeServers: application node started before database migration")
+		}
+		if running(application1) && *application1.Spec.Version != "8.4.0" && *application0.Spec.Version == "8.4.0" {
+			t.Fatal("reconcileSonarQubeServers: application node running old version after database migration")
+		}
+
+		if err == nil {
+			break
+		} else if utils.ReasonForError(err) == utils.ErrorReasonResourceWaiting {
+			settleSonarQubeServers(t, r)
+		}
+	}
+
+	for _, v := range []sonarsourcev1alpha1.ClusterPhase{
+		sonarsourcev1alpha1.ClusterPhaseUpgradeStopApplication,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeSearch,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeMigrateDatabase,
+		sonarsourcev1alpha1.ClusterPhaseUpgradeApplication,
+		sonarsourcev1alpha1.ClusterPhaseRunning,
+	} {
+		if !phases[v] {
+			t.Errorf("reconcileSonarQubeServers: phase %s not reported", v)
+		}
+	}
+	if sonarqube.Status.Version != "8.4.0" || sonarqube.Status.Upgrade != nil {
+		t.Errorf("reconcileSonarQubeServers: expected version 8.4.0 got %s", sonarqube.Status.Version)
+	}
+
+	// Automatic upgrade to a compatible version reported by the application nodes
+	sonarqube.Spec.UpdatesMinor = &[]bool{true}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	application := &sonarsourcev1alpha1.SonarQubeServer{}
+	list := &sonarsourcev1alpha1.SonarQubeServerList{}
+	if err := r.client.List(context.TODO(), list); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	for i := range list.Items {
+		if list.Items[i].Name == name+"-application-0" {
+			application = &list.Items[i]
+		}
+	}
+	application.Status.Upgrades.Compatible = []string{"8.5.0", "9.0.0"}
+	if err := r.client.Update(context.TODO(), application); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	_, err := r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSonarQubeServers: resource update error not thrown for compatible upgrade")
+	}
+	sonarqube = &sonarsourcev1alpha1.SonarQube{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+	if *sonarqube.Spec.Version != "8.4.0" {
+		t.Errorf("reconcileSonarQubeServers: expected spec version 8.4.0 got %s", *sonarqube.Spec.Version)
+	}
+	if sonarqube.Status.Upgrade == nil || sonarqube.Status.Upgrade.To != "8.5.0" || *r.version(sonarqube) != "8.5.0" {
+		t.Errorf("reconcileSonarQubeServers: expected upgrade to 8.5.0 got %v", sonarqube.Status.Upgrade)
+	}
+
+	sonarqube.Spec.Version = &[]string{"8.3.0"}[0]
+	if version := r.version(sonarqube); version == nil || *version != "8.3.0" {
+		t.Errorf("version: changed spec version older than the resolved version ignored, got %v", version)
+	}
+}
+
+// TestSonarQubeUpgradeRollback runs ReconcileSonarQube.ReconcileSonarQubeServers() against a fake client while the
+// first application node rolls back the database migration of an upgrade and checks the cluster returns to the
+// previous version
+func TestSonarQubeUpgradeRollback(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Size:    2,
+			Version: &[]string{"8.4.0"}[0],
+			Snapshot: &sonarsourcev1alpha1.UpgradeSnapshot{
+				Rollback: &[]bool{true}[0],
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, &sonarsourcev1alpha1.SonarQubeServer{}, &sonarsourcev1alpha1.SonarQubeServerList{})
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQube object with the scheme and fake client.
+	r := &ReconcileSonarQube{client: cl, scheme: s}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+
+	getServer := func(component string) *sonarsourcev1alpha1.SonarQubeServer {
+		server := &sonarsourcev1alpha1.SonarQubeServer{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name + "-" + component, Namespace: namespace}, server); err != nil {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		return server
+	}
+	if getServer("application-0").Spec.Snapshot == nil || getServer("application-1").Spec.Snapshot != nil {
+		t.Error("reconcileSonarQubeServers: snapshot not passed on to the first application node only")
+	}
+
+	sonarqube.Spec.Version = &[]string{"8.5.0"}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+
+	// Upgrade until the first application node waits for its snapshot
+	for i := 0; ; i++ {
+		if i > 100 {
+			t.Fatal("reconcileSonarQubeServers: database migration not started")
+		}
+		_, err := r.ReconcileSonarQubeServers(sonarqube)
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+		}
+		if utils.ReasonForError(err) == utils.ErrorReasonResourceWaiting && strings.Contains(err.Error(), "snapshot") {
+			break
+		} else if utils.ReasonForError(err) == utils.ErrorReasonResourceWaiting {
+			settleSonarQubeServers(t, r)
+		}
+	}
+
+	// The migration fails and the first application node rolls back to the snapshot
+	settleSonarQubeServers(t, r)
+	migrator := getServer("application-0")
+	migrator.Status.Version = "8.4.0"
+	migrator.Status.Snapshot = &sonarsourcev1alpha1.UpgradeSnapshotStatus{
+		Name:  name + "-application-0-snapshot",
+		From:  "8.4.0",
+		To:    "8.5.0",
+		Phase: sonarsourcev1alpha1.SnapshotPhaseRolledBack,
+	}
+	if err := r.client.Update(context.TODO(), migrator); err != nil {
+		t.Fatalf("reconcileSonarQubeServers: (%v)", err)
+	}
+
+	_, err := r.ReconcileSonarQubeServers(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Errorf("reconcileSonarQubeServers: resource update error not returned for rollback (%v)", err)
+	}
+	if sonarqube.Status.Upgrade != nil || sonarqube.Status.Snapshot == nil || sonarqube.Status.Snapshot.To != "8.5.0" {
+		t.Errorf("reconcileSonarQubeServers: rollback not recorded in status %v", sonarqube.Status.Snapshot)
+	}
+	if version := r.version(sonarqube); version == nil || *version != "8.4.0" {
+		t.Error("reconcileSonarQubeServers: rolled back version not skipped")
+	}
+
+	reconcileSonarQubeServers(t, r, sonarqube)
+	for _, v := range []string{"search-0", "application-0", "application-1"} {
+		if server := getServer(v); server.Spec.Version == nil || *server.Spec.Version != "8.4.0" {
+			t.Errorf("reconcileSonarQubeServers: %s not returned to 8.4.0", v)
+		}
+	}
+	if sonarqube.Status.Version != "8.4.0" || sonarqube.Status.Upgrade != nil {
+		t.Errorf("reconcileSonarQubeServers: expected version 8.4.0 got %s", sonarqube.Status.Version)
+	}
+}
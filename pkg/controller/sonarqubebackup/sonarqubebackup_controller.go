@@ -0,0 +1,169 @@
+package sonarqubebackup
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"github.com/parflesh/sonarqube-operator/version"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_sonarqubebackup")
+
+// Add creates a new SonarQubeBackup Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSonarQubeBackup{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("sonarqubebackup-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource SonarQubeBackup
+	err = c.Watch(&source.Kind{Type: &sonarsourcev1alpha1.SonarQubeBackup{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Secret and requeue the owner SonarQubeBackup
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeBackup{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Job and requeue the owner SonarQubeBackup
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeBackup{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource CronJob and requeue the owner SonarQubeBackup
+	err = c.Watch(&source.Kind{Type: &batchv1beta1.CronJob{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeBackup{},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileSonarQubeBackup implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSonarQubeBackup{}
+
+// ReconcileSonarQubeBackup reconciles a SonarQubeBackup object
+type ReconcileSonarQubeBackup struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile reads that state of the cluster for a SonarQubeBackup object and makes changes based on the state read
+// and what is in the SonarQubeBackup.Spec
+func (r *ReconcileSonarQubeBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling SonarQubeBackup")
+
+	// Fetch the SonarQubeBackup instance
+	instance := &sonarsourcev1alpha1.SonarQubeBackup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected, backups in storage are kept.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	err = utils.ValidateSonarQubeBackup(instance)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	target, err := utils.GetBackupTarget(r.client, instance.Namespace, instance.Spec.ServerRef)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	_, err = r.ReconcileSecret(instance, target)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	err = r.ReconcileJob(instance, target)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	lastBackup, err := r.lastBackup(instance)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	newStatus := instance.DeepCopy()
+
+	newStatus.Status.Conditions = utils.ClearConditions(newStatus.Status.Conditions)
+	newStatus.Status.ObservedGeneration = instance.Generation
+	newStatus.Status.Location = utils.BackupLocation(instance.Spec.Storage)
+	if lastBackup != nil {
+		newStatus.Status.LastBackup = lastBackup.Name
+		newStatus.Status.LastBackupTime = lastBackup.Status.CompletionTime
+	}
+
+	utils.UpdateStatus(r.client, newStatus, instance)
+
+	// The server can be started or stopped without a change of SonarQubeBackup, requeue to keep the affinity of the
+	// scheduled job template current
+	if instance.Spec.Schedule != nil {
+		return reconcile.Result{RequeueAfter: utils.ResyncPeriod}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileSonarQubeBackup) Labels(cr *sonarsourcev1alpha1.SonarQubeBackup) map[string]string {
+	labels := make(map[string]string)
+
+	for k, v := range cr.Labels {
+		labels[k] = v
+	}
+
+	labels[sonarsourcev1alpha1.BackupTypeLabel] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppName] = "SonarQubeBackup"
+	labels[sonarsourcev1alpha1.KubeAppInstance] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppManagedby] = fmt.Sprintf("sonarqube-operator.v%s", version.Version)
+
+	return labels
+}
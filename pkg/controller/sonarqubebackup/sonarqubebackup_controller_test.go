@@ -0,0 +1,176 @@
+package sonarqubebackup
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+	"time"
+)
+
+const (
+	ReconcileErrorFormat string = "reconcile: (%v)"
+)
+
+// TestSonarQubeBackupController runs ReconcileSonarQubeBackup.Reconcile() against a
+// fake client that tracks a SonarQubeBackup object.
+func TestSonarQubeBackupController(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource that is backed up.
+	sonarqubeServer := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+			ObservedVersion: "8.3.0",
+		},
+	}
+	// A SonarQubeBackup resource with metadata and spec.
+	sonarqubeBackup := &sonarsourcev1alpha1.SonarQubeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(time.Date(2020, 5, 20, 10, 0, 0, 0, time.UTC)),
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeBackupSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Storage: sonarsourcev1alpha1.BackupStorage{
+				PersistentVolumeClaim: &[]string{"backups"}[0],
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeServer,
+		sonarqubeBackup,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeServer, sonarqubeBackup)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeBackup object with the scheme and fake client.
+	r := &ReconcileSonarQubeBackup{client: cl, scheme: s}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for config secret of server")
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SecretName(name, nil),
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			utils.SonarPropertiesFile: []byte("sonar.jdbc.url=jdbc:postgresql://postgres/sonar\nsonar.jdbc.username=sonar\nsonar.jdbc.password=secret\n"),
+		},
+	}
+	err = r.client.Create(context.TODO(), secret)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	// Secret and Job are created, then the Job is waited on
+	for _, v := range []string{"Secret", "Job"} {
+		res, err = r.Reconcile(req)
+		if err != nil {
+			t.Fatalf(ReconcileErrorFormat, err)
+		}
+		if !res.Requeue {
+			t.Errorf("reconcile did not requeue after creating %s", v)
+		}
+	}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for backup job to complete")
+	}
+
+	job := &batchv1.Job{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: r.jobName(sonarqubeBackup), Namespace: namespace}, job)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if job.Name != name+"-26499480" {
+		t.Errorf("reconcile: job name %s is not named after the creation minute", job.Name)
+	}
+	completionTime := metav1.NewTime(time.Date(2020, 5, 20, 10, 5, 0, 0, time.UTC))
+	job.Status = batchv1.JobStatus{
+		Succeeded:      1,
+		CompletionTime: &completionTime,
+		Conditions: []batchv1.JobCondition{{
+			Type:   batchv1.JobComplete,
+			Status: corev1.ConditionTrue,
+		}},
+	}
+	err = r.client.Status().Update(context.TODO(), job)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued even though everything should be good")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeBackup)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeBackup.Status.LastBackup != job.Name {
+		t.Errorf("reconcile: last backup %s is not job %s", sonarqubeBackup.Status.LastBackup, job.Name)
+	}
+	if sonarqubeBackup.Status.Location != "pvc://backups" {
+		t.Errorf("reconcile: unexpected location %s", sonarqubeBackup.Status.Location)
+	}
+
+	// The completed Job is not replaced once it is gone
+	err = r.client.Delete(context.TODO(), job)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued after the job of a completed backup was removed")
+	}
+}
@@ -0,0 +1,387 @@
+package sonarqubebackup
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	VolumeWork       = "work"
+	VolumeExtensions = "extensions"
+	VolumeBackup     = "backup"
+
+	VolumePathWork       = "/work"
+	VolumePathExtensions = "/extensions"
+	VolumePathBackup     = "/backup"
+)
+
+// dumpScript writes the database dump, the extensions archive, and the manifest of a backup to the work volume
+var dumpScript = fmt.Sprintf(`set -e
+pg_dump --format=custom --no-owner --file=%[1]s/%[2]s
+tar -czf %[1]s/%[3]s -C %[4]s .
+cat > %[1]s/%[5]s <<EOF
+{"name": "${BACKUP_NAME}", "kind": "${SERVER_KIND}", "server": "${SERVER_NAME}", "version": "${SONARQUBE_VERSION}", "database": "${PGDATABASE}", "created": "$(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)"}
+EOF
+`, VolumePathWork, sonarsourcev1alpha1.BackupDatabaseFile, sonarsourcev1alpha1.BackupExtensionsFile, VolumePathExtensions, sonarsourcev1alpha1.BackupManifestFile)
+
+// pvcUploadScript copies the backup to the storage volume and removes backups exceeding the retention
+var pvcUploadScript = fmt.Sprintf(`set -e
+mkdir -p "%[2]s/${BACKUP_NAME}"
+cp %[1]s/* "%[2]s/${BACKUP_NAME}/"
+ls -1 %[2]s | grep -E "^${BACKUP_PREFIX}-[0-9]+$" | sort -r | tail -n +$((RETENTION + 1)) | while read -r v; do rm -rf "%[2]s/$v"; done
+`, VolumePathWork, VolumePathBackup)
+
+// s3UploadScript uploads the backup to the object store and removes backups exceeding the retention
+var s3UploadScript = fmt.Sprintf(`set -e
+mc alias set backup "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}" > /dev/null
+mc cp --recursive %[1]s/ "backup/${S3_PATH}/${BACKUP_NAME}/"
+mc ls "backup/${S3_PATH}/" | awk '{print $NF}' | tr -d / | grep -E "^${BACKUP_PREFIX}-[0-9]+$" | sort -r | tail -n +$((RETENTION + 1)) | while read -r v; do mc rm --recursive --force "backup/${S3_PATH}/$v/"; done
+`, VolumePathWork)
+
+// Reconciles Job of a single backup or CronJob of scheduled backups for SonarQubeBackup
+// Returns: Error
+// If Error is non-nil, Job or CronJob is not in expected state
+// Errors:
+//   ErrorReasonResourceCreate: returned when Job or CronJob does not exists
+//   ErrorReasonResourceUpdate: returned when CronJob was updated to meet expected state or deleted because schedule is not set
+//   ErrorReasonResourceWaiting: returned when Job of a single backup has not completed
+//   ErrorReasonResourceInvalid: returned when Job of a single backup failed
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeBackup) ReconcileJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) error {
+	if cr.Spec.Schedule == nil {
+		err := utils.DeleteResourceIfOwned(r.client, cr, "CronJob", types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, &batchv1beta1.CronJob{})
+		if err != nil {
+			return err
+		}
+
+		// The Job of a completed backup may have been removed, don't take another one
+		if cr.Status.LastBackup == r.jobName(cr) {
+			return nil
+		}
+
+		job, err := r.findJob(cr, target)
+		if err != nil {
+			return err
+		}
+
+		return utils.VerifyJob(job, "backup job")
+	}
+
+	cronJob, err := r.findCronJob(cr, target)
+	if err != nil {
+		return err
+	}
+
+	return r.verifyCronJob(cr, target, cronJob)
+}
+
+// jobName returns the name of the Job of a single backup
+// Backups are named after their Job, names follow the CronJob convention of appending the scheduled minute so
+// backups of both sort by age
+func (r *ReconcileSonarQubeBackup) jobName(cr *sonarsourcev1alpha1.SonarQubeBackup) string {
+	return fmt.Sprintf("%s-%d", cr.Name, cr.CreationTimestamp.Unix()/60)
+}
+
+// lastBackup returns the Job of the last completed backup of SonarQubeBackup or nil
+func (r *ReconcileSonarQubeBackup) lastBackup(cr *sonarsourcev1alpha1.SonarQubeBackup) (*batchv1.Job, error) {
+	list := &batchv1.JobList{}
+	err := r.client.List(context.TODO(), list, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		sonarsourcev1alpha1.BackupTypeLabel: cr.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var output *batchv1.Job
+	for i := range list.Items {
+		v := &list.Items[i]
+		if v.Status.Succeeded == 0 || v.Status.CompletionTime == nil {
+			continue
+		}
+		if output == nil || output.Status.CompletionTime.Before(v.Status.CompletionTime) {
+			output = v
+		}
+	}
+
+	return output, nil
+}
+
+func (r *ReconcileSonarQubeBackup) findJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1.Job, error) {
+	newJob, err := r.newJob(cr, target)
+	if err != nil {
+		return newJob, err
+	}
+
+	foundJob := &batchv1.Job{}
+
+	return foundJob, utils.CreateResourceIfNotFound(r.client, newJob, foundJob)
+}
+
+func (r *ReconcileSonarQubeBackup) newJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1.Job, error) {
+	jobSpec, err := r.newJobSpec(cr, target)
+	if err != nil {
+		return nil, err
+	}
+
+	dep := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      r.jobName(cr),
+			Labels:    r.Labels(cr),
+		},
+		Spec: jobSpec,
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQubeBackup) findCronJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1beta1.CronJob, error) {
+	newCronJob, err := r.newCronJob(cr, target)
+	if err != nil {
+		return newCronJob, err
+	}
+
+	foundCronJob := &batchv1beta1.CronJob{}
+
+	return foundCronJob, utils.CreateResourceIfNotFound(r.client, newCronJob, foundCronJob)
+}
+
+func (r *ReconcileSonarQubeBackup) newCronJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1beta1.CronJob, error) {
+	jobSpec, err := r.newJobSpec(cr, target)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := utils.GenVersion(jobSpec, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dep := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   cr.Namespace,
+			Name:        cr.Name,
+			Labels:      r.Labels(cr),
+			Annotations: map[string]string{sonarsourcev1alpha1.BackupRevisionAnnotation: revision},
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:                   *cr.Spec.Schedule,
+			ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &[]int32{3}[0],
+			FailedJobsHistoryLimit:     &[]int32{1}[0],
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: r.Labels(cr),
+				},
+				Spec: jobSpec,
+			},
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+// verifyCronJob replaces schedule and job template of CronJob when they changed
+// The job template is compared by the revision annotation since the api server sets defaults on it
+func (r *ReconcileSonarQubeBackup) verifyCronJob(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget, cronJob *batchv1beta1.CronJob) error {
+	newCronJob, err := r.newCronJob(cr, target)
+	if err != nil {
+		return err
+	}
+
+	if cronJob.Spec.Schedule != newCronJob.Spec.Schedule {
+		cronJob.Spec.Schedule = newCronJob.Spec.Schedule
+		return utils.UpdateResource(r.client, cronJob, utils.ErrorReasonResourceUpdate, "updated backup schedule")
+	}
+
+	revision := newCronJob.Annotations[sonarsourcev1alpha1.BackupRevisionAnnotation]
+	if cronJob.Annotations[sonarsourcev1alpha1.BackupRevisionAnnotation] != revision {
+		if cronJob.Annotations == nil {
+			cronJob.Annotations = make(map[string]string)
+		}
+		cronJob.Annotations[sonarsourcev1alpha1.BackupRevisionAnnotation] = revision
+		cronJob.Spec.JobTemplate = newCronJob.Spec.JobTemplate
+		return utils.UpdateResource(r.client, cronJob, utils.ErrorReasonResourceUpdate, "updated backup job template")
+	}
+
+	return nil
+}
+
+// newJobSpec returns a Job that dumps the database and archives the extensions of target, then writes both to storage
+func (r *ReconcileSonarQubeBackup) newJobSpec(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (batchv1.JobSpec, error) {
+	retention := utils.DefaultBackupRetention
+	if cr.Spec.Retention != nil {
+		retention = *cr.Spec.Retention
+	}
+
+	image := utils.DefaultDatabaseImage
+	if cr.Spec.Image != nil {
+		image = *cr.Spec.Image
+	}
+
+	secretEnv := func(env, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: r.secretName(cr)},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	// Backups are named after the Job, which is also set for Jobs created by a CronJob
+	backupEnv := []corev1.EnvVar{
+		{
+			Name: "BACKUP_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels['job-name']"},
+			},
+		},
+		{Name: "BACKUP_PREFIX", Value: cr.Name},
+		{Name: "RETENTION", Value: fmt.Sprint(retention)},
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: VolumeWork,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: VolumeExtensions,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: target.Claim,
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+
+	var upload corev1.Container
+	switch {
+	case cr.Spec.Storage.PersistentVolumeClaim != nil:
+		volumes = append(volumes, corev1.Volume{
+			Name: VolumeBackup,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: *cr.Spec.Storage.PersistentVolumeClaim,
+				},
+			},
+		})
+		upload = corev1.Container{
+			Name:    "upload",
+			Image:   image,
+			Command: []string{"/bin/sh", "-c", pvcUploadScript},
+			Env:     backupEnv,
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: VolumeWork, MountPath: VolumePathWork},
+				{Name: VolumeBackup, MountPath: VolumePathBackup},
+			},
+		}
+	case cr.Spec.Storage.S3 != nil:
+		s3 := cr.Spec.Storage.S3
+		uploadImage := utils.DefaultBackupUploadImage
+		if s3.Image != nil {
+			uploadImage = *s3.Image
+		}
+		upload = corev1.Container{
+			Name:    "upload",
+			Image:   uploadImage,
+			Command: []string{"/bin/sh", "-c", s3UploadScript},
+			Env: append([]corev1.EnvVar{
+				{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+				{Name: "S3_PATH", Value: utils.S3Path(s3)},
+				{Name: "HOME", Value: "/tmp"},
+			}, backupEnv...),
+			EnvFrom: []corev1.EnvFromSource{
+				{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: s3.CredentialsSecret},
+					},
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: VolumeWork, MountPath: VolumePathWork},
+			},
+		}
+	default:
+		return batchv1.JobSpec{}, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: "storage requires one of persistentVolumeClaim or s3",
+		}
+	}
+
+	jobSpec := batchv1.JobSpec{
+		BackoffLimit: &[]int32{2}[0],
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: r.Labels(cr),
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyNever,
+				Volumes:       volumes,
+				InitContainers: []corev1.Container{
+					{
+						Name:    "dump",
+						Image:   image,
+						Command: []string{"/bin/sh", "-c", dumpScript},
+						Env: append([]corev1.EnvVar{
+							{Name: "PGHOST", Value: target.Database.Host},
+							{Name: "PGPORT", Value: target.Database.Port},
+							{Name: "PGDATABASE", Value: target.Database.Database},
+							secretEnv("PGUSER", sonarsourcev1alpha1.DatabaseSecretUsername),
+							secretEnv("PGPASSWORD", sonarsourcev1alpha1.DatabaseSecretPassword),
+							{Name: "SERVER_KIND", Value: target.Kind},
+							{Name: "SERVER_NAME", Value: target.Name},
+							{Name: "SONARQUBE_VERSION", Value: target.Version},
+						}, backupEnv...),
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: VolumeWork, MountPath: VolumePathWork},
+							{Name: VolumeExtensions, MountPath: VolumePathExtensions, SubPath: "extensions", ReadOnly: true},
+						},
+					},
+				},
+				Containers: []corev1.Container{upload},
+			},
+		},
+	}
+
+	// The claim can only be mounted next to a running server
+	if target.Running {
+		jobSpec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{sonarsourcev1alpha1.ServerTypeLabel: target.Server},
+						},
+						TopologyKey: corev1.LabelHostname,
+					},
+				},
+			},
+		}
+	}
+
+	return jobSpec, nil
+}
@@ -0,0 +1,229 @@
+package sonarqubebackup
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeBackupJob runs ReconcileSonarQubeBackup.ReconcileJob() against a
+// fake client with scheduled backups of a SonarQube cluster to S3
+func TestSonarQubeBackupJob(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQube cluster with a managed database that is backed up.
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeSpec{
+			Database: &sonarsourcev1alpha1.Database{
+				Managed: &sonarsourcev1alpha1.ManagedDatabase{},
+			},
+		},
+		Status: sonarsourcev1alpha1.SonarQubeStatus{
+			Version: "8.3.1",
+		},
+	}
+	// The config secret with the sonar.jdbc.* properties rendered for the managed database
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SecretName(name, nil),
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			utils.SonarPropertiesFile: []byte("sonar.jdbc.url=jdbc:postgresql://sonarqube-operator-database:5432/sonar\nsonar.jdbc.username=sonar\nsonar.jdbc.password=secret\n"),
+		},
+	}
+	// A SonarQubeBackup resource with metadata and spec.
+	sonarqubeBackup := &sonarsourcev1alpha1.SonarQubeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeBackupSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Kind: &[]string{sonarsourcev1alpha1.ServerKindSonarQube}[0],
+				Name: name,
+			},
+			Storage: sonarsourcev1alpha1.BackupStorage{
+				S3: &sonarsourcev1alpha1.S3Storage{
+					Endpoint:          "http://minio:9000",
+					Bucket:            "sonarqube",
+					Prefix:            &[]string{"/backups/"}[0],
+					CredentialsSecret: "minio",
+				},
+			},
+			Schedule:  &[]string{"0 2 * * *"}[0],
+			Retention: &[]int32{3}[0],
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+		secret,
+		sonarqubeBackup,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube, sonarqubeBackup)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeBackup object with the scheme and fake client.
+	r := &ReconcileSonarQubeBackup{client: cl, scheme: s}
+
+	target, err := utils.GetBackupTarget(r.client, namespace, sonarqubeBackup.Spec.ServerRef)
+	if err != nil {
+		t.Fatalf("getBackupTarget: (%v)", err)
+	}
+	if target.Claim != name+"-application-0" {
+		t.Errorf("getBackupTarget: expected claim of first application node got %s", target.Claim)
+	}
+	if target.Database.Host != name+"-database" || target.Database.Port != "5432" || target.Database.Database != "sonar" {
+		t.Errorf("getBackupTarget: unexpected database %v", target.Database)
+	}
+
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileJob: resource created error not thrown when creating CronJob")
+	}
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if err != nil {
+		t.Errorf("reconcileJob: returned error even though CronJob is in expected state (%v)", err)
+	}
+
+	cronJob := &batchv1beta1.CronJob{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cronJob)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	env := make(map[string]string)
+	for _, v := range append(podSpec.InitContainers[0].Env, podSpec.Containers[0].Env...) {
+		env[v.Name] = v.Value
+	}
+	for k, v := range map[string]string{
+		"PGHOST":            name + "-database",
+		"PGDATABASE":        "sonar",
+		"SONARQUBE_VERSION": "8.3.1",
+		"S3_PATH":           "sonarqube/backups",
+		"RETENTION":         "3",
+	} {
+		if env[k] != v {
+			t.Errorf("reconcileJob: expected %s to be %s got %s", k, v, env[k])
+		}
+	}
+	if podSpec.Affinity != nil {
+		t.Error("reconcileJob: backup job required to run next to a server that is not running")
+	}
+	if podSpec.Containers[0].Image != utils.DefaultBackupUploadImage {
+		t.Errorf("reconcileJob: expected upload image %s got %s", utils.DefaultBackupUploadImage, podSpec.Containers[0].Image)
+	}
+	if len(podSpec.Containers[0].EnvFrom) == 0 || podSpec.Containers[0].EnvFrom[0].SecretRef.Name != "minio" {
+		t.Error("reconcileJob: object store credentials not set on upload container")
+	}
+
+	sonarqubeBackup.Spec.Schedule = &[]string{"0 3 * * *"}[0]
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileJob: resource update error not returned when schedule changed")
+	}
+
+	sonarqubeBackup.Spec.Retention = &[]int32{5}[0]
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileJob: resource update error not returned when job template changed")
+	}
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if err != nil {
+		t.Errorf("reconcileJob: returned error even though CronJob is in expected state (%v)", err)
+	}
+
+	// The first application node mounts the claim once it runs
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-application-0",
+			Namespace: namespace,
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1},
+	}
+	if err := r.client.Create(context.TODO(), deployment); err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	target, err = utils.GetBackupTarget(r.client, namespace, sonarqubeBackup.Spec.ServerRef)
+	if err != nil {
+		t.Fatalf("getBackupTarget: (%v)", err)
+	}
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileJob: resource update error not returned when server started")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cronJob)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	podSpec = cronJob.Spec.JobTemplate.Spec.Template.Spec
+	if podSpec.Affinity == nil || podSpec.Affinity.PodAffinity == nil || len(podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Error("reconcileJob: backup job not required to run next to the server mounting its claim")
+	}
+
+	// Removing the schedule replaces the CronJob with a single backup
+	sonarqubeBackup.Spec.Schedule = nil
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileJob: resource update error not returned when deleting CronJob")
+	}
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileJob: resource created error not thrown when creating Job")
+	}
+
+	job := &batchv1.Job{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: r.jobName(sonarqubeBackup), Namespace: namespace}, job)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:    batchv1.JobFailed,
+		Status:  corev1.ConditionTrue,
+		Message: "Job has reached the specified backoff limit",
+	}}
+	err = r.client.Status().Update(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	err = r.ReconcileJob(sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceInvalid {
+		t.Error("reconcileJob: resource invalid error not returned when backup job failed")
+	}
+
+	// Only PostgreSQL can be dumped
+	secret.Data[utils.SonarPropertiesFile] = []byte("sonar.jdbc.url=jdbc:sqlserver://mssql;databaseName=sonar\n")
+	err = r.client.Update(context.TODO(), secret)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	_, err = utils.GetBackupTarget(r.client, namespace, sonarqubeBackup.Spec.ServerRef)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("getBackupTarget: spec invalid error not returned for a database that is not PostgreSQL")
+	}
+}
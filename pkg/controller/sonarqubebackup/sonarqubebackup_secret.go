@@ -0,0 +1,23 @@
+package sonarqubebackup
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reconciles Secret with the database credentials of the backup Job for SonarQubeBackup
+// Returns: *Secret, Error
+// If Error is non-nil, Secret is not in expected state
+// Errors:
+//   ErrorReasonResourceCreate: returned when Secret does not exists
+//   ErrorReasonResourceUpdate: returned when Secret was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeBackup) ReconcileSecret(cr *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*corev1.Secret, error) {
+	return utils.ReconcileCredentialsSecret(r.client, r.scheme, cr, r.secretName(cr), r.Labels(cr), target.Database)
+}
+
+func (r *ReconcileSonarQubeBackup) secretName(cr *sonarsourcev1alpha1.SonarQubeBackup) string {
+	return fmt.Sprintf("%s-backup", cr.Name)
+}
@@ -0,0 +1,171 @@
+package sonarqubeproject
+
+import (
+	"context"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	"testing"
+
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+const (
+	ReconcileErrorFormat string = "reconcile: (%v)"
+)
+
+// TestSonarQubeProjectController runs ReconcileSonarQubeProject.Reconcile() against a
+// fake client that tracks a SonarQubeProject object.
+func TestSonarQubeProjectController(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource the project is provisioned on.
+	sonarqubeServer := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			AdminSecret: &[]string{"admin"}[0],
+		},
+	}
+	// A SonarQubeProject resource with metadata and spec.
+	sonarqubeProject := &sonarsourcev1alpha1.SonarQubeProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeProjectSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Key:        "project",
+			Visibility: &[]sonarsourcev1alpha1.ProjectVisibility{sonarsourcev1alpha1.ProjectPrivate}[0],
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "127.0.0.1",
+			Ports:     []corev1.ServicePort{{Port: sonarsourcev1alpha1.ApplicationWebPort}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *sonarqubeServer.Spec.AdminSecret,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.AdminSecretToken: []byte("token"),
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeServer,
+		sonarqubeProject,
+		service,
+		secret,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeServer, sonarqubeProject)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeProject object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeProject{client: cl, scheme: s, apiClient: apiMock}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for server to be ready")
+	}
+
+	sonarqubeServer.Status = sonarsourcev1alpha1.SonarQubeServerStatus{
+		Conditions: status.Conditions{{
+			Type:   sonarsourcev1alpha1.ConditionProgressing,
+			Status: corev1.ConditionFalse,
+		}},
+		Service:         service.Name,
+		ObservedVersion: "8.3.0",
+	}
+	err = r.client.Status().Update(context.TODO(), sonarqubeServer)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after creating project")
+	}
+	if !apiMock.CreateProjectCalled {
+		t.Error("reconcile: project not created")
+	}
+	if apiMock.Auth == nil || apiMock.Auth.Token != "token" {
+		t.Error("reconcile: admin token not used for api calls")
+	}
+
+	apiMock.GetProjectOutput = &api_client.Project{
+		Key:        sonarqubeProject.Spec.Key,
+		Visibility: string(sonarsourcev1alpha1.ProjectPublic),
+	}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after updating visibility")
+	}
+	if !apiMock.UpdateProjectVisibilityCalled {
+		t.Error("reconcile: project visibility not updated")
+	}
+
+	apiMock.GetProjectOutput.Visibility = string(sonarsourcev1alpha1.ProjectPrivate)
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != utils.ResyncPeriod {
+		t.Error("reconcile not scheduled for resync even though everything should be good")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeProject)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeProject.Status.URL != "http://127.0.0.1:9000/dashboard?id=project" {
+		t.Errorf("reconcile: project url %s not set in status", sonarqubeProject.Status.URL)
+	}
+}
@@ -0,0 +1,157 @@
+package sonarqubeproject
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+)
+
+// Reconciles project on SonarQube server for SonarQubeProject
+// Returns: Project, Error
+// If Error is non-nil, Project is not in expected state
+// Errors:
+//   ErrorReasonSpecInvalid: returned when spec can not be applied to a project
+//   ErrorReasonResourceCreate: returned when project does not exists
+//   ErrorReasonResourceUpdate: returned when project was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from api occurs
+func (r *ReconcileSonarQubeProject) ReconcileProject(cr *sonarsourcev1alpha1.SonarQubeProject, apiClient api_client.APIReader) (*api_client.Project, error) {
+	err := r.validateProject(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := r.findProject(cr, apiClient)
+	if err != nil {
+		return project, err
+	}
+
+	err = r.verifyProject(cr, apiClient, project)
+	if err != nil {
+		return project, err
+	}
+
+	return project, nil
+}
+
+func (r *ReconcileSonarQubeProject) validateProject(cr *sonarsourcev1alpha1.SonarQubeProject) error {
+	if cr.Spec.Key == "" {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: "project key must be set",
+		}
+	}
+
+	if cr.Spec.Visibility != nil && *cr.Spec.Visibility != sonarsourcev1alpha1.ProjectPublic && *cr.Spec.Visibility != sonarsourcev1alpha1.ProjectPrivate {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("project visibility must be %s or %s", sonarsourcev1alpha1.ProjectPublic, sonarsourcev1alpha1.ProjectPrivate),
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQubeProject) findProject(cr *sonarsourcev1alpha1.SonarQubeProject, apiClient api_client.APIReader) (*api_client.Project, error) {
+	project, err := apiClient.GetProject(cr.Spec.Key)
+	if err != nil || project != nil {
+		return project, err
+	}
+
+	name := cr.Spec.Key
+	if cr.Spec.Name != nil {
+		name = *cr.Spec.Name
+	}
+
+	var visibility string
+	if cr.Spec.Visibility != nil {
+		visibility = string(*cr.Spec.Visibility)
+	}
+
+	project, err = apiClient.CreateProject(cr.Spec.Key, name, visibility)
+	if err != nil {
+		return project, err
+	}
+
+	return project, &utils.Error{
+		Reason:  utils.ErrorReasonResourceCreate,
+		Message: fmt.Sprintf("created project %s", cr.Spec.Key),
+	}
+}
+
+func (r *ReconcileSonarQubeProject) verifyProject(cr *sonarsourcev1alpha1.SonarQubeProject, apiClient api_client.APIReader, project *api_client.Project) error {
+	if cr.Spec.Visibility != nil && project.Visibility != string(*cr.Spec.Visibility) {
+		err := apiClient.UpdateProjectVisibility(cr.Spec.Key, string(*cr.Spec.Visibility))
+		if err != nil {
+			return err
+		}
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceUpdate,
+			Message: fmt.Sprintf("updated visibility of project %s to %s", cr.Spec.Key, *cr.Spec.Visibility),
+		}
+	}
+
+	if cr.Spec.MainBranch != nil {
+		branches, err := apiClient.ProjectBranches(cr.Spec.Key)
+		if err != nil {
+			return err
+		}
+		for _, v := range branches {
+			if v.IsMain && v.Name != *cr.Spec.MainBranch {
+				err := apiClient.RenameMainBranch(cr.Spec.Key, *cr.Spec.MainBranch)
+				if err != nil {
+					return err
+				}
+				return &utils.Error{
+					Reason:  utils.ErrorReasonResourceUpdate,
+					Message: fmt.Sprintf("renamed main branch of project %s from %s to %s", cr.Spec.Key, v.Name, *cr.Spec.MainBranch),
+				}
+			}
+		}
+	}
+
+	if cr.Spec.QualityGate != nil {
+		qualityGate, err := apiClient.ProjectQualityGate(cr.Spec.Key)
+		if err != nil {
+			return err
+		}
+		if qualityGate == nil || qualityGate.Name != *cr.Spec.QualityGate {
+			err := apiClient.SelectQualityGate(cr.Spec.Key, *cr.Spec.QualityGate)
+			if err != nil {
+				return err
+			}
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceUpdate,
+				Message: fmt.Sprintf("assigned quality gate %s to project %s", *cr.Spec.QualityGate, cr.Spec.Key),
+			}
+		}
+	}
+
+	if len(cr.Spec.QualityProfiles) > 0 {
+		qualityProfiles, err := apiClient.ProjectQualityProfiles(cr.Spec.Key)
+		if err != nil {
+			return err
+		}
+		for _, v := range cr.Spec.QualityProfiles {
+			var assigned bool
+			for _, p := range qualityProfiles {
+				if p.Language == v.Language && p.Name == v.Name {
+					assigned = true
+					break
+				}
+			}
+			if !assigned {
+				err := apiClient.AddProjectQualityProfile(cr.Spec.Key, v.Language, v.Name)
+				if err != nil {
+					return err
+				}
+				return &utils.Error{
+					Reason:  utils.ErrorReasonResourceUpdate,
+					Message: fmt.Sprintf("assigned %s quality profile %s to project %s", v.Language, v.Name, cr.Spec.Key),
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package sonarqubeproject
+
+import (
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeProjectProject runs ReconcileSonarQubeProject.ReconcileProject() against a
+// mocked api client
+func TestSonarQubeProjectProject(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeProject resource with metadata and spec.
+	sonarqubeProject := &sonarsourcev1alpha1.SonarQubeProject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeProjectSpec{
+			Key:         "project",
+			MainBranch:  &[]string{"main"}[0],
+			QualityGate: &[]string{"strict"}[0],
+			QualityProfiles: []sonarsourcev1alpha1.ProjectQualityProfile{
+				{Language: "java", Name: "strict"},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeProject,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeProject)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeProject object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{
+		GetProjectOutput: &api_client.Project{Key: "project"},
+		ProjectBranchesOutput: []api_client.ProjectBranch{
+			{Name: "master", IsMain: true},
+		},
+		ProjectQualityGateOutput: &api_client.QualityGate{Name: "Sonar way", Default: true},
+		ProjectQualityProfilesOutput: []api_client.QualityProfile{
+			{Language: "java", Name: "Sonar way", IsDefault: true},
+		},
+	}
+	r := &ReconcileSonarQubeProject{client: cl, scheme: s, apiClient: apiMock}
+
+	_, err := r.ReconcileProject(sonarqubeProject, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.RenameMainBranchCalled {
+		t.Error("ReconcileProject: main branch not renamed")
+	}
+
+	apiMock.ProjectBranchesOutput[0].Name = "main"
+	_, err = r.ReconcileProject(sonarqubeProject, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.SelectQualityGateCalled {
+		t.Error("ReconcileProject: quality gate not assigned")
+	}
+
+	apiMock.ProjectQualityGateOutput.Name = "strict"
+	_, err = r.ReconcileProject(sonarqubeProject, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.AddProjectQualityProfileCalled {
+		t.Error("ReconcileProject: quality profile not assigned")
+	}
+
+	apiMock.ProjectQualityProfilesOutput[0].Name = "strict"
+	_, err = r.ReconcileProject(sonarqubeProject, apiMock)
+	if err != nil {
+		t.Fatalf("ReconcileProject: (%v)", err)
+	}
+
+	sonarqubeProject.Spec.Visibility = &[]sonarsourcev1alpha1.ProjectVisibility{"hidden"}[0]
+	_, err = r.ReconcileProject(sonarqubeProject, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileProject: spec invalid error not thrown for unknown visibility")
+	}
+}
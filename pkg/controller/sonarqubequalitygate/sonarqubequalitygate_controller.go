@@ -0,0 +1,104 @@
+package sonarqubequalitygate
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_sonarqubequalitygate")
+
+// Add creates a new SonarQubeQualityGate Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSonarQubeQualityGate{
+		client:    mgr.GetClient(),
+		scheme:    mgr.GetScheme(),
+		apiClient: &api_client.APIClient{},
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("sonarqubequalitygate-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource SonarQubeQualityGate
+	err = c.Watch(&source.Kind{Type: &sonarsourcev1alpha1.SonarQubeQualityGate{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileSonarQubeQualityGate implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSonarQubeQualityGate{}
+
+// ReconcileSonarQubeQualityGate reconciles a SonarQubeQualityGate object
+type ReconcileSonarQubeQualityGate struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client    client.Client
+	scheme    *runtime.Scheme
+	apiClient api_client.APIProvider
+}
+
+// Reconcile reads that state of the cluster for a SonarQubeQualityGate object and makes changes based on the state read
+// and what is in the SonarQubeQualityGate.Spec
+func (r *ReconcileSonarQubeQualityGate) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling SonarQubeQualityGate")
+
+	// Fetch the SonarQubeQualityGate instance
+	instance := &sonarsourcev1alpha1.SonarQubeQualityGate{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Quality gates are left on the server so projects using them keep their policy.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	serverURL, auth, err := utils.GetServerConnection(r.client, instance.Namespace, instance.Spec.ServerRef)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	_, err = r.ReconcileQualityGate(instance, r.apiClient.New(serverURL, auth))
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	newStatus := instance.DeepCopy()
+
+	newStatus.Status.Conditions = utils.ClearConditions(newStatus.Status.Conditions)
+	newStatus.Status.ObservedGeneration = instance.Generation
+
+	utils.UpdateStatus(r.client, newStatus, instance)
+
+	// Quality gates can be changed on the server directly, requeue to correct drift
+	return reconcile.Result{RequeueAfter: utils.ResyncPeriod}, nil
+}
@@ -0,0 +1,164 @@
+package sonarqubequalitygate
+
+import (
+	"context"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"testing"
+
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+const (
+	ReconcileErrorFormat string = "reconcile: (%v)"
+)
+
+// TestSonarQubeQualityGateController runs ReconcileSonarQubeQualityGate.Reconcile() against a
+// fake client that tracks a SonarQubeQualityGate object.
+func TestSonarQubeQualityGateController(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource the quality gate is provisioned on.
+	sonarqubeServer := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			AdminSecret: &[]string{"admin"}[0],
+		},
+	}
+	// A SonarQubeQualityGate resource with metadata and spec.
+	sonarqubeQualityGate := &sonarsourcev1alpha1.SonarQubeQualityGate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeQualityGateSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Conditions: []sonarsourcev1alpha1.QualityGateCondition{
+				{Metric: "coverage", Operator: sonarsourcev1alpha1.QualityGateLessThan, Threshold: "80"},
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "127.0.0.1",
+			Ports:     []corev1.ServicePort{{Port: sonarsourcev1alpha1.ApplicationWebPort}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *sonarqubeServer.Spec.AdminSecret,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.AdminSecretToken: []byte("token"),
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeServer,
+		sonarqubeQualityGate,
+		service,
+		secret,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeServer, sonarqubeQualityGate)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeQualityGate object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeQualityGate{client: cl, scheme: s, apiClient: apiMock}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for server to be ready")
+	}
+
+	sonarqubeServer.Status = sonarsourcev1alpha1.SonarQubeServerStatus{
+		Conditions: status.Conditions{{
+			Type:   sonarsourcev1alpha1.ConditionProgressing,
+			Status: corev1.ConditionFalse,
+		}},
+		Service:         service.Name,
+		ObservedVersion: "8.3.0",
+	}
+	err = r.client.Status().Update(context.TODO(), sonarqubeServer)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after creating quality gate")
+	}
+	if !apiMock.CreateQualityGateCalled {
+		t.Error("reconcile: quality gate not created")
+	}
+	if apiMock.Auth == nil || apiMock.Auth.Token != "token" {
+		t.Error("reconcile: admin token not used for api calls")
+	}
+
+	apiMock.GetQualityGateOutput = &api_client.QualityGate{Name: name}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after adding condition")
+	}
+	if !apiMock.CreateQualityGateConditionCalled {
+		t.Error("reconcile: quality gate condition not added")
+	}
+
+	apiMock.GetQualityGateOutput.Conditions = []api_client.QualityGateCondition{
+		{ID: "1", Metric: "coverage", Op: "LT", Error: "80"},
+	}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != utils.ResyncPeriod {
+		t.Error("reconcile not scheduled for resync even though everything should be good")
+	}
+}
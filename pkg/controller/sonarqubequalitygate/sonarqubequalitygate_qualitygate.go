@@ -0,0 +1,226 @@
+package sonarqubequalitygate
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+)
+
+// Reconciles quality gate on SonarQube server for SonarQubeQualityGate
+// Returns: QualityGate, Error
+// If Error is non-nil, QualityGate is not in expected state
+// Errors:
+//   ErrorReasonSpecInvalid: returned when spec can not be applied to a quality gate
+//   ErrorReasonResourceCreate: returned when quality gate does not exists
+//   ErrorReasonResourceUpdate: returned when quality gate was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from api occurs
+func (r *ReconcileSonarQubeQualityGate) ReconcileQualityGate(cr *sonarsourcev1alpha1.SonarQubeQualityGate, apiClient api_client.APIReader) (*api_client.QualityGate, error) {
+	err := r.validateQualityGate(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	qualityGate, err := r.findQualityGate(cr, apiClient)
+	if err != nil {
+		return qualityGate, err
+	}
+
+	err = r.verifyQualityGateConditions(cr, apiClient, qualityGate)
+	if err != nil {
+		return qualityGate, err
+	}
+
+	err = r.verifyQualityGateDefault(cr, apiClient, qualityGate)
+	if err != nil {
+		return qualityGate, err
+	}
+
+	err = r.verifyQualityGateProjects(cr, apiClient, qualityGate)
+	if err != nil {
+		return qualityGate, err
+	}
+
+	return qualityGate, nil
+}
+
+func (r *ReconcileSonarQubeQualityGate) qualityGateName(cr *sonarsourcev1alpha1.SonarQubeQualityGate) string {
+	if cr.Spec.Name != nil {
+		return *cr.Spec.Name
+	}
+	return cr.Name
+}
+
+func (r *ReconcileSonarQubeQualityGate) validateQualityGate(cr *sonarsourcev1alpha1.SonarQubeQualityGate) error {
+	metrics := make(map[string]bool)
+	for _, v := range cr.Spec.Conditions {
+		if v.Metric == "" || v.Threshold == "" {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: "quality gate conditions require metric and threshold",
+			}
+		}
+		if v.Operator != sonarsourcev1alpha1.QualityGateGreaterThan && v.Operator != sonarsourcev1alpha1.QualityGateLessThan {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("operator of condition %s must be %s or %s", v.Metric, sonarsourcev1alpha1.QualityGateGreaterThan, sonarsourcev1alpha1.QualityGateLessThan),
+			}
+		}
+		if metrics[v.Metric] {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("metric %s has more than one condition", v.Metric),
+			}
+		}
+		metrics[v.Metric] = true
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQubeQualityGate) findQualityGate(cr *sonarsourcev1alpha1.SonarQubeQualityGate, apiClient api_client.APIReader) (*api_client.QualityGate, error) {
+	name := r.qualityGateName(cr)
+
+	qualityGate, err := apiClient.GetQualityGate(name)
+	if err != nil || qualityGate != nil {
+		return qualityGate, err
+	}
+
+	qualityGate, err = apiClient.CreateQualityGate(name)
+	if err != nil {
+		return qualityGate, err
+	}
+
+	return qualityGate, &utils.Error{
+		Reason:  utils.ErrorReasonResourceCreate,
+		Message: fmt.Sprintf("created quality gate %s", name),
+	}
+}
+
+func (r *ReconcileSonarQubeQualityGate) verifyQualityGateConditions(cr *sonarsourcev1alpha1.SonarQubeQualityGate, apiClient api_client.APIReader, qualityGate *api_client.QualityGate) error {
+	name := r.qualityGateName(cr)
+
+	expected := make(map[string]api_client.QualityGateCondition)
+	for _, v := range cr.Spec.Conditions {
+		expected[v.Metric] = api_client.QualityGateCondition{
+			Metric: v.Metric,
+			Op:     string(v.Operator),
+			Error:  v.Threshold,
+		}
+	}
+
+	var (
+		update  func() error
+		message string
+	)
+	found := make(map[string]bool)
+	for _, v := range qualityGate.Conditions {
+		condition, ok := expected[v.Metric]
+		found[v.Metric] = true
+		if !ok {
+			id := v.ID
+			update = func() error { return apiClient.DeleteQualityGateCondition(id) }
+			message = fmt.Sprintf("removed condition %s from quality gate %s", v.Metric, name)
+			break
+		} else if condition.Op != v.Op || condition.Error != v.Error {
+			condition.ID = v.ID
+			update = func() error { return apiClient.UpdateQualityGateCondition(condition) }
+			message = fmt.Sprintf("updated condition %s of quality gate %s", v.Metric, name)
+			break
+		}
+	}
+
+	if update == nil {
+		for _, v := range cr.Spec.Conditions {
+			if !found[v.Metric] {
+				condition := expected[v.Metric]
+				update = func() error { return apiClient.CreateQualityGateCondition(name, condition) }
+				message = fmt.Sprintf("added condition %s to quality gate %s", v.Metric, name)
+				break
+			}
+		}
+	}
+
+	if update == nil {
+		return nil
+	}
+
+	if qualityGate.IsBuiltIn {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("conditions of built-in quality gate %s can not be changed", name),
+		}
+	}
+
+	err := update()
+	if err != nil {
+		return err
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: message,
+	}
+}
+
+func (r *ReconcileSonarQubeQualityGate) verifyQualityGateDefault(cr *sonarsourcev1alpha1.SonarQubeQualityGate, apiClient api_client.APIReader, qualityGate *api_client.QualityGate) error {
+	if cr.Spec.Default == nil || !*cr.Spec.Default || qualityGate.IsDefault {
+		return nil
+	}
+
+	name := r.qualityGateName(cr)
+	err := apiClient.SetDefaultQualityGate(name)
+	if err != nil {
+		return err
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("set quality gate %s as default", name),
+	}
+}
+
+func (r *ReconcileSonarQubeQualityGate) verifyQualityGateProjects(cr *sonarsourcev1alpha1.SonarQubeQualityGate, apiClient api_client.APIReader, _ *api_client.QualityGate) error {
+	if len(cr.Spec.Projects) == 0 {
+		return nil
+	}
+
+	name := r.qualityGateName(cr)
+	projects, err := apiClient.QualityGateProjects(name)
+	if err != nil {
+		return err
+	}
+
+	var selected []string
+	for _, v := range projects {
+		if !v.Selected {
+			continue
+		}
+		selected = append(selected, v.Key)
+		if !utils.ContainsString(cr.Spec.Projects, v.Key) {
+			err := apiClient.DeselectQualityGate(v.Key)
+			if err != nil {
+				return err
+			}
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceUpdate,
+				Message: fmt.Sprintf("removed project %s from quality gate %s", v.Key, name),
+			}
+		}
+	}
+
+	for _, v := range cr.Spec.Projects {
+		if !utils.ContainsString(selected, v) {
+			err := apiClient.SelectQualityGate(v, name)
+			if err != nil {
+				return err
+			}
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceUpdate,
+				Message: fmt.Sprintf("added project %s to quality gate %s", v, name),
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package sonarqubequalitygate
+
+import (
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeQualityGateQualityGate runs ReconcileSonarQubeQualityGate.ReconcileQualityGate() against a
+// mocked api client
+func TestSonarQubeQualityGateQualityGate(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeQualityGate resource with metadata and spec.
+	sonarqubeQualityGate := &sonarsourcev1alpha1.SonarQubeQualityGate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeQualityGateSpec{
+			Conditions: []sonarsourcev1alpha1.QualityGateCondition{
+				{Metric: "coverage", Operator: sonarsourcev1alpha1.QualityGateLessThan, Threshold: "80"},
+			},
+			Default:  &[]bool{true}[0],
+			Projects: []string{"project"},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeQualityGate,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeQualityGate)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeQualityGate object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{
+		GetQualityGateOutput: &api_client.QualityGate{
+			Name: name,
+			Conditions: []api_client.QualityGateCondition{
+				{ID: "1", Metric: "duplicated_lines_density", Op: "GT", Error: "3"},
+				{ID: "2", Metric: "coverage", Op: "LT", Error: "50"},
+			},
+		},
+		QualityGateProjectsOutput: []api_client.QualityGateProject{
+			{Key: "other", Selected: true},
+		},
+	}
+	r := &ReconcileSonarQubeQualityGate{client: cl, scheme: s, apiClient: apiMock}
+
+	tests := []struct {
+		update func()
+		called *bool
+		name   string
+	}{
+		{func() {}, &apiMock.DeleteQualityGateConditionCalled, "unlisted condition not removed"},
+		{func() { apiMock.GetQualityGateOutput.Conditions = apiMock.GetQualityGateOutput.Conditions[1:] }, &apiMock.UpdateQualityGateConditionCalled, "condition threshold not updated"},
+		{func() { apiMock.GetQualityGateOutput.Conditions[0].Error = "80" }, &apiMock.SetDefaultQualityGateCalled, "quality gate not set as default"},
+		{func() { apiMock.GetQualityGateOutput.IsDefault = true }, &apiMock.DeselectQualityGateCalled, "unlisted project not removed"},
+		{func() { apiMock.QualityGateProjectsOutput = nil }, &apiMock.SelectQualityGateCalled, "project not added"},
+	}
+
+	for _, test := range tests {
+		test.update()
+		_, err := r.ReconcileQualityGate(sonarqubeQualityGate, apiMock)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !*test.called {
+			t.Errorf("ReconcileQualityGate: %s", test.name)
+		}
+	}
+
+	apiMock.QualityGateProjectsOutput = []api_client.QualityGateProject{
+		{Key: "project", Selected: true},
+	}
+	_, err := r.ReconcileQualityGate(sonarqubeQualityGate, apiMock)
+	if err != nil {
+		t.Fatalf("ReconcileQualityGate: (%v)", err)
+	}
+
+	apiMock.GetQualityGateOutput.IsBuiltIn = true
+	apiMock.GetQualityGateOutput.Conditions = nil
+	_, err = r.ReconcileQualityGate(sonarqubeQualityGate, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileQualityGate: spec invalid error not thrown when changing built-in quality gate")
+	}
+
+	sonarqubeQualityGate.Spec.Conditions[0].Operator = "EQ"
+	_, err = r.ReconcileQualityGate(sonarqubeQualityGate, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileQualityGate: spec invalid error not thrown for unknown operator")
+	}
+}
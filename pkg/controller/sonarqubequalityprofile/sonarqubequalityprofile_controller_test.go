@@ -0,0 +1,179 @@
+package sonarqubequalityprofile
+
+import (
+	"context"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"testing"
+
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+)
+
+const (
+	ReconcileErrorFormat string = "reconcile: (%v)"
+)
+
+// TestSonarQubeQualityProfileController runs ReconcileSonarQubeQualityProfile.Reconcile() against a
+// fake client that tracks a SonarQubeQualityProfile object.
+func TestSonarQubeQualityProfileController(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource the quality profile is provisioned on.
+	sonarqubeServer := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			AdminSecret: &[]string{"admin"}[0],
+		},
+	}
+	// A SonarQubeQualityProfile resource with metadata and spec.
+	sonarqubeQualityProfile := &sonarsourcev1alpha1.SonarQubeQualityProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeQualityProfileSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Language: &[]string{"java"}[0],
+			Rules: []sonarsourcev1alpha1.QualityProfileRule{
+				{Key: "java:S1067", Severity: &[]string{"MAJOR"}[0]},
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "127.0.0.1",
+			Ports:     []corev1.ServicePort{{Port: sonarsourcev1alpha1.ApplicationWebPort}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *sonarqubeServer.Spec.AdminSecret,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.AdminSecretToken: []byte("token"),
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeServer,
+		sonarqubeQualityProfile,
+		service,
+		secret,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeServer, sonarqubeQualityProfile)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeQualityProfile object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeQualityProfile{client: cl, scheme: s, apiClient: apiMock}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for server to be ready")
+	}
+
+	sonarqubeServer.Status = sonarsourcev1alpha1.SonarQubeServerStatus{
+		Conditions: status.Conditions{{
+			Type:   sonarsourcev1alpha1.ConditionProgressing,
+			Status: corev1.ConditionFalse,
+		}},
+		Service:         service.Name,
+		ObservedVersion: "8.3.0",
+	}
+	err = r.client.Status().Update(context.TODO(), sonarqubeServer)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after creating quality profile")
+	}
+	if !apiMock.CreateQualityProfileCalled {
+		t.Error("reconcile: quality profile not created")
+	}
+
+	apiMock.GetQualityProfileOutput = &api_client.QualityProfile{Key: "AU-1", Name: name, Language: "java"}
+	apiMock.BackupQualityProfileOutput = `<profile><name>` + name + `</name><language>java</language><rules/></profile>`
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after activating rules")
+	}
+	if len(apiMock.ActivatedRules) != 1 {
+		t.Error("reconcile: rule not activated")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeQualityProfile)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if len(sonarqubeQualityProfile.Status.Drift) != 1 {
+		t.Error("reconcile: drift not reported in status")
+	}
+
+	apiMock.BackupQualityProfileOutput = `<profile><name>` + name + `</name><language>java</language><rules><rule><repositoryKey>java</repositoryKey><key>S1067</key><priority>MAJOR</priority></rule></rules></profile>`
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != utils.ResyncPeriod {
+		t.Error("reconcile not scheduled for resync even though everything should be good")
+	}
+	sonarqubeQualityProfile = &sonarsourcev1alpha1.SonarQubeQualityProfile{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeQualityProfile)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeQualityProfile.Status.Key != "AU-1" {
+		t.Error("reconcile: quality profile key not set in status")
+	}
+	if len(sonarqubeQualityProfile.Status.Drift) != 0 || sonarqubeQualityProfile.Status.DriftCorrected == nil {
+		t.Errorf("reconcile: drift not cleared once quality profile matches, got %v", sonarqubeQualityProfile.Status.Drift)
+	}
+}
@@ -0,0 +1,340 @@
+package sonarqubequalityprofile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	backupNameRegexp = regexp.MustCompile(`<name>[^<]*</name>`)
+	ruleSeverities   = []string{"INFO", "MINOR", "MAJOR", "CRITICAL", "BLOCKER"}
+)
+
+// Reconciles quality profile on SonarQube server for SonarQubeQualityProfile
+// Returns: QualityProfile, Error
+// If Error is non-nil, QualityProfile is not in expected state
+// Errors:
+//   ErrorReasonSpecInvalid: returned when spec can not be applied to a quality profile
+//   ErrorReasonResourceCreate: returned when quality profile does not exists
+//   ErrorReasonResourceUpdate: returned when quality profile was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from api occurs
+func (r *ReconcileSonarQubeQualityProfile) ReconcileQualityProfile(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, apiClient api_client.APIReader) (*api_client.QualityProfile, error) {
+	desired, err := r.desiredQualityProfile(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	qualityProfile, err := r.findQualityProfile(cr, apiClient, desired)
+	if err != nil {
+		return qualityProfile, err
+	}
+
+	if cr.Spec.Backup != nil {
+		err = r.verifyQualityProfileBackup(cr, apiClient, desired)
+		if err != nil {
+			return qualityProfile, err
+		}
+	} else {
+		err = r.verifyQualityProfileParent(cr, apiClient, qualityProfile)
+		if err != nil {
+			return qualityProfile, err
+		}
+
+		err = r.verifyQualityProfileRules(cr, apiClient, qualityProfile, desired)
+		if err != nil {
+			return qualityProfile, err
+		}
+	}
+
+	return qualityProfile, nil
+}
+
+// desiredQualityProfile returns the expected profile from either the backup or the listed rules
+func (r *ReconcileSonarQubeQualityProfile) desiredQualityProfile(cr *sonarsourcev1alpha1.SonarQubeQualityProfile) (*api_client.QualityProfileBackup, error) {
+	name := cr.Name
+	if cr.Spec.Name != nil {
+		name = *cr.Spec.Name
+	}
+
+	if cr.Spec.Backup != nil {
+		desired, err := api_client.ParseQualityProfileBackup(*cr.Spec.Backup)
+		if err != nil {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("invalid quality profile backup: %s", err.Error()),
+			}
+		}
+		if cr.Spec.Language != nil && *cr.Spec.Language != desired.Language {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("language %s does not match backup language %s", *cr.Spec.Language, desired.Language),
+			}
+		}
+		desired.Name = name
+		return desired, nil
+	}
+
+	if cr.Spec.Language == nil || *cr.Spec.Language == "" {
+		return nil, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: "language must be set when backup is not set",
+		}
+	}
+
+	desired := &api_client.QualityProfileBackup{
+		Name:     name,
+		Language: *cr.Spec.Language,
+	}
+	for _, v := range cr.Spec.Rules {
+		parts := strings.SplitN(v.Key, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("rule key %s must be in the format repository:key", v.Key),
+			}
+		}
+		rule := api_client.QualityProfileBackupRule{
+			RepositoryKey: parts[0],
+			Key:           parts[1],
+		}
+		if v.Severity != nil {
+			if !utils.ContainsString(ruleSeverities, *v.Severity) {
+				return nil, &utils.Error{
+					Reason:  utils.ErrorReasonSpecInvalid,
+					Message: fmt.Sprintf("severity of rule %s must be one of %s", v.Key, strings.Join(ruleSeverities, ", ")),
+				}
+			}
+			rule.Priority = *v.Severity
+		}
+		for k, p := range v.Params {
+			// Parameters are sent as key=value pairs separated by ;, double quotes escape values but can't be escaped
+			if k == "" || strings.ContainsAny(k, `;="`) || strings.Contains(p, `"`) {
+				return nil, &utils.Error{
+					Reason:  utils.ErrorReasonSpecInvalid,
+					Message: fmt.Sprintf("param %s of rule %s must not contain double quotes, and its key must not contain ; or =", k, v.Key),
+				}
+			}
+			rule.Parameters = append(rule.Parameters, api_client.QualityProfileBackupParameter{Key: k, Value: p})
+		}
+		desired.Rules = append(desired.Rules, rule)
+	}
+
+	return desired, nil
+}
+
+func (r *ReconcileSonarQubeQualityProfile) findQualityProfile(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, apiClient api_client.APIReader, desired *api_client.QualityProfileBackup) (*api_client.QualityProfile, error) {
+	qualityProfile, err := apiClient.GetQualityProfile(desired.Language, desired.Name)
+	if err != nil || qualityProfile != nil {
+		return qualityProfile, err
+	}
+
+	if cr.Spec.Backup != nil {
+		qualityProfile, err = apiClient.RestoreQualityProfile(r.renameBackup(*cr.Spec.Backup, desired.Name))
+	} else {
+		qualityProfile, err = apiClient.CreateQualityProfile(desired.Language, desired.Name)
+	}
+	if err != nil {
+		return qualityProfile, err
+	}
+
+	return qualityProfile, &utils.Error{
+		Reason:  utils.ErrorReasonResourceCreate,
+		Message: fmt.Sprintf("created %s quality profile %s", desired.Language, desired.Name),
+	}
+}
+
+func (r *ReconcileSonarQubeQualityProfile) verifyQualityProfileBackup(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, apiClient api_client.APIReader, desired *api_client.QualityProfileBackup) error {
+	actual, err := r.backupQualityProfile(apiClient, desired)
+	if err != nil {
+		return err
+	}
+
+	drift := qualityProfileDrift(desired, actual, true)
+	if len(drift) == 0 {
+		return nil
+	}
+
+	r.updateDrift(cr, drift)
+
+	_, err = apiClient.RestoreQualityProfile(r.renameBackup(*cr.Spec.Backup, desired.Name))
+	if err != nil {
+		return err
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("restored %s quality profile %s to correct drift: %s", desired.Language, desired.Name, strings.Join(drift, ", ")),
+	}
+}
+
+// verifyQualityProfileParent changes the parent of the quality profile to Spec.Parent, the parent is removed when
+// Spec.Parent is not set
+func (r *ReconcileSonarQubeQualityProfile) verifyQualityProfileParent(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, apiClient api_client.APIReader, qualityProfile *api_client.QualityProfile) error {
+	parent := ""
+	if cr.Spec.Parent != nil {
+		parent = *cr.Spec.Parent
+	}
+	if qualityProfile.ParentName == parent {
+		return nil
+	}
+
+	if parent == "" {
+		r.updateDrift(cr, []string{fmt.Sprintf("parent %s expected none", qualityProfile.ParentName)})
+	} else {
+		r.updateDrift(cr, []string{fmt.Sprintf("parent %s expected %s", qualityProfile.ParentName, parent)})
+	}
+
+	err := apiClient.ChangeQualityProfileParent(qualityProfile.Language, qualityProfile.Name, parent)
+	if err != nil {
+		return err
+	}
+
+	if parent == "" {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceUpdate,
+			Message: fmt.Sprintf("removed parent %s of quality profile %s", qualityProfile.ParentName, qualityProfile.Name),
+		}
+	}
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("changed parent of quality profile %s to %s", qualityProfile.Name, parent),
+	}
+}
+
+func (r *ReconcileSonarQubeQualityProfile) verifyQualityProfileRules(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, apiClient api_client.APIReader, qualityProfile *api_client.QualityProfile, desired *api_client.QualityProfileBackup) error {
+	if len(desired.Rules) == 0 {
+		return nil
+	}
+
+	actual, err := r.backupQualityProfile(apiClient, desired)
+	if err != nil {
+		return err
+	}
+
+	drift := qualityProfileDrift(desired, actual, false)
+	if len(drift) == 0 {
+		return nil
+	}
+
+	r.updateDrift(cr, drift)
+
+	active := backupRules(actual)
+	for _, v := range desired.Rules {
+		if actualRule, ok := active[v.RuleKey()]; ok && ruleDrift(v, actualRule) == nil {
+			continue
+		}
+		params := make(map[string]string)
+		for _, p := range v.Parameters {
+			params[p.Key] = p.Value
+		}
+		err := apiClient.ActivateRule(qualityProfile.Key, v.RuleKey(), v.Priority, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("activated rules in quality profile %s to correct drift: %s", qualityProfile.Name, strings.Join(drift, ", ")),
+	}
+}
+
+func (r *ReconcileSonarQubeQualityProfile) backupQualityProfile(apiClient api_client.APIReader, desired *api_client.QualityProfileBackup) (*api_client.QualityProfileBackup, error) {
+	backup, err := apiClient.BackupQualityProfile(desired.Language, desired.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return api_client.ParseQualityProfileBackup(backup)
+}
+
+func (r *ReconcileSonarQubeQualityProfile) updateDrift(cr *sonarsourcev1alpha1.SonarQubeQualityProfile, drift []string) {
+	newStatus := cr.DeepCopy()
+	now := metav1.Now()
+	newStatus.Status.Drift = drift
+	newStatus.Status.DriftCorrected = &now
+	utils.UpdateStatus(r.client, newStatus, cr)
+}
+
+// renameBackup replaces the profile name in backup so it is restored under name
+func (r *ReconcileSonarQubeQualityProfile) renameBackup(backup, name string) string {
+	escaped := &bytes.Buffer{}
+	_ = xml.EscapeText(escaped, []byte(name))
+
+	var replaced bool
+	return backupNameRegexp.ReplaceAllStringFunc(backup, func(s string) string {
+		if replaced {
+			return s
+		}
+		replaced = true
+		return fmt.Sprintf("<name>%s</name>", escaped.String())
+	})
+}
+
+// qualityProfileDrift lists the differences between the desired and actual rules
+// If exact is true rules active on the server but not desired are reported as well
+func qualityProfileDrift(desired, actual *api_client.QualityProfileBackup, exact bool) []string {
+	var drift []string
+
+	active := backupRules(actual)
+	for _, v := range desired.Rules {
+		actualRule, ok := active[v.RuleKey()]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("rule %s not active", v.RuleKey()))
+			continue
+		}
+		drift = append(drift, ruleDrift(v, actualRule)...)
+	}
+
+	if exact {
+		expected := backupRules(desired)
+		var extra []string
+		for k := range active {
+			if _, ok := expected[k]; !ok {
+				extra = append(extra, fmt.Sprintf("rule %s active but not expected", k))
+			}
+		}
+		sort.Strings(extra)
+		drift = append(drift, extra...)
+	}
+
+	return drift
+}
+
+func ruleDrift(desired, actual api_client.QualityProfileBackupRule) []string {
+	var drift []string
+
+	if desired.Priority != "" && desired.Priority != actual.Priority {
+		drift = append(drift, fmt.Sprintf("rule %s severity %s expected %s", desired.RuleKey(), actual.Priority, desired.Priority))
+	}
+
+	actualParams := make(map[string]string)
+	for _, p := range actual.Parameters {
+		actualParams[p.Key] = p.Value
+	}
+	var params []string
+	for _, p := range desired.Parameters {
+		if actualParams[p.Key] != p.Value {
+			params = append(params, fmt.Sprintf("rule %s param %s %s expected %s", desired.RuleKey(), p.Key, actualParams[p.Key], p.Value))
+		}
+	}
+	sort.Strings(params)
+
+	return append(drift, params...)
+}
+
+func backupRules(backup *api_client.QualityProfileBackup) map[string]api_client.QualityProfileBackupRule {
+	rules := make(map[string]api_client.QualityProfileBackupRule)
+	for _, v := range backup.Rules {
+		rules[v.RuleKey()] = v
+	}
+	return rules
+}
@@ -0,0 +1,142 @@
+package sonarqubequalityprofile
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"strings"
+	"testing"
+)
+
+const testBackup = `<?xml version='1.0' encoding='UTF-8'?>
+<profile>
+  <name>exported</name>
+  <language>java</language>
+  <rules>
+    <rule>
+      <repositoryKey>java</repositoryKey>
+      <key>S1067</key>
+      <priority>MAJOR</priority>
+      <parameters>
+        <parameter>
+          <key>max</key>
+          <value>3</value>
+        </parameter>
+      </parameters>
+    </rule>
+  </rules>
+</profile>`
+
+// TestSonarQubeQualityProfileQualityProfile runs ReconcileSonarQubeQualityProfile.ReconcileQualityProfile() against a
+// mocked api client
+func TestSonarQubeQualityProfileQualityProfile(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeQualityProfile resource with metadata and spec.
+	sonarqubeQualityProfile := &sonarsourcev1alpha1.SonarQubeQualityProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeQualityProfileSpec{
+			Backup: &[]string{testBackup}[0],
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeQualityProfile,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeQualityProfile)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeQualityProfile object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{
+		GetQualityProfileOutput: &api_client.QualityProfile{Key: "AU-1", Name: name, Language: "java"},
+		BackupQualityProfileOutput: strings.Replace(strings.Replace(testBackup, "<value>3</value>", "<value>5</value>", 1),
+			"</rules>", "<rule><repositoryKey>java</repositoryKey><key>S100</key><priority>MINOR</priority></rule></rules>", 1),
+	}
+	r := &ReconcileSonarQubeQualityProfile{client: cl, scheme: s, apiClient: apiMock}
+
+	if !strings.Contains(r.renameBackup(testBackup, "a&b"), "<name>a&amp;b</name>") {
+		t.Error("renameBackup: profile name not replaced")
+	}
+
+	_, err := r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.RestoreQualityProfileCalled {
+		t.Error("ReconcileQualityProfile: backup not restored on drift")
+	}
+	if len(sonarqubeQualityProfile.Status.Drift) != 2 {
+		t.Errorf("ReconcileQualityProfile: expected param and extra rule drift got %v", sonarqubeQualityProfile.Status.Drift)
+	}
+
+	apiMock.BackupQualityProfileOutput = testBackup
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if err != nil {
+		t.Fatalf("ReconcileQualityProfile: (%v)", err)
+	}
+
+	sonarqubeQualityProfile.Spec.Backup = nil
+	if err := cl.Update(context.TODO(), sonarqubeQualityProfile); err != nil {
+		t.Fatalf("ReconcileQualityProfile: (%v)", err)
+	}
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileQualityProfile: spec invalid error not thrown without language")
+	}
+
+	sonarqubeQualityProfile.Spec.Language = &[]string{"java"}[0]
+	sonarqubeQualityProfile.Spec.Parent = &[]string{"Sonar way"}[0]
+	sonarqubeQualityProfile.Spec.Rules = []sonarsourcev1alpha1.QualityProfileRule{
+		{Key: "java:S1067", Params: map[string]string{"max": "3"}},
+		{Key: "java:S100", Severity: &[]string{"BLOCKER"}[0]},
+	}
+	if err := cl.Update(context.TODO(), sonarqubeQualityProfile); err != nil {
+		t.Fatalf("ReconcileQualityProfile: (%v)", err)
+	}
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.ChangeQualityProfileParentCalled {
+		t.Error("ReconcileQualityProfile: parent not changed")
+	}
+
+	apiMock.GetQualityProfileOutput.ParentName = "Sonar way"
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || len(apiMock.ActivatedRules) != 1 || apiMock.ActivatedRules[0] != "java:S100" {
+		t.Errorf("ReconcileQualityProfile: expected only java:S100 to be activated got %v", apiMock.ActivatedRules)
+	}
+
+	sonarqubeQualityProfile.Spec.Rules[1].Severity = &[]string{"SEVERE"}[0]
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileQualityProfile: spec invalid error not thrown for unknown severity")
+	}
+
+	sonarqubeQualityProfile.Spec.Rules[1].Severity = nil
+	sonarqubeQualityProfile.Spec.Rules[0].Params = map[string]string{"max": `"3"`}
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("ReconcileQualityProfile: spec invalid error not thrown for param with double quotes")
+	}
+
+	sonarqubeQualityProfile.Spec.Rules[0].Params = map[string]string{"max": "3"}
+	sonarqubeQualityProfile.Spec.Parent = nil
+	apiMock.ChangeQualityProfileParentCalled = false
+	_, err = r.ReconcileQualityProfile(sonarqubeQualityProfile, apiMock)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || !apiMock.ChangeQualityProfileParentCalled {
+		t.Error("ReconcileQualityProfile: parent not removed")
+	}
+}
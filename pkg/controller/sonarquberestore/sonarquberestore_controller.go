@@ -0,0 +1,266 @@
+package sonarquberestore
+
+import (
+	"context"
+	"fmt"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"github.com/parflesh/sonarqube-operator/version"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller_sonarquberestore")
+
+// Add creates a new SonarQubeRestore Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSonarQubeRestore{
+		client:    mgr.GetClient(),
+		scheme:    mgr.GetScheme(),
+		apiClient: &api_client.APIClient{},
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("sonarquberestore-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource SonarQubeRestore
+	err = c.Watch(&source.Kind{Type: &sonarsourcev1alpha1.SonarQubeRestore{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Secret and requeue the owner SonarQubeRestore
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeRestore{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Job and requeue the owner SonarQubeRestore
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeRestore{},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// blank assignment to verify that ReconcileSonarQubeRestore implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSonarQubeRestore{}
+
+// ReconcileSonarQubeRestore reconciles a SonarQubeRestore object
+type ReconcileSonarQubeRestore struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client    client.Client
+	scheme    *runtime.Scheme
+	apiClient api_client.APIProvider
+}
+
+// Reconcile reads that state of the cluster for a SonarQubeRestore object and makes changes based on the state read
+// and what is in the SonarQubeRestore.Spec
+// A restore runs once, the server is shutdown, the backup is restored by a Job, and the server is started again
+func (r *ReconcileSonarQubeRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling SonarQubeRestore")
+
+	// Fetch the SonarQubeRestore instance
+	instance := &sonarsourcev1alpha1.SonarQubeRestore{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	if instance.Status.Phase == sonarsourcev1alpha1.RestorePhaseCompleted {
+		return reconcile.Result{}, nil
+	}
+
+	err = utils.ValidateSonarQubeRestore(instance)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	backup, err := r.getBackup(instance)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	server, err := r.getServer(instance)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	// The Job is not run again once the data was restored
+	if !instance.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionDataRestored) {
+		target, err := utils.GetBackupTarget(r.client, instance.Namespace, instance.Spec.ServerRef)
+		if err != nil {
+			return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		}
+
+		err = r.ReconcileShutdown(instance, server)
+		if err != nil {
+			return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		}
+
+		_, err = r.ReconcileSecret(instance, target)
+		if err != nil {
+			return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		}
+
+		err = r.ReconcileJob(instance, backup, target)
+		if err != nil {
+			return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		}
+	}
+
+	err = r.ReconcileStartup(instance, server)
+	if err != nil {
+		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+	}
+
+	now := metav1.Now()
+	newStatus := instance.DeepCopy()
+
+	newStatus.Status.Conditions = utils.ClearConditions(newStatus.Status.Conditions)
+	newStatus.Status.ObservedGeneration = instance.Generation
+	newStatus.Status.Phase = sonarsourcev1alpha1.RestorePhaseCompleted
+	newStatus.Status.CompletionTime = &now
+
+	utils.UpdateStatus(r.client, newStatus, instance)
+
+	return reconcile.Result{}, nil
+}
+
+// getBackup returns the SonarQubeBackup of the restore and pins the name of the restored backup in status
+func (r *ReconcileSonarQubeRestore) getBackup(cr *sonarsourcev1alpha1.SonarQubeRestore) (*sonarsourcev1alpha1.SonarQubeBackup, error) {
+	backup := &sonarsourcev1alpha1.SonarQubeBackup{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Backup, Namespace: cr.Namespace}, backup)
+	if err != nil && errors.IsNotFound(err) {
+		return backup, &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for SonarQubeBackup %s to exist", cr.Spec.Backup),
+		}
+	} else if err != nil {
+		return backup, err
+	}
+
+	if cr.Status.BackupName != "" {
+		return backup, nil
+	}
+
+	backupName := backup.Status.LastBackup
+	if cr.Spec.BackupName != nil {
+		backupName = *cr.Spec.BackupName
+	}
+	if backupName == "" {
+		return backup, &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for a completed backup of SonarQubeBackup %s", backup.Name),
+		}
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.BackupName = backupName
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return backup, nil
+}
+
+// getServer returns the SonarQubeServer that is restored
+// Servers of a SonarQube cluster are started by the cluster and can not be shutdown by a restore
+func (r *ReconcileSonarQubeRestore) getServer(cr *sonarsourcev1alpha1.SonarQubeRestore) (*sonarsourcev1alpha1.SonarQubeServer, error) {
+	server := &sonarsourcev1alpha1.SonarQubeServer{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.ServerRef.Name, Namespace: cr.Namespace}, server)
+	if err != nil && errors.IsNotFound(err) {
+		return server, &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for %s %s to exist", sonarsourcev1alpha1.ServerKindSonarQubeServer, cr.Spec.ServerRef.Name),
+		}
+	} else if err != nil {
+		return server, err
+	}
+
+	if owner := metav1.GetControllerOf(server); owner != nil && owner.Kind == sonarsourcev1alpha1.ServerKindSonarQube {
+		return server, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("%s %s is managed by %s %s", sonarsourcev1alpha1.ServerKindSonarQubeServer, server.Name, owner.Kind, owner.Name),
+		}
+	}
+
+	return server, nil
+}
+
+// updatePhase reports phase in status
+func (r *ReconcileSonarQubeRestore) updatePhase(cr *sonarsourcev1alpha1.SonarQubeRestore, phase sonarsourcev1alpha1.RestorePhase) {
+	if cr.Status.Phase != phase {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Phase = phase
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+}
+
+// completePhase reports the phase of conditionType as completed, the condition is kept until the restore is removed
+func (r *ReconcileSonarQubeRestore) completePhase(cr *sonarsourcev1alpha1.SonarQubeRestore, conditionType status.ConditionType, message string) {
+	if !cr.Status.Conditions.IsTrueFor(conditionType) {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Conditions.SetCondition(status.Condition{
+			Type:    conditionType,
+			Status:  corev1.ConditionTrue,
+			Reason:  sonarsourcev1alpha1.ConditionPhaseCompleted,
+			Message: message,
+		})
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+}
+
+func (r *ReconcileSonarQubeRestore) Labels(cr *sonarsourcev1alpha1.SonarQubeRestore) map[string]string {
+	labels := make(map[string]string)
+
+	for k, v := range cr.Labels {
+		labels[k] = v
+	}
+
+	labels[sonarsourcev1alpha1.RestoreTypeLabel] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppName] = "SonarQubeRestore"
+	labels[sonarsourcev1alpha1.KubeAppInstance] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppManagedby] = fmt.Sprintf("sonarqube-operator.v%s", version.Version)
+
+	return labels
+}
@@ -0,0 +1,291 @@
+package sonarquberestore
+
+import (
+	"context"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+const (
+	ReconcileErrorFormat string = "reconcile: (%v)"
+)
+
+// TestSonarQubeRestoreController runs ReconcileSonarQubeRestore.Reconcile() against a
+// fake client that tracks a SonarQubeRestore object through every phase of the restore.
+func TestSonarQubeRestoreController(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name       = "sonarqube-operator"
+		namespace  = "sonarqube"
+		backupName = "sonarqube-operator-26499480"
+	)
+
+	// A running SonarQubeServer resource that is restored.
+	sonarqubeServer := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+			Service: name,
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports:     []corev1.ServicePort{{Port: 9000}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SecretName(name, nil),
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			utils.SonarPropertiesFile: []byte("sonar.jdbc.url=jdbc:postgresql://postgres/sonar\nsonar.jdbc.username=sonar\nsonar.jdbc.password=secret\n"),
+		},
+	}
+	// A SonarQubeBackup resource the backup is read from.
+	sonarqubeBackup := &sonarsourcev1alpha1.SonarQubeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeBackupSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Storage: sonarsourcev1alpha1.BackupStorage{
+				PersistentVolumeClaim: &[]string{"backups"}[0],
+			},
+		},
+		Status: sonarsourcev1alpha1.SonarQubeBackupStatus{
+			LastBackup: backupName,
+		},
+	}
+	// A SonarQubeRestore resource with metadata and spec.
+	sonarqubeRestore := &sonarsourcev1alpha1.SonarQubeRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeRestoreSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Backup: name,
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqubeServer,
+		service,
+		secret,
+		sonarqubeBackup,
+		sonarqubeRestore,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeServer, sonarqubeBackup, sonarqubeRestore)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeRestore object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{
+		InfoOutput: &api_client.Status{Status: api_client.SystemStarting},
+	}
+	r := &ReconcileSonarQubeRestore{client: cl, scheme: s, apiClient: apiMock}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource .
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// setServerShutdown reports the server as shutdown or running like the SonarQubeServer controller
+	setServerShutdown := func(shutdown bool) {
+		err := r.client.Get(context.TODO(), req.NamespacedName, sonarqubeServer)
+		if err != nil {
+			t.Fatalf(ReconcileErrorFormat, err)
+		}
+		condition := corev1.ConditionFalse
+		if shutdown {
+			condition = corev1.ConditionTrue
+		}
+		sonarqubeServer.Status.Conditions.SetCondition(status.Condition{
+			Type:   sonarsourcev1alpha1.ConditionShutdown,
+			Status: condition,
+		})
+		err = r.client.Status().Update(context.TODO(), sonarqubeServer)
+		if err != nil {
+			t.Fatalf(ReconcileErrorFormat, err)
+		}
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after shutting down server")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeServer)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeServer.Spec.Shutdown == nil || !*sonarqubeServer.Spec.Shutdown {
+		t.Error("reconcile: server was not shutdown")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeRestore)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeRestore.Status.BackupName != backupName {
+		t.Errorf("reconcile: backup name %s is not last backup %s", sonarqubeRestore.Status.BackupName, backupName)
+	}
+	if sonarqubeRestore.Status.Phase != sonarsourcev1alpha1.RestorePhaseShuttingDown {
+		t.Errorf("reconcile: expected phase %s got %s", sonarsourcev1alpha1.RestorePhaseShuttingDown, sonarqubeRestore.Status.Phase)
+	}
+	if sonarqubeRestore.Status.ServerShutdown == nil || *sonarqubeRestore.Status.ServerShutdown {
+		t.Error("reconcile: running server was not recorded before shutting it down")
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for server to shutdown")
+	}
+
+	setServerShutdown(true)
+
+	// Secret and Job are created, then the Job is waited on
+	for _, v := range []string{"Secret", "Job"} {
+		res, err = r.Reconcile(req)
+		if err != nil {
+			t.Fatalf(ReconcileErrorFormat, err)
+		}
+		if !res.Requeue {
+			t.Errorf("reconcile did not requeue after creating %s", v)
+		}
+	}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for restore job to complete")
+	}
+
+	job := &batchv1.Job{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, job)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:   batchv1.JobComplete,
+		Status: corev1.ConditionTrue,
+	}}
+	err = r.client.Status().Update(context.TODO(), job)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !res.Requeue {
+		t.Error("reconcile did not requeue after starting server")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeServer)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeServer.Spec.Shutdown == nil || *sonarqubeServer.Spec.Shutdown {
+		t.Error("reconcile: server was not started")
+	}
+
+	setServerShutdown(false)
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("reconcile did not wait for server to be up")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeRestore)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	for _, v := range []status.ConditionType{sonarsourcev1alpha1.ConditionServerStopped, sonarsourcev1alpha1.ConditionDataRestored} {
+		if !sonarqubeRestore.Status.Conditions.IsTrueFor(v) {
+			t.Errorf("reconcile: condition %s of completed phase is not true", v)
+		}
+	}
+
+	apiMock.InfoOutput = &api_client.Status{Status: api_client.SystemUp}
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued even though everything should be good")
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqubeRestore)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if sonarqubeRestore.Status.Phase != sonarsourcev1alpha1.RestorePhaseCompleted || sonarqubeRestore.Status.CompletionTime == nil {
+		t.Errorf("reconcile: restore did not complete, phase %s", sonarqubeRestore.Status.Phase)
+	}
+	if !sonarqubeRestore.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionServerStarted) {
+		t.Error("reconcile: server started condition is not true")
+	}
+
+	// A server that was shutdown before the restore is left shutdown
+	sonarqubeRestore.Status.ServerShutdown = &[]bool{true}[0]
+	sonarqubeRestore.Status.Conditions.RemoveCondition(sonarsourcev1alpha1.ConditionServerStarted)
+	err = r.client.Status().Update(context.TODO(), sonarqubeRestore)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	err = r.ReconcileStartup(sonarqubeRestore, sonarqubeServer)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileStartup: resource update error not returned when shutting down server again")
+	}
+	if sonarqubeServer.Spec.Shutdown == nil || !*sonarqubeServer.Spec.Shutdown {
+		t.Error("reconcileStartup: server was not left shutdown")
+	}
+	setServerShutdown(true)
+	err = r.ReconcileStartup(sonarqubeRestore, sonarqubeServer)
+	if err != nil {
+		t.Errorf("reconcileStartup: returned error even though server is left shutdown (%v)", err)
+	}
+	if !sonarqubeRestore.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionServerStarted) {
+		t.Error("reconcileStartup: phase of server left shutdown was not completed")
+	}
+}
@@ -0,0 +1,230 @@
+package sonarquberestore
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	VolumeWork       = "work"
+	VolumeExtensions = "extensions"
+	VolumeBackup     = "backup"
+
+	VolumePathWork       = "/work"
+	VolumePathExtensions = "/extensions"
+	VolumePathBackup     = "/backup"
+)
+
+// pvcDownloadScript copies the backup from the storage volume to the work volume
+var pvcDownloadScript = fmt.Sprintf(`set -e
+if [ ! -d "%[2]s/${BACKUP_NAME}" ]; then echo "backup ${BACKUP_NAME} not found" >&2; exit 1; fi
+cp "%[2]s/${BACKUP_NAME}/"* %[1]s/
+`, VolumePathWork, VolumePathBackup)
+
+// s3DownloadScript downloads the backup from the object store to the work volume
+var s3DownloadScript = fmt.Sprintf(`set -e
+mc alias set backup "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}" > /dev/null
+mc cp --recursive "backup/${S3_PATH}/${BACKUP_NAME}/" %[1]s/
+`, VolumePathWork)
+
+// restoreScript replaces the database in a single transaction, then replaces the extensions with the archive
+var restoreScript = fmt.Sprintf(`set -e
+pg_restore --clean --if-exists --no-owner --single-transaction --dbname="${PGDATABASE}" %[1]s/%[2]s
+find %[4]s -mindepth 1 -delete
+tar -xzf %[1]s/%[3]s -C %[4]s
+`, VolumePathWork, sonarsourcev1alpha1.BackupDatabaseFile, sonarsourcev1alpha1.BackupExtensionsFile, VolumePathExtensions)
+
+// Reconciles Job restoring the backup to the shutdown SonarQubeServer for SonarQubeRestore
+// Returns: Error
+// If Error is non-nil, data is not restored
+// Errors:
+//   ErrorReasonResourceCreate: returned when Job does not exists
+//   ErrorReasonResourceWaiting: returned when Job has not completed
+//   ErrorReasonResourceInvalid: returned when Job failed
+//   ErrorReasonSpecInvalid: returned when storage of backup is not set
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeRestore) ReconcileJob(cr *sonarsourcev1alpha1.SonarQubeRestore, backup *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) error {
+	r.updatePhase(cr, sonarsourcev1alpha1.RestorePhaseRestoring)
+
+	job, err := r.findJob(cr, backup, target)
+	if err != nil {
+		return err
+	}
+
+	err = utils.VerifyJob(job, "restore job")
+	if err != nil {
+		return err
+	}
+
+	r.completePhase(cr, sonarsourcev1alpha1.ConditionDataRestored, fmt.Sprintf("restored backup %s", cr.Status.BackupName))
+
+	return nil
+}
+
+func (r *ReconcileSonarQubeRestore) findJob(cr *sonarsourcev1alpha1.SonarQubeRestore, backup *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1.Job, error) {
+	newJob, err := r.newJob(cr, backup, target)
+	if err != nil {
+		return newJob, err
+	}
+
+	foundJob := &batchv1.Job{}
+
+	return foundJob, utils.CreateResourceIfNotFound(r.client, newJob, foundJob)
+}
+
+func (r *ReconcileSonarQubeRestore) newJob(cr *sonarsourcev1alpha1.SonarQubeRestore, backup *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (*batchv1.Job, error) {
+	jobSpec, err := r.newJobSpec(cr, backup, target)
+	if err != nil {
+		return nil, err
+	}
+
+	dep := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      cr.Name,
+			Labels:    r.Labels(cr),
+		},
+		Spec: jobSpec,
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+// newJobSpec returns a Job that reads the backup from the storage of backup, then restores the database and
+// extensions of target
+// The Job is not retried, a failed restore is inspected before the server is started
+func (r *ReconcileSonarQubeRestore) newJobSpec(cr *sonarsourcev1alpha1.SonarQubeRestore, backup *sonarsourcev1alpha1.SonarQubeBackup, target *utils.BackupTarget) (batchv1.JobSpec, error) {
+	image := utils.DefaultDatabaseImage
+	if cr.Spec.Image != nil {
+		image = *cr.Spec.Image
+	}
+
+	secretEnv := func(env, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: r.secretName(cr)},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	backupEnv := []corev1.EnvVar{
+		{Name: "BACKUP_NAME", Value: cr.Status.BackupName},
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: VolumeWork,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: VolumeExtensions,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: target.Claim,
+				},
+			},
+		},
+	}
+
+	var download corev1.Container
+	switch {
+	case backup.Spec.Storage.PersistentVolumeClaim != nil:
+		volumes = append(volumes, corev1.Volume{
+			Name: VolumeBackup,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: *backup.Spec.Storage.PersistentVolumeClaim,
+					ReadOnly:  true,
+				},
+			},
+		})
+		download = corev1.Container{
+			Name:    "download",
+			Image:   image,
+			Command: []string{"/bin/sh", "-c", pvcDownloadScript},
+			Env:     backupEnv,
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: VolumeWork, MountPath: VolumePathWork},
+				{Name: VolumeBackup, MountPath: VolumePathBackup, ReadOnly: true},
+			},
+		}
+	case backup.Spec.Storage.S3 != nil:
+		s3 := backup.Spec.Storage.S3
+		downloadImage := utils.DefaultBackupUploadImage
+		if s3.Image != nil {
+			downloadImage = *s3.Image
+		}
+		download = corev1.Container{
+			Name:    "download",
+			Image:   downloadImage,
+			Command: []string{"/bin/sh", "-c", s3DownloadScript},
+			Env: append([]corev1.EnvVar{
+				{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+				{Name: "S3_PATH", Value: utils.S3Path(s3)},
+				{Name: "HOME", Value: "/tmp"},
+			}, backupEnv...),
+			EnvFrom: []corev1.EnvFromSource{
+				{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: s3.CredentialsSecret},
+					},
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: VolumeWork, MountPath: VolumePathWork},
+			},
+		}
+	default:
+		return batchv1.JobSpec{}, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("storage of SonarQubeBackup %s requires one of persistentVolumeClaim or s3", backup.Name),
+		}
+	}
+
+	return batchv1.JobSpec{
+		BackoffLimit: &[]int32{0}[0],
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: r.Labels(cr),
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy:  corev1.RestartPolicyNever,
+				Volumes:        volumes,
+				InitContainers: []corev1.Container{download},
+				Containers: []corev1.Container{
+					{
+						Name:    "restore",
+						Image:   image,
+						Command: []string{"/bin/sh", "-c", restoreScript},
+						Env: append([]corev1.EnvVar{
+							{Name: "PGHOST", Value: target.Database.Host},
+							{Name: "PGPORT", Value: target.Database.Port},
+							{Name: "PGDATABASE", Value: target.Database.Database},
+							secretEnv("PGUSER", sonarsourcev1alpha1.DatabaseSecretUsername),
+							secretEnv("PGPASSWORD", sonarsourcev1alpha1.DatabaseSecretPassword),
+						}, backupEnv...),
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: VolumeWork, MountPath: VolumePathWork},
+							{Name: VolumeExtensions, MountPath: VolumePathExtensions, SubPath: "extensions"},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,130 @@
+package sonarquberestore
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeRestoreJob runs ReconcileSonarQubeRestore.ReconcileJob() against a
+// fake client with a backup read from S3
+func TestSonarQubeRestoreJob(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	sonarqubeBackup := &sonarsourcev1alpha1.SonarQubeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeBackupSpec{
+			Storage: sonarsourcev1alpha1.BackupStorage{
+				S3: &sonarsourcev1alpha1.S3Storage{
+					Endpoint:          "http://minio:9000",
+					Bucket:            "sonarqube",
+					CredentialsSecret: "minio",
+				},
+			},
+		},
+	}
+	sonarqubeRestore := &sonarsourcev1alpha1.SonarQubeRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeRestoreSpec{
+			ServerRef: sonarsourcev1alpha1.ServerReference{
+				Name: name,
+			},
+			Backup: name,
+		},
+		Status: sonarsourcev1alpha1.SonarQubeRestoreStatus{
+			BackupName: "sonarqube-operator-26499480",
+		},
+	}
+	target := &utils.BackupTarget{
+		Kind:   sonarsourcev1alpha1.ServerKindSonarQubeServer,
+		Name:   name,
+		Server: name,
+		Claim:  name,
+		Database: &utils.DatabaseConnection{
+			Host:     "postgres",
+			Port:     "5432",
+			Database: "sonar",
+		},
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqubeBackup, sonarqubeRestore)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, sonarqubeRestore)
+	// Create a ReconcileSonarQubeRestore object with the scheme and fake client.
+	r := &ReconcileSonarQubeRestore{client: cl, scheme: s}
+
+	err := r.ReconcileJob(sonarqubeRestore, sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileJob: resource created error not thrown when creating Job")
+	}
+	err = r.ReconcileJob(sonarqubeRestore, sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileJob: resource waiting error not returned when job has not completed")
+	}
+
+	job := &batchv1.Job{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, job)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	podSpec := job.Spec.Template.Spec
+	env := make(map[string]string)
+	for _, v := range append(podSpec.InitContainers[0].Env, podSpec.Containers[0].Env...) {
+		env[v.Name] = v.Value
+	}
+	for k, v := range map[string]string{
+		"PGHOST":      "postgres",
+		"PGDATABASE":  "sonar",
+		"S3_PATH":     "sonarqube",
+		"BACKUP_NAME": "sonarqube-operator-26499480",
+	} {
+		if env[k] != v {
+			t.Errorf("reconcileJob: expected %s to be %s got %s", k, v, env[k])
+		}
+	}
+	if podSpec.InitContainers[0].Image != utils.DefaultBackupUploadImage {
+		t.Errorf("reconcileJob: expected download image %s got %s", utils.DefaultBackupUploadImage, podSpec.InitContainers[0].Image)
+	}
+	if mounts := podSpec.Containers[0].VolumeMounts; len(mounts) != 2 || mounts[1].ReadOnly || mounts[1].SubPath != "extensions" {
+		t.Error("reconcileJob: extensions of server are not mounted writable")
+	}
+
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:    batchv1.JobFailed,
+		Status:  corev1.ConditionTrue,
+		Message: "Job has reached the specified backoff limit",
+	}}
+	err = r.client.Status().Update(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("reconcileJob: (%v)", err)
+	}
+	err = r.ReconcileJob(sonarqubeRestore, sonarqubeBackup, target)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceInvalid {
+		t.Error("reconcileJob: resource invalid error not returned when restore job failed")
+	}
+	if sonarqubeRestore.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionDataRestored) {
+		t.Error("reconcileJob: data restored condition set even though restore job failed")
+	}
+}
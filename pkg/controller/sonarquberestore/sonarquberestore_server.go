@@ -0,0 +1,107 @@
+package sonarquberestore
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+)
+
+// Reconciles shutdown of the SonarQubeServer before its data is restored for SonarQubeRestore
+// Spec.Shutdown of the server is recorded in Status.ServerShutdown first so ReconcileStartup can set it back
+// Returns: Error
+// If Error is non-nil, server is not shutdown
+// Errors:
+//   ErrorReasonResourceUpdate: returned when Spec.Shutdown of server was set
+//   ErrorReasonResourceWaiting: returned when server has not finished shutting down
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeRestore) ReconcileShutdown(cr *sonarsourcev1alpha1.SonarQubeRestore, server *sonarsourcev1alpha1.SonarQubeServer) error {
+	if cr.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionServerStopped) {
+		return nil
+	}
+
+	r.updatePhase(cr, sonarsourcev1alpha1.RestorePhaseShuttingDown)
+
+	if cr.Status.ServerShutdown == nil {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.ServerShutdown = &[]bool{server.Spec.Shutdown != nil && *server.Spec.Shutdown}[0]
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
+	err := r.setServerShutdown(server, true)
+	if err != nil {
+		return err
+	}
+
+	r.completePhase(cr, sonarsourcev1alpha1.ConditionServerStopped, fmt.Sprintf("sonarqube server %s is shutdown", server.Name))
+
+	return nil
+}
+
+// Reconciles startup of the SonarQubeServer after its data was restored for SonarQubeRestore
+// A server that was shutdown before the restore is left shutdown
+// Returns: Error
+// If Error is non-nil, server is not up
+// Errors:
+//   ErrorReasonResourceUpdate: returned when Spec.Shutdown of server was set back
+//   ErrorReasonResourceWaiting: returned when server or its service has not finished starting
+//   ErrorReasonServerWaiting: returned when server does not report status UP
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeRestore) ReconcileStartup(cr *sonarsourcev1alpha1.SonarQubeRestore, server *sonarsourcev1alpha1.SonarQubeServer) error {
+	r.updatePhase(cr, sonarsourcev1alpha1.RestorePhaseStarting)
+
+	shutdown := cr.Status.ServerShutdown != nil && *cr.Status.ServerShutdown
+	err := r.setServerShutdown(server, shutdown)
+	if err != nil {
+		return err
+	}
+	if shutdown {
+		r.completePhase(cr, sonarsourcev1alpha1.ConditionServerStarted, fmt.Sprintf("sonarqube server %s was shutdown before the restore and is left shutdown", server.Name))
+		return nil
+	}
+
+	url, err := utils.ServerURL(r.client, server.Namespace, sonarsourcev1alpha1.ServerKindSonarQubeServer, server.Name, server.Spec.ExternalURL, server.Status.Service)
+	if err != nil {
+		return err
+	}
+
+	systemStatus, err := r.apiClient.New(url, nil).Status()
+	if err != nil {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerWaiting,
+			Message: fmt.Sprintf("waiting for api of sonarqube server %s to respond (%s)", server.Name, err.Error()),
+		}
+	}
+	if systemStatus.Status != api_client.SystemUp {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerWaiting,
+			Message: fmt.Sprintf("waiting for sonarqube server %s to be up, status %s", server.Name, systemStatus.Status),
+		}
+	}
+
+	r.completePhase(cr, sonarsourcev1alpha1.ConditionServerStarted, fmt.Sprintf("sonarqube server %s is up", server.Name))
+
+	return nil
+}
+
+// setServerShutdown sets Spec.Shutdown of server and waits for the server to apply it
+func (r *ReconcileSonarQubeRestore) setServerShutdown(server *sonarsourcev1alpha1.SonarQubeServer, shutdown bool) error {
+	action := "starting"
+	if shutdown {
+		action = "shutting down"
+	}
+
+	if server.Spec.Shutdown == nil || *server.Spec.Shutdown != shutdown {
+		server.Spec.Shutdown = &shutdown
+		return utils.UpdateResource(r.client, server, utils.ErrorReasonResourceUpdate, fmt.Sprintf("%s sonarqube server %s", action, server.Name))
+	}
+
+	if server.Status.ObservedGeneration != server.Generation || server.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionShutdown) != shutdown {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for sonarqube server %s to finish %s", server.Name, action),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"github.com/thanhpk/randstr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	DefaultAdminLogin    = "admin"
+	DefaultAdminPassword = "admin"
+	AdminTokenName       = "sonarqube-operator"
+)
+
+// Reconciles admin credentials Secret for SonarQubeServer
+// Returns: Secret, Error
+// If Error is non-nil, Secret is not in expected state
+// Errors:
+//   ErrorReasonResourceCreate: returned when Secret does not exists
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeServer) ReconcileAdminSecret(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Secret, error) {
+	foundSecret, err := r.findAdminSecret(cr)
+	if err != nil {
+		return foundSecret, err
+	}
+
+	return foundSecret, nil
+}
+
+func (r *ReconcileSonarQubeServer) findAdminSecret(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Secret, error) {
+	newSecret, err := r.newAdminSecret(cr)
+	if err != nil {
+		return newSecret, err
+	}
+
+	foundSecret := &corev1.Secret{}
+
+	return foundSecret, utils.CreateResourceIfNotFound(r.client, newSecret, foundSecret)
+}
+
+func (r *ReconcileSonarQubeServer) newAdminSecret(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Secret, error) {
+	labels := r.Labels(cr)
+
+	dep := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *cr.Spec.AdminSecret,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.AdminSecretUsername: []byte(DefaultAdminLogin),
+			sonarsourcev1alpha1.AdminSecretPassword: []byte(randstr.String(32)),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+// Returns credentials for admin only api calls, nil if Spec.AdminSecret is not set
+// Owned secrets are bootstrapped by changing the default admin password and generating a token, a stored token that
+// is no longer valid is revoked and generated again. Application nodes of a cluster share the Secret, it is only
+// created and bootstrapped by the first application node
+// Errors:
+//   ErrorReasonResourceCreate: returned when admin Secret does not exists
+//   ErrorReasonResourceUpdate: returned when generated token was stored in admin Secret
+//   ErrorReasonResourceWaiting: returned when admin Secret was not created by the first application node yet
+//   ErrorReasonSpecInvalid: returned when admin Secret has no usable credentials
+//   ErrorReasonUnknown: returned when unhandled error from client or api occurs
+func (r *ReconcileSonarQubeServer) verifyAdminAuth(cr *sonarsourcev1alpha1.SonarQubeServer, url string) (*api_client.Auth, error) {
+	if cr.Spec.AdminSecret == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	var err error
+	if bootstrap := r.adminSecretBootstrap(cr); bootstrap != "" {
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: *cr.Spec.AdminSecret, Namespace: cr.Namespace}, secret)
+		if err != nil && errors.IsNotFound(err) {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for admin secret %s to be created by %s", *cr.Spec.AdminSecret, bootstrap),
+			}
+		}
+	} else {
+		secret, err = r.ReconcileAdminSecret(cr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't make changes to unowned resources
+	owned := utils.IsOwner(cr, secret)
+
+	if token, ok := secret.Data[sonarsourcev1alpha1.AdminSecretToken]; ok && len(token) > 0 {
+		tokenAuth := &api_client.Auth{Token: string(token)}
+		if !owned {
+			return tokenAuth, nil
+		}
+		valid, err := r.apiClient.New(url, tokenAuth).Validate()
+		if err != nil {
+			return nil, err
+		} else if valid {
+			return tokenAuth, nil
+		}
+	}
+
+	auth := &api_client.Auth{
+		Username: string(secret.Data[sonarsourcev1alpha1.AdminSecretUsername]),
+		Password: string(secret.Data[sonarsourcev1alpha1.AdminSecretPassword]),
+	}
+	if auth.Username == "" || auth.Password == "" {
+		return nil, &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("admin secret %s must contain %s or %s and %s", secret.Name, sonarsourcev1alpha1.AdminSecretToken, sonarsourcev1alpha1.AdminSecretUsername, sonarsourcev1alpha1.AdminSecretPassword),
+		}
+	}
+
+	if !owned {
+		return auth, nil
+	}
+
+	valid, err := r.apiClient.New(url, auth).Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		err = r.apiClient.New(url, &api_client.Auth{Username: auth.Username, Password: DefaultAdminPassword}).ChangePassword(auth.Username, DefaultAdminPassword, auth.Password)
+		if err != nil {
+			return nil, &utils.Error{
+				Reason:  utils.ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("unable to change default password for %s: %s", auth.Username, err.Error()),
+			}
+		}
+	}
+
+	// The operator keeps a single token, the previous one is revoked so tokens don't pile up
+	err = r.apiClient.New(url, auth).RevokeToken(AdminTokenName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := r.apiClient.New(url, auth).GenerateToken(AdminTokenName)
+	if err != nil {
+		return nil, err
+	} else if token == nil || token.Token == "" {
+		return nil, fmt.Errorf("empty token generated for %s", auth.Username)
+	}
+
+	secret.Data[sonarsourcev1alpha1.AdminSecretToken] = []byte(token.Token)
+
+	return nil, utils.UpdateResource(r.client, secret, utils.ErrorReasonResourceUpdate, fmt.Sprintf("stored admin token in secret %s", secret.Name))
+}
+
+// adminSecretBootstrap returns the name of the first application node of the cluster cr is part of when cr is another
+// application node, otherwise an empty string as cr creates and bootstraps the admin Secret itself
+func (r *ReconcileSonarQubeServer) adminSecretBootstrap(cr *sonarsourcev1alpha1.SonarQubeServer) string {
+	cluster, ok := cr.Labels[sonarsourcev1alpha1.KubeAppPartof]
+	if !ok || cr.Spec.Type == nil || *cr.Spec.Type != sonarsourcev1alpha1.Application {
+		return ""
+	}
+	if first := utils.ServerName(cluster, sonarsourcev1alpha1.Application, 0); first != cr.Name {
+		return first
+	}
+	return ""
+}
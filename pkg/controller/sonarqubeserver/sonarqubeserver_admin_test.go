@@ -0,0 +1,148 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeServerAdminAuth runs ReconcileSonarQubeServer.verifyAdminAuth() against a
+// fake client
+func TestSonarQubeServerAdminAuth(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+		url       = "http://localhost:9000"
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	auth, err := r.verifyAdminAuth(sonarqube, url)
+	if err != nil || auth != nil {
+		t.Error("verifyAdminAuth: returned credentials even though admin secret not set in spec")
+	}
+
+	sonarqube.Spec.AdminSecret = &[]string{"admin"}[0]
+
+	_, err = r.verifyAdminAuth(sonarqube, url)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("verifyAdminAuth: resource created error not thrown when creating admin secret")
+	}
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.AdminSecret, Namespace: namespace}, secret)
+	if err != nil {
+		t.Fatalf("verifyAdminAuth: (%v)", err)
+	}
+	if len(secret.Data[sonarsourcev1alpha1.AdminSecretPassword]) == 0 {
+		t.Error("verifyAdminAuth: admin secret created without password")
+	}
+
+	apiMock.ValidateOutput = false
+	apiMock.GenerateTokenOutput = &api_client.UserToken{Token: "token"}
+	_, err = r.verifyAdminAuth(sonarqube, url)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("verifyAdminAuth: resource update error not thrown when storing token")
+	}
+	if !apiMock.ChangePasswordCalled {
+		t.Error("verifyAdminAuth: default admin password not changed")
+	}
+	if len(apiMock.RevokedTokens) != 1 || apiMock.RevokedTokens[0] != AdminTokenName {
+		t.Errorf("verifyAdminAuth: expected token %s to be revoked before generating got %v", AdminTokenName, apiMock.RevokedTokens)
+	}
+
+	apiMock.ValidateOutput = true
+	auth, err = r.verifyAdminAuth(sonarqube, url)
+	if err != nil {
+		t.Fatalf("verifyAdminAuth: (%v)", err)
+	}
+	if auth == nil || auth.Token != "token" {
+		t.Error("verifyAdminAuth: stored token not returned")
+	}
+
+	// A stored token that is no longer valid is revoked and generated again
+	apiMock.ValidateOutput = false
+	apiMock.GenerateTokenOutput = &api_client.UserToken{Token: "regenerated"}
+	_, err = r.verifyAdminAuth(sonarqube, url)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("verifyAdminAuth: resource update error not thrown when regenerating token")
+	}
+	if len(apiMock.RevokedTokens) != 2 {
+		t.Error("verifyAdminAuth: invalid token not revoked before generating")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.AdminSecret, Namespace: namespace}, secret)
+	if err != nil {
+		t.Fatalf("verifyAdminAuth: (%v)", err)
+	}
+	if string(secret.Data[sonarsourcev1alpha1.AdminSecretToken]) != "regenerated" {
+		t.Error("verifyAdminAuth: regenerated token not stored")
+	}
+
+	// Other application nodes of a cluster wait for the first application node to create the admin secret
+	application := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.ServerName("cluster", sonarsourcev1alpha1.Application, 1),
+			Namespace: namespace,
+			Labels:    map[string]string{sonarsourcev1alpha1.KubeAppPartof: "cluster"},
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Type:        &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Application}[0],
+			AdminSecret: &[]string{"cluster-admin"}[0],
+		},
+	}
+	_, err = r.verifyAdminAuth(application, url)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("verifyAdminAuth: resource waiting error not thrown when admin secret is created by the first application node")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *application.Spec.AdminSecret, Namespace: namespace}, &corev1.Secret{})
+	if err == nil {
+		t.Error("verifyAdminAuth: admin secret created by application node other than the first")
+	}
+
+	unowned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unowned",
+			Namespace: namespace,
+		},
+	}
+	err = r.client.Create(context.TODO(), unowned)
+	if err != nil {
+		t.Fatalf("verifyAdminAuth: (%v)", err)
+	}
+	sonarqube.Spec.AdminSecret = &unowned.Name
+	_, err = r.verifyAdminAuth(sonarqube, url)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("verifyAdminAuth: spec invalid error not thrown for admin secret without credentials")
+	}
+}
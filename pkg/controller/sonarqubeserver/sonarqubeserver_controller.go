@@ -6,9 +6,12 @@ import (
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -37,6 +40,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 		client:    mgr.GetClient(),
 		scheme:    mgr.GetScheme(),
 		apiClient: &api_client.APIClient{},
+		recorder:  mgr.GetEventRecorderFor("sonarqubeserver-controller"),
 	}
 }
 
@@ -81,6 +85,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to secondary resource Ingress and requeue the owner SonarQube
+	err = c.Watch(&source.Kind{Type: &networkingv1beta1.Ingress{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeServer{},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to secondary resource PersistentVolumeClaim and requeue the owner SonarQube
 	err = c.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, &handler.EnqueueRequestForOwner{
 		IsController: true,
@@ -90,6 +103,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to secondary resource Job of upgrade snapshots and requeue the owner SonarQubeServer
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &sonarsourcev1alpha1.SonarQubeServer{},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Watch for changes to secondary resource Secret and requeue the watcher
 	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: &utils.SecretMapper{Annotation: sonarsourcev1alpha1.ServerSecretAnnotation},
@@ -98,6 +120,14 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to ConfigMaps of propertiesFrom and requeue the watcher
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &utils.SecretMapper{Annotation: sonarsourcev1alpha1.ServerSecretAnnotation},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -111,6 +141,7 @@ type ReconcileSonarQubeServer struct {
 	client    client.Client
 	scheme    *runtime.Scheme
 	apiClient api_client.APIProvider
+	recorder  record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a SonarQubeServer object and makes changes based on the state read
@@ -141,36 +172,73 @@ func (r *ReconcileSonarQubeServer) Reconcile(request reconcile.Request) (reconci
 
 	_, err = r.ReconcileSecret(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
 	_, err = r.ReconcileServiceAccount(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
 	_, err = r.ReconcileService(instance)
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	_, err = r.ReconcileHeadlessService(instance)
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	err = r.ReconcileExpose(instance)
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
+	}
+
+	// The Deployment is not updated to a new version until the snapshot of the current version succeeded
+	err = r.ReconcileSnapshot(instance)
+	if err != nil {
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
 	_, err = r.ReconcileDeployment(instance)
+	if err != nil && utils.ReasonForError(err) == utils.ErrorReasonResourceShutdown {
+		newStatus = instance.DeepCopy()
+		newStatus.Status.ObservedGeneration = instance.Generation
+		utils.UpdateStatus(r.client, newStatus, instance)
+	}
 	if err != nil {
-		return utils.ParseErrorForReconcileResult(r.client, instance, err)
+		return r.parseErrorForReconcileResult(instance, err)
 	}
 
 	if (instance.Spec.Shutdown == nil || !*instance.Spec.Shutdown) && (instance.Spec.Type == nil || *instance.Spec.Type != sonarsourcev1alpha1.Search) {
 		err = r.ReconcileServer(instance)
 		if err != nil {
-			return utils.ParseErrorForReconcileResult(r.client, instance, err)
+			err = r.rollbackSnapshot(instance, err)
+			return r.parseErrorForReconcileResult(instance, err)
 		}
 	}
 
+	utils.RecordEventForReconciled(r.recorder, instance, instance.Status.Conditions, "sonarqube server is up")
+
 	newStatus = instance.DeepCopy()
 
 	newStatus.Status.Conditions = utils.ClearConditions(newStatus.Status.Conditions)
+	if instance.Spec.Type != nil && *instance.Spec.Type == sonarsourcev1alpha1.Search {
+		utils.SetReadyCondition(&newStatus.Status.Conditions, sonarsourcev1alpha1.ConditionDeploymentAvailable, "search node is available")
+	} else {
+		utils.SetReadyCondition(&newStatus.Status.Conditions, sonarsourcev1alpha1.ConditionServerUp, "sonarqube server is up")
+	}
+	newStatus.Status.Phase = sonarsourcev1alpha1.ServerPhaseRunning
+	newStatus.Status.ObservedGeneration = instance.Generation
 
 	utils.UpdateStatus(r.client, newStatus, instance)
 
 	return reconcile.Result{}, nil
 }
+
+// parseErrorForReconcileResult records an event for err and reports it in the status of cr
+func (r *ReconcileSonarQubeServer) parseErrorForReconcileResult(cr *sonarsourcev1alpha1.SonarQubeServer, err error) (reconcile.Result, error) {
+	utils.RecordEventForError(r.recorder, cr, err)
+	return utils.ParseErrorForReconcileResult(r.client, cr, err)
+}
@@ -2,7 +2,9 @@ package sonarqubeserver
 
 import (
 	"context"
+	"fmt"
 	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -14,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -54,7 +57,8 @@ func TestSonarQubeServerController(t *testing.T) {
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
 	apiMock := &api_client.APIClientMock{}
-	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+	recorder := record.NewFakeRecorder(100)
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock, recorder: recorder}
 	apiMock.InfoOutput = &api_client.Status{
 		Version: api_client.SystemVersion{
 			Major: 8,
@@ -79,15 +83,6 @@ func TestSonarQubeServerController(t *testing.T) {
 	if !res.Requeue {
 		t.Error("reconcile did not requeue")
 	}
-
-	res, err = r.Reconcile(req)
-	if err != nil {
-		t.Fatalf(ReconcileErrorFormat, err)
-	}
-	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue")
-	}
 	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqube)
 	if err != nil {
 		t.Fatalf(ReconcileErrorFormat, err)
@@ -95,8 +90,14 @@ func TestSonarQubeServerController(t *testing.T) {
 	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionProgressing) {
 		t.Errorf("condition progressing not set")
 	}
+	if sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionReady) || sonarqube.Status.Phase != sonarsourcev1alpha1.ServerPhaseProgressing {
+		t.Errorf("reconcile: server reported ready while progressing, phase %s", sonarqube.Status.Phase)
+	}
+	if event := <-recorder.Events; event != fmt.Sprintf("Normal ResourceCreate created Secret %s", utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret)) {
+		t.Errorf("reconcile: expected event for created secret got %s", event)
+	}
 	secret := &corev1.Secret{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.Secret, Namespace: sonarqube.Namespace}, secret)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
 	if err != nil && errors.IsNotFound(err) {
 		t.Error("reconcile: secret not created")
 	} else if err != nil {
@@ -210,14 +211,33 @@ func TestSonarQubeServerController(t *testing.T) {
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
 	if !res.Requeue {
-		t.Error("reconcile did not requeue to set version")
+		t.Error("reconcile did not requeue to resolve version")
 	}
 	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqube)
 	if err != nil {
 		t.Fatalf(ReconcileErrorFormat, err)
 	}
-	if sonarqube.Spec.Version == nil {
-		t.Error("sonarqube version not set")
+	if sonarqube.Spec.Version != nil {
+		t.Error("sonarqube spec version set while resolving version")
+	}
+	if sonarqube.Status.Version != "8.3.0" {
+		t.Errorf("sonarqube version not resolved in status, got %s", sonarqube.Status.Version)
+	}
+
+	res, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	// Check the result of reconciliation to make sure it has the desired state.
+	if !res.Requeue {
+		t.Error("reconcile did not requeue to roll deployment to resolved version")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: namespace}, deployment)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != utils.GetImage(sonarqube.Spec.Edition, &sonarqube.Status.Version, sonarqube.Spec.Type) {
+		t.Error("deployment image not updated to resolved version")
 	}
 
 	apiMock.UpgradesOutput = &api_client.Upgrades{
@@ -233,4 +253,18 @@ func TestSonarQubeServerController(t *testing.T) {
 	if res.Requeue {
 		t.Error("reconcile requeued even though everything should be good")
 	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf(ReconcileErrorFormat, err)
+	}
+	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionReady) || sonarqube.Status.Phase != sonarsourcev1alpha1.ServerPhaseRunning {
+		t.Errorf("reconcile: server not reported ready when up, phase %s", sonarqube.Status.Phase)
+	}
+	var reconciled bool
+	for len(recorder.Events) > 0 {
+		reconciled = <-recorder.Events == "Normal Reconciled sonarqube server is up"
+	}
+	if !reconciled {
+		t.Error("reconcile: reconciled event not recorded when server became up")
+	}
 }
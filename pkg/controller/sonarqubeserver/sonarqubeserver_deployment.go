@@ -6,6 +6,7 @@ import (
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"reflect"
@@ -26,15 +27,22 @@ const (
 // If Error is non-nil, Deployment is not in expected state
 // Errors:
 //   ErrorReasonResourceCreate: returned when Deployment does not exists
-//   ErrorReasonResourceUpdate: returned when Deployment was updated to meet expected state
+//   ErrorReasonResourceUpdate: returned when Deployment or a referenced database Secret was updated to meet expected state
+//   ErrorReasonResourceWaiting: returned when Deployment is not ready, pods of a shutdown server are terminating, or a referenced database Secret does not exist
+//   ErrorReasonResourceShutdown: returned when server is shutdown and no pods are running
 //   ErrorReasonUnknown: returned when unhandled error from client occurs
 func (r *ReconcileSonarQubeServer) ReconcileDeployment(cr *sonarsourcev1alpha1.SonarQubeServer) (*appsv1.Deployment, error) {
-	deployment, err := r.findDeployment(cr)
+	databaseRevision, err := r.databaseRevision(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := r.findDeployment(cr, databaseRevision)
 	if err != nil {
 		return deployment, err
 	}
 
-	err = r.verifyDeployment(cr, deployment)
+	err = r.verifyDeployment(cr, deployment, databaseRevision)
 	if err != nil {
 		return deployment, err
 	}
@@ -42,34 +50,63 @@ func (r *ReconcileSonarQubeServer) ReconcileDeployment(cr *sonarsourcev1alpha1.S
 	newStatus := cr.DeepCopy()
 
 	newStatus.Status.Deployment = r.getDeploymentStatus([]*appsv1.Deployment{deployment})
+	utils.SetAvailableCondition(&newStatus.Status.Conditions, deployment.Status.AvailableReplicas > 0,
+		fmt.Sprintf("%d of %d pods of deployment %s are available", deployment.Status.AvailableReplicas, deployment.Status.Replicas, deployment.Name))
 	utils.UpdateStatus(r.client, newStatus, cr)
 
+	if cr.Spec.Shutdown != nil && *cr.Spec.Shutdown {
+		if deployment.Status.Replicas > 0 {
+			return deployment, &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for pods of deployment %s to terminate", deployment.Name),
+			}
+		}
+		return deployment, &utils.Error{
+			Reason:  utils.ErrorReasonResourceShutdown,
+			Message: fmt.Sprintf("sonarqube server %s is shutdown", cr.Name),
+		}
+	}
+
 	if utils.GetDeploymentCondition(deployment, appsv1.DeploymentReplicaFailure) == corev1.ConditionTrue {
 		return deployment, &utils.Error{
 			Reason:  utils.ErrorReasonResourceInvalid,
-			Message: "deployment replica failure",
+			Message: fmt.Sprintf("replica failure of deployment %s", deployment.Name),
 		}
 	}
 
 	if deployment.Status.Replicas > 0 && len(newStatus.Status.Deployment[sonarsourcev1alpha1.DeploymentReady]) < 1 {
 		return deployment, &utils.Error{
 			Reason:  utils.ErrorReasonResourceWaiting,
-			Message: "waiting for deployment to be ready",
+			Message: fmt.Sprintf("waiting for deployment %s to be ready", deployment.Name),
 		}
 	}
 
 	if deployment.Status.Replicas > 0 && len(newStatus.Status.Deployment[sonarsourcev1alpha1.DeploymentAvailable]) < 1 && len(newStatus.Status.Deployment[sonarsourcev1alpha1.DeploymentReady]) < 1 {
 		return deployment, &utils.Error{
 			Reason:  utils.ErrorReasonResourceWaiting,
-			Message: "waiting for deployment to be available and not progressing",
+			Message: fmt.Sprintf("waiting for deployment %s to be available and not progressing", deployment.Name),
 		}
 	}
 
 	return deployment, nil
 }
 
-func (r *ReconcileSonarQubeServer) findDeployment(cr *sonarsourcev1alpha1.SonarQubeServer) (*appsv1.Deployment, error) {
-	newDeployment, err := r.newDeployment(cr)
+// databaseRevision returns a hash of the database Secrets referenced by cr, empty when the server does not read any
+// Referenced Secrets are annotated so their changes requeue cr
+func (r *ReconcileSonarQubeServer) databaseRevision(cr *sonarsourcev1alpha1.SonarQubeServer) (string, error) {
+	// Search nodes don't connect to the database
+	if (cr.Spec.Type != nil && *cr.Spec.Type == sonarsourcev1alpha1.Search) || !utils.HasDatabaseSecretRefs(cr.Spec.Database) {
+		return "", nil
+	}
+	databaseSecrets, err := utils.GetDatabaseSecrets(r.client, cr.Namespace, sonarsourcev1alpha1.ServerSecretAnnotation, cr.Name, cr.Spec.Database)
+	if err != nil {
+		return "", err
+	}
+	return utils.GenVersion(cr.Spec.Database, nil, databaseSecrets...)
+}
+
+func (r *ReconcileSonarQubeServer) findDeployment(cr *sonarsourcev1alpha1.SonarQubeServer, databaseRevision string) (*appsv1.Deployment, error) {
+	newDeployment, err := r.newDeployment(cr, databaseRevision)
 	if err != nil {
 		return newDeployment, err
 	}
@@ -79,16 +116,18 @@ func (r *ReconcileSonarQubeServer) findDeployment(cr *sonarsourcev1alpha1.SonarQ
 	return foundDeployment, utils.CreateResourceIfNotFound(r.client, newDeployment, foundDeployment)
 }
 
-func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQubeServer) (*appsv1.Deployment, error) {
+// newDeployment returns the expected Deployment of cr, databaseRevision is the hash of the referenced database Secrets
+// returned by databaseRevision
+func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQubeServer, databaseRevision string) (*appsv1.Deployment, error) {
 	labels := r.Labels(cr)
 	podLabels := r.PodLabels(cr)
 
-	serviceAccount, secret, pvc, service, err := r.getDeploymentDeps(cr)
+	serviceAccount, secret, pvc, _, err := r.getDeploymentDeps(cr)
 	if err != nil {
 		return nil, err
 	}
 
-	sqImage := utils.GetImage(cr.Spec.Edition, cr.Spec.Version)
+	sqImage := utils.GetImage(cr.Spec.Edition, r.version(cr), cr.Spec.Type)
 
 	var replicas *int32
 	if cr.Spec.Shutdown == nil || *cr.Spec.Shutdown == false {
@@ -245,6 +284,13 @@ func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQu
 		},
 	}
 
+	if url := r.externalURL(cr); url != "" {
+		dep.Spec.Template.Spec.Containers[0].Env = append(dep.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "SONAR_CORE_SERVERBASEURL",
+			Value: url,
+		})
+	}
+
 	if cr.Spec.NodeConfig.Resources != nil {
 		dep.Spec.Template.Spec.Containers[0].Resources = *cr.Spec.NodeConfig.Resources
 	}
@@ -264,6 +310,15 @@ func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQu
 		nodeType = *cr.Spec.Type
 	}
 
+	// Search nodes don't load plugins
+	if nodeType != sonarsourcev1alpha1.Search && len(cr.Spec.Plugins) > 0 {
+		pluginsContainer, err := r.newPluginsContainer(cr.Spec.Plugins)
+		if err != nil {
+			return dep, err
+		}
+		dep.Spec.Template.Spec.InitContainers = []corev1.Container{*pluginsContainer}
+	}
+
 	switch nodeType {
 	case sonarsourcev1alpha1.AIO:
 		dep.Spec.Template.Spec.Containers[0].Ports = []corev1.ContainerPort{
@@ -292,13 +347,10 @@ func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQu
 			},
 		}
 		hosts := cr.Spec.Hosts
-		if !utils.ContainsString(hosts, service.Spec.ClusterIP) {
-			hosts = append(hosts, service.Spec.ClusterIP)
+		if !utils.ContainsString(hosts, r.clusterHost(cr)) {
+			hosts = append(hosts, r.clusterHost(cr))
 		}
 		searchHosts := cr.Spec.SearchHosts
-		if !utils.ContainsString(searchHosts, service.Spec.ClusterIP) {
-			searchHosts = append(searchHosts, service.Spec.ClusterIP)
-		}
 
 		clusteredEnv := []corev1.EnvVar{
 			{
@@ -341,8 +393,8 @@ func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQu
 			},
 		}
 		searchHosts := cr.Spec.SearchHosts
-		if !utils.ContainsString(cr.Spec.SearchHosts, service.Spec.ClusterIP) {
-			searchHosts = append(searchHosts, service.Spec.ClusterIP)
+		if !utils.ContainsString(searchHosts, r.clusterHost(cr)) {
+			searchHosts = append(searchHosts, r.clusterHost(cr))
 		}
 
 		clusteredEnv := []corev1.EnvVar{
@@ -399,6 +451,12 @@ func (r *ReconcileSonarQubeServer) newDeployment(cr *sonarsourcev1alpha1.SonarQu
 		}
 	}
 
+	// Search nodes don't connect to the database
+	if nodeType != sonarsourcev1alpha1.Search && utils.HasDatabaseSecretRefs(cr.Spec.Database) {
+		dep.Spec.Template.Annotations = map[string]string{sonarsourcev1alpha1.SecretsRevisionAnnotation: databaseRevision}
+		dep.Spec.Template.Spec.Containers[0].Env = append(dep.Spec.Template.Spec.Containers[0].Env, utils.DatabaseEnv(cr.Spec.Database)...)
+	}
+
 	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
 		return dep, err
 	}
@@ -431,38 +489,163 @@ func (r *ReconcileSonarQubeServer) getDeploymentDeps(cr *sonarsourcev1alpha1.Son
 	return serviceAccount, secret, pvc, service, nil
 }
 
-func (r *ReconcileSonarQubeServer) verifyDeployment(cr *sonarsourcev1alpha1.SonarQubeServer, deployment *appsv1.Deployment) error {
-	newDeployment, err := r.newDeployment(cr)
+// verifyDeployment updates every field of deployment that differs from the expected Deployment in a single update
+// Fields defaulted by the api server are ignored, the returned error lists the corrected fields
+func (r *ReconcileSonarQubeServer) verifyDeployment(cr *sonarsourcev1alpha1.SonarQubeServer, deployment *appsv1.Deployment, databaseRevision string) error {
+	newDeployment, err := r.newDeployment(cr, databaseRevision)
 	if err != nil {
 		return err
 	}
 
-	if !reflect.DeepEqual(*deployment.Spec.Replicas, *newDeployment.Spec.Replicas) {
+	var updated []string
+
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != *newDeployment.Spec.Replicas {
 		deployment.Spec.Replicas = newDeployment.Spec.Replicas
-		return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, "updated deployment replicas")
+		updated = append(updated, "replicas")
 	}
 
-	if !r.envEqual(newDeployment.Spec.Template.Spec.Containers[0].Env, deployment.Spec.Template.Spec.Containers[0].Env) {
-		deployment.Spec.Template.Spec.Containers[0].Env = newDeployment.Spec.Template.Spec.Containers[0].Env
-		return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, "updated deployment env")
+	if !reflect.DeepEqual(deployment.Labels, newDeployment.Labels) {
+		deployment.Labels = newDeployment.Labels
+		updated = append(updated, "labels")
 	}
 
-	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Containers[0].ReadinessProbe, newDeployment.Spec.Template.Spec.Containers[0].ReadinessProbe) {
-		deployment.Spec.Template.Spec.Containers[0].ReadinessProbe = newDeployment.Spec.Template.Spec.Containers[0].ReadinessProbe
-		return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, "updated deployment readiness probe")
+	// A new revision of referenced secrets rolls the pods without a change of the pod spec
+	if revision := newDeployment.Spec.Template.Annotations[sonarsourcev1alpha1.SecretsRevisionAnnotation]; deployment.Spec.Template.Annotations[sonarsourcev1alpha1.SecretsRevisionAnnotation] != revision {
+		if revision == "" {
+			delete(deployment.Spec.Template.Annotations, sonarsourcev1alpha1.SecretsRevisionAnnotation)
+		} else {
+			if deployment.Spec.Template.Annotations == nil {
+				deployment.Spec.Template.Annotations = make(map[string]string)
+			}
+			deployment.Spec.Template.Annotations[sonarsourcev1alpha1.SecretsRevisionAnnotation] = revision
+		}
+		updated = append(updated, "secrets revision")
 	}
 
-	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Containers[0].LivenessProbe, newDeployment.Spec.Template.Spec.Containers[0].LivenessProbe) {
-		deployment.Spec.Template.Spec.Containers[0].LivenessProbe = newDeployment.Spec.Template.Spec.Containers[0].LivenessProbe
-		return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, "updated deployment liveness probe")
+	podSpec, newPodSpec := &deployment.Spec.Template.Spec, &newDeployment.Spec.Template.Spec
+
+	// Plugins removed from the spec are pruned by a plugins container without plugins until the pods ran it
+	if len(newPodSpec.InitContainers) == 0 && !r.pluginsPruned(deployment) {
+		pluginsContainer, err := r.newPluginsContainer(nil)
+		if err != nil {
+			return err
+		}
+		newPodSpec.InitContainers = []corev1.Container{*pluginsContainer}
 	}
 
-	if !reflect.DeepEqual(deployment.Labels, newDeployment.Labels) {
-		deployment.Labels = newDeployment.Labels
-		return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, "updated deployment labels")
+	if !r.pluginsContainerEqual(newPodSpec.InitContainers, podSpec.InitContainers) {
+		podSpec.InitContainers = newPodSpec.InitContainers
+		updated = append(updated, "plugins")
+	}
+
+	if !equality.Semantic.DeepEqual(r.defaultVolumes(podSpec.Volumes), r.defaultVolumes(newPodSpec.Volumes)) {
+		podSpec.Volumes = newPodSpec.Volumes
+		updated = append(updated, "volumes")
+	}
+
+	if podSpec.ServiceAccountName != newPodSpec.ServiceAccountName {
+		podSpec.ServiceAccountName = newPodSpec.ServiceAccountName
+		podSpec.DeprecatedServiceAccount = newPodSpec.ServiceAccountName
+		updated = append(updated, "service account")
 	}
 
-	return nil
+	if !equality.Semantic.DeepEqual(podSpec.NodeSelector, newPodSpec.NodeSelector) {
+		podSpec.NodeSelector = newPodSpec.NodeSelector
+		updated = append(updated, "node selector")
+	}
+
+	if !r.affinityEqual(podSpec.Affinity, newPodSpec.Affinity) {
+		podSpec.Affinity = newPodSpec.Affinity
+		updated = append(updated, "affinity")
+	}
+
+	if podSpec.PriorityClassName != newPodSpec.PriorityClassName {
+		podSpec.PriorityClassName = newPodSpec.PriorityClassName
+		// Priority is resolved from the priority class by admission and must be cleared when the class changes
+		podSpec.Priority = nil
+		updated = append(updated, "priority class")
+	}
+
+	if !equality.Semantic.DeepEqual(podSpec.TerminationGracePeriodSeconds, newPodSpec.TerminationGracePeriodSeconds) {
+		podSpec.TerminationGracePeriodSeconds = newPodSpec.TerminationGracePeriodSeconds
+		updated = append(updated, "termination grace period")
+	}
+
+	container, newContainer := &podSpec.Containers[0], &newPodSpec.Containers[0]
+
+	if container.Image != newContainer.Image {
+		container.Image = newContainer.Image
+		updated = append(updated, "image")
+	}
+
+	if container.ImagePullPolicy != newContainer.ImagePullPolicy {
+		container.ImagePullPolicy = newContainer.ImagePullPolicy
+		updated = append(updated, "image pull policy")
+	}
+
+	if !r.envEqual(newContainer.Env, container.Env) {
+		container.Env = newContainer.Env
+		updated = append(updated, "env")
+	}
+
+	// Without resources in the spec LimitRange defaults applied on admission are kept
+	if cr.Spec.NodeConfig.Resources != nil && !equality.Semantic.DeepEqual(container.Resources, newContainer.Resources) {
+		container.Resources = newContainer.Resources
+		updated = append(updated, "resources")
+	}
+
+	if !equality.Semantic.DeepEqual(container.Ports, newContainer.Ports) {
+		container.Ports = newContainer.Ports
+		updated = append(updated, "ports")
+	}
+
+	if !equality.Semantic.DeepEqual(container.VolumeMounts, newContainer.VolumeMounts) {
+		container.VolumeMounts = newContainer.VolumeMounts
+		updated = append(updated, "volume mounts")
+	}
+
+	if !reflect.DeepEqual(container.ReadinessProbe, newContainer.ReadinessProbe) {
+		container.ReadinessProbe = newContainer.ReadinessProbe
+		updated = append(updated, "readiness probe")
+	}
+
+	if !reflect.DeepEqual(container.LivenessProbe, newContainer.LivenessProbe) {
+		container.LivenessProbe = newContainer.LivenessProbe
+		updated = append(updated, "liveness probe")
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+
+	return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, fmt.Sprintf("updated deployment %s", strings.Join(updated, ", ")))
+}
+
+// affinityEqual compares affinities treating nil and empty affinities as equal
+func (r *ReconcileSonarQubeServer) affinityEqual(c, p *corev1.Affinity) bool {
+	if c == nil {
+		c = &corev1.Affinity{}
+	}
+	if p == nil {
+		p = &corev1.Affinity{}
+	}
+	return equality.Semantic.DeepEqual(c, p)
+}
+
+// defaultVolumes returns a copy of volumes with the defaults applied by the api server
+func (r *ReconcileSonarQubeServer) defaultVolumes(volumes []corev1.Volume) []corev1.Volume {
+	var defaulted []corev1.Volume
+	for _, v := range volumes {
+		volume := *v.DeepCopy()
+		if volume.Secret != nil && volume.Secret.DefaultMode == nil {
+			volume.Secret.DefaultMode = &[]int32{corev1.SecretVolumeSourceDefaultMode}[0]
+		}
+		if volume.ConfigMap != nil && volume.ConfigMap.DefaultMode == nil {
+			volume.ConfigMap.DefaultMode = &[]int32{corev1.ConfigMapVolumeSourceDefaultMode}[0]
+		}
+		defaulted = append(defaulted, volume)
+	}
+	return defaulted
 }
 
 func (r *ReconcileSonarQubeServer) envEqual(c, p []corev1.EnvVar) bool {
@@ -510,6 +693,26 @@ func (r *ReconcileSonarQubeServer) envEqual(c, p []corev1.EnvVar) bool {
 	return equal
 }
 
+func (r *ReconcileSonarQubeServer) pluginsContainerEqual(c, p []corev1.Container) bool {
+	var cPlugins, pPlugins *corev1.Container
+	for i := range c {
+		if c[i].Name == PluginsContainerName {
+			cPlugins = &c[i]
+		}
+	}
+	for i := range p {
+		if p[i].Name == PluginsContainerName {
+			pPlugins = &p[i]
+		}
+	}
+
+	if cPlugins == nil || pPlugins == nil {
+		return cPlugins == pPlugins
+	}
+
+	return cPlugins.Image == pPlugins.Image && reflect.DeepEqual(cPlugins.Command, pPlugins.Command) && r.envEqual(cPlugins.Env, pPlugins.Env)
+}
+
 func (r *ReconcileSonarQubeServer) getDeploymentStatus(deployments []*appsv1.Deployment) sonarsourcev1alpha1.DeploymentStatuses {
 	status := sonarsourcev1alpha1.DeploymentStatuses{
 		sonarsourcev1alpha1.DeploymentAvailable:   []string{},
@@ -8,12 +8,14 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"strings"
 	"testing"
 )
 
@@ -134,6 +136,7 @@ func TestSonarQubeServerDeployment(t *testing.T) {
 			Type:   appsv1.DeploymentAvailable,
 			Status: corev1.ConditionTrue,
 		})
+		deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.ReadyReplicas, deployment.Status.AvailableReplicas = 1, 1, 1, 1
 		err = r.client.Status().Update(context.TODO(), deployment)
 		if err != nil {
 			t.Fatalf("reconcileDeployment: (%v)", err)
@@ -143,5 +146,283 @@ func TestSonarQubeServerDeployment(t *testing.T) {
 		if err != nil {
 			t.Error("reconcileDeployment: returned error even though Deployment is in expected state")
 		}
+		if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionAvailable) || sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionUnavailable) {
+			t.Error("reconcileDeployment: available condition not set when pods are available")
+		}
+
+		sonarqube.Spec.Shutdown = &[]bool{true}[0]
+		if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+			t.Fatalf("reconcileDeployment: (%v)", err)
+		}
+		deployment.Status.Replicas = 1
+		if err := r.client.Status().Update(context.TODO(), deployment); err != nil {
+			t.Fatalf("reconcileDeployment: (%v)", err)
+		}
+		_, err = r.ReconcileDeployment(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+			t.Error("reconcileDeployment: resource updated error not thrown when shutting down")
+		}
+		_, err = r.ReconcileDeployment(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+			t.Error("reconcileDeployment: resource waiting error not thrown while pods terminate")
+		}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: sonarqube.Namespace}, deployment)
+		if err != nil {
+			t.Fatalf("reconcileDeployment: (%v)", err)
+		}
+		deployment.Status.Replicas, deployment.Status.AvailableReplicas = 0, 0
+		if err := r.client.Status().Update(context.TODO(), deployment); err != nil {
+			t.Fatalf("reconcileDeployment: (%v)", err)
+		}
+		_, err = r.ReconcileDeployment(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceShutdown {
+			t.Error("reconcileDeployment: resource shutdown error not thrown when server is shutdown")
+		}
+		if sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionAvailable) || !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionUnavailable) {
+			t.Error("reconcileDeployment: unavailable condition not set when no pod is available")
+		}
+	}
+}
+
+// TestSonarQubeServerDeploymentDatabase runs ReconcileSonarQubeServer.ReconcileDeployment() against a
+// fake client with database secret key references
+func TestSonarQubeServerDeploymentDatabase(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Database: &sonarsourcev1alpha1.Database{
+				URLFrom: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"},
+					Key:                  "url",
+				},
+				PasswordFrom: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"},
+					Key:                  "password",
+				},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	// Take care of dependencies, if there is an unkown error here there is not much to do
+	for {
+		_, _, _, _, err := r.getDeploymentDeps(sonarqube)
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("getDeploymentDeps: (%v)", err)
+		} else if err == nil {
+			break
+		}
+	}
+
+	_, err := r.ReconcileDeployment(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileDeployment: resource waiting error not returned when database secret does not exist")
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jdbc",
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"url":      []byte("jdbc:postgresql://postgres/sonar"),
+			"password": []byte("sonar"),
+		},
+	}
+	if err := r.client.Create(context.TODO(), secret); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+
+	_, err = r.ReconcileDeployment(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileDeployment: resource update error not returned when annotating database secret")
+	}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, secret); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+	if v := secret.Annotations[sonarsourcev1alpha1.ServerSecretAnnotation]; v != sonarqube.Name {
+		t.Errorf("reconcileDeployment: database secret annotation isn't sonarqube name, got %s", v)
+	}
+
+	_, err = r.ReconcileDeployment(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileDeployment: resource created error not thrown when creating Deployment")
+	}
+	deployment := &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: sonarqube.Namespace}, deployment); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+	env := make(map[string]corev1.EnvVar)
+	for _, v := range deployment.Spec.Template.Spec.Containers[0].Env {
+		env[v.Name] = v
+	}
+	if v, ok := env["SONAR_JDBC_URL"]; !ok || v.ValueFrom == nil || v.ValueFrom.SecretKeyRef == nil || v.ValueFrom.SecretKeyRef.Key != "url" {
+		t.Error("reconcileDeployment: SONAR_JDBC_URL not set from database secret")
+	}
+	if _, ok := env["SONAR_JDBC_USERNAME"]; ok {
+		t.Error("reconcileDeployment: SONAR_JDBC_USERNAME set without usernameFrom")
+	}
+	revision := deployment.Spec.Template.Annotations[sonarsourcev1alpha1.SecretsRevisionAnnotation]
+	if revision == "" {
+		t.Error("reconcileDeployment: secrets revision annotation not set on pod template")
+	}
+
+	deployment.Status.Conditions = append(deployment.Status.Conditions, appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentAvailable,
+		Status: corev1.ConditionTrue,
+	})
+	if err := r.client.Status().Update(context.TODO(), deployment); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+	_, err = r.ReconcileDeployment(sonarqube)
+	if err != nil {
+		t.Errorf("reconcileDeployment: returned error even though Deployment is in expected state (%v)", err)
+	}
+
+	// Rotating the password rolls the pods
+	secret.Data["password"] = []byte("rotated")
+	if err := r.client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+	_, err = r.ReconcileDeployment(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileDeployment: resource update error not returned when database secret changed")
+	}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: sonarqube.Namespace}, deployment); err != nil {
+		t.Fatalf("reconcileDeployment: (%v)", err)
+	}
+	if v := deployment.Spec.Template.Annotations[sonarsourcev1alpha1.SecretsRevisionAnnotation]; v == revision {
+		t.Error("reconcileDeployment: secrets revision annotation not updated when database secret changed")
+	}
+}
+
+// TestSonarQubeServerDeploymentDrift runs ReconcileSonarQubeServer.verifyDeployment() against a
+// Deployment with server defaults and drifted fields
+func TestSonarQubeServerDeploymentDrift(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Version: &[]string{"8.3"}[0],
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	var deployment *appsv1.Deployment
+	for {
+		var err error
+		deployment, err = r.findDeployment(sonarqube, "")
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("findDeployment: (%v)", err)
+		} else if err == nil {
+			break
+		}
+	}
+
+	// Fields defaulted by the api server
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.DeprecatedServiceAccount = podSpec.ServiceAccountName
+	podSpec.Affinity = nil
+	for _, v := range podSpec.Volumes {
+		if v.Secret != nil {
+			v.Secret.DefaultMode = &[]int32{corev1.SecretVolumeSourceDefaultMode}[0]
+		}
+	}
+	err := r.verifyDeployment(sonarqube, deployment, "")
+	if err != nil {
+		t.Errorf("verifyDeployment: server defaults reported as drift (%v)", err)
+	}
+
+	sonarqube.Spec.Version = &[]string{"8.4"}[0]
+	sonarqube.Spec.NodeConfig.NodeSelector = &map[string]string{"node-role.kubernetes.io/sonarqube": ""}
+	sonarqube.Spec.NodeConfig.PriorityClass = &[]string{"high"}[0]
+	sonarqube.Spec.NodeConfig.Resources = &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("verifyDeployment: (%v)", err)
+	}
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Fatal("verifyDeployment: resource updated error not thrown for drifted deployment")
+	}
+	for _, v := range []string{"node selector", "priority class", "image", "resources"} {
+		if !strings.Contains(err.Error(), v) {
+			t.Errorf("verifyDeployment: %s not listed in %s", v, err.Error())
+		}
+	}
+
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if err != nil {
+		t.Errorf("verifyDeployment: returned error even though Deployment is in expected state (%v)", err)
+	}
+
+	sonarqube.Spec.Plugins = []sonarsourcev1alpha1.Plugin{{Key: "java", Version: &[]string{"6.5.0.22421"}[0]}}
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || len(podSpec.InitContainers) != 1 {
+		t.Error("verifyDeployment: plugins container not added for declared plugins")
+	}
+
+	sonarqube.Spec.Plugins = nil
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || len(podSpec.InitContainers) != 1 {
+		t.Error("verifyDeployment: plugins container removed before removed plugins were pruned")
+	}
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if err != nil {
+		t.Errorf("verifyDeployment: plugins container changed while pruning plugins (%v)", err)
+	}
+
+	deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.ReadyReplicas = 1, 1, 1
+	err = r.verifyDeployment(sonarqube, deployment, "")
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate || len(podSpec.InitContainers) != 0 {
+		t.Error("verifyDeployment: plugins container not removed after plugins were pruned")
 	}
 }
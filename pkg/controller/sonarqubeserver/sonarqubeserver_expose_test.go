@@ -0,0 +1,152 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeServerExpose runs ReconcileSonarQubeServer.ReconcileExpose() against a
+// fake client
+func TestSonarQubeServerExpose(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Expose: &sonarsourcev1alpha1.Expose{
+				Host:         "sonarqube.example.com",
+				TLSSecret:    &[]string{"sonarqube-tls"}[0],
+				IngressClass: &[]string{"nginx"}[0],
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	for {
+		_, err := r.ReconcileService(sonarqube)
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("reconcileService: (%v)", err)
+		} else if err == nil {
+			break
+		}
+	}
+
+	err := r.ReconcileExpose(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileExpose: resource created error not thrown when creating Ingress")
+	}
+	ingress := &networkingv1beta1.Ingress{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: sonarqube.Namespace}, ingress)
+	if err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	if ingress.Annotations[utils.IngressClassAnnotation] != "nginx" || len(ingress.Spec.TLS) != 1 || ingress.Spec.TLS[0].SecretName != "sonarqube-tls" {
+		t.Error("reconcileExpose: ingress does not match expose")
+	}
+
+	err = r.ReconcileExpose(sonarqube)
+	if err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	if sonarqube.Status.URL != "https://sonarqube.example.com" {
+		t.Errorf("reconcileExpose: expected url https://sonarqube.example.com got %s", sonarqube.Status.URL)
+	}
+
+	var deployment *appsv1.Deployment
+	for {
+		deployment, err = r.newDeployment(sonarqube, "")
+		if err != nil && utils.ReasonForError(err) == utils.ErrorReasonUnknown {
+			t.Fatalf("newDeployment: (%v)", err)
+		} else if err == nil {
+			break
+		}
+	}
+	var serverBaseURL string
+	for _, v := range deployment.Spec.Template.Spec.Containers[0].Env {
+		if v.Name == "SONAR_CORE_SERVERBASEURL" {
+			serverBaseURL = v.Value
+		}
+	}
+	if serverBaseURL != "https://sonarqube.example.com" {
+		t.Error("newDeployment: sonar.core.serverBaseURL not set from expose")
+	}
+
+	sonarqube.Spec.Expose.Host = "sonar.example.com"
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	err = r.ReconcileExpose(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileExpose: resource updated error not thrown when host changed")
+	}
+
+	sonarqube.Spec.Expose = nil
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	err = r.ReconcileExpose(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileExpose: resource updated error not thrown when removing Ingress")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: sonarqube.Name, Namespace: sonarqube.Namespace}, ingress)
+	if err == nil || !errors.IsNotFound(err) {
+		t.Error("reconcileExpose: Ingress not deleted")
+	}
+
+	sonarqube.Spec.ServerBaseURL = &[]string{"https://cluster.example.com"}[0]
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	err = r.ReconcileExpose(sonarqube)
+	if err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	if sonarqube.Status.URL != "https://cluster.example.com" {
+		t.Errorf("reconcileExpose: expected url https://cluster.example.com got %s", sonarqube.Status.URL)
+	}
+	sonarqube.Spec.ServerBaseURL = nil
+
+	sonarqube.Spec.Type = &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search}[0]
+	sonarqube.Spec.Expose = &sonarsourcev1alpha1.Expose{Host: "search.example.com"}
+	if err := r.client.Update(context.TODO(), sonarqube); err != nil {
+		t.Fatalf("reconcileExpose: (%v)", err)
+	}
+	err = r.ReconcileExpose(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("reconcileExpose: spec invalid error not thrown for search node")
+	}
+}
@@ -0,0 +1,70 @@
+package sonarqubeserver
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+)
+
+// Starts and tracks database migration for SonarQubeServer
+// Returns: Error
+// If Error is non-nil, database migration is not finished
+// Errors:
+//   ErrorReasonServerMigrating: returned when migration was started or is still running
+//   ErrorReasonServerMigrationFailed: returned when migration failed or is not supported
+//   ErrorReasonServerWaiting: returned when migration finished and server is restarting
+//   ErrorReasonUnknown: returned when unhandled error from api occurs
+func (r *ReconcileSonarQubeServer) verifyDBMigration(_ *sonarsourcev1alpha1.SonarQubeServer, apiClient api_client.APIReader) error {
+	migration, err := apiClient.DBMigrationStatus()
+	if err != nil {
+		return err
+	} else if migration == nil {
+		return fmt.Errorf("nil returned for database migration status")
+	}
+
+	switch migration.State {
+	case api_client.DBMigrationRequired:
+		migration, err = apiClient.MigrateDB()
+		if err != nil {
+			return err
+		} else if migration == nil {
+			return fmt.Errorf("nil returned for database migration")
+		}
+		if migration.State == api_client.DBMigrationFailed {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonServerMigrationFailed,
+				Message: fmt.Sprintf("database migration failed: %s", migration.Message),
+			}
+		}
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerMigrating,
+			Message: fmt.Sprintf("started database migration: %s", migration.Message),
+		}
+	case api_client.DBMigrationRunning:
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerMigrating,
+			Message: fmt.Sprintf("database migration running since %s: %s", migration.StartedAt, migration.Message),
+		}
+	case api_client.DBMigrationFailed:
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerMigrationFailed,
+			Message: fmt.Sprintf("database migration failed: %s", migration.Message),
+		}
+	case api_client.DBMigrationNotSupported:
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerMigrationFailed,
+			Message: fmt.Sprintf("database migration not supported: %s", migration.Message),
+		}
+	case api_client.DBMigrationSucceeded, api_client.DBMigrationNone:
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerWaiting,
+			Message: "database migration finished, waiting for server to start",
+		}
+	default:
+		return &utils.Error{
+			Reason:  utils.ErrorReasonServerWaiting,
+			Message: "waiting for database migration status to report",
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeServerDBMigration runs ReconcileSonarQubeServer.verifyDBMigration() against a
+// mocked api client
+func TestSonarQubeServerDBMigration(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name           = "sonarqube-operator"
+		namespace      = "sonarqube"
+		namespacedName = types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{
+		MigrateDBOutput: &api_client.DBMigrationStatus{
+			State: api_client.DBMigrationRunning,
+		},
+	}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	tests := []struct {
+		state    api_client.DBMigrationState
+		expected utils.ErrorType
+	}{
+		{api_client.DBMigrationRequired, utils.ErrorReasonServerMigrating},
+		{api_client.DBMigrationRunning, utils.ErrorReasonServerMigrating},
+		{api_client.DBMigrationFailed, utils.ErrorReasonServerMigrationFailed},
+		{api_client.DBMigrationNotSupported, utils.ErrorReasonServerMigrationFailed},
+		{api_client.DBMigrationSucceeded, utils.ErrorReasonServerWaiting},
+		{api_client.DBMigrationNone, utils.ErrorReasonServerWaiting},
+	}
+
+	for _, test := range tests {
+		apiMock.DBMigrationStatusOutput = &api_client.DBMigrationStatus{State: test.state}
+		err := r.verifyDBMigration(sonarqube, apiMock)
+		if utils.ReasonForError(err) != test.expected {
+			t.Errorf("verifyDBMigration: state %s returned %s expected %s", test.state, utils.ReasonForError(err), test.expected)
+		}
+	}
+
+	apiMock.DBMigrationStatusOutput = &api_client.DBMigrationStatus{State: api_client.DBMigrationRunning}
+	err := r.verifyDBMigration(sonarqube, apiMock)
+	res, err := utils.ParseErrorForReconcileResult(r.client, sonarqube, err)
+	if err != nil {
+		t.Fatalf("verifyDBMigration: (%v)", err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("verifyDBMigration: running migration not polled")
+	}
+	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf("verifyDBMigration: (%v)", err)
+	}
+	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionDBMigration) {
+		t.Error("verifyDBMigration: condition db migration not set while running")
+	}
+
+	apiMock.DBMigrationStatusOutput = &api_client.DBMigrationStatus{State: api_client.DBMigrationFailed}
+	err = r.verifyDBMigration(sonarqube, apiMock)
+	_, err = utils.ParseErrorForReconcileResult(r.client, sonarqube, err)
+	if err != nil {
+		t.Fatalf("verifyDBMigration: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf("verifyDBMigration: (%v)", err)
+	}
+	if !sonarqube.Status.Conditions.IsTrueFor(sonarsourcev1alpha1.ConditionInvalid) {
+		t.Error("verifyDBMigration: condition invalid not set on failed migration")
+	}
+	if c := sonarqube.Status.Conditions.GetCondition(sonarsourcev1alpha1.ConditionDBMigration); c == nil || c.Reason != sonarsourcev1alpha1.ConditionDBMigrationFailed {
+		t.Error("verifyDBMigration: condition db migration reason not set to failed")
+	}
+}
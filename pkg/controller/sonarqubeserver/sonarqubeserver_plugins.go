@@ -0,0 +1,208 @@
+package sonarqubeserver
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	PluginsContainerName string = "plugins"
+	PluginsImage         string = "busybox:1.32"
+	UpdateCenterURL      string = "https://update.sonarsource.org/update-center.properties"
+
+	// Operator environment variables overriding PluginsImage and UpdateCenterURL
+	PluginsImageEnv    string = "PLUGINS_IMAGE"
+	UpdateCenterURLEnv string = "UPDATE_CENTER_URL"
+)
+
+var (
+	pluginFieldRegexp    = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	pluginChecksumRegexp = regexp.MustCompile(`^[A-Fa-f0-9]{64}$`)
+)
+
+// pluginsScript downloads every plugin listed in SONAR_PLUGINS (one key|version|url|checksum per line)
+// to extensions/plugins and removes jars it installed earlier that are no longer listed
+// Installed jars are recorded in .operator-plugins so plugins installed by other means are left in place
+const pluginsScript = `set -e
+plugins="$SONAR_PATH_EXTENSIONS/plugins"
+managed="$plugins/.operator-plugins"
+mkdir -p "$plugins"
+touch "$managed"
+: > /tmp/keep
+echo "$SONAR_PLUGINS" | while IFS='|' read -r key version url checksum; do
+  [ -n "$key" ] || continue
+  if [ -z "$url" ]; then
+    [ -f /tmp/update-center.properties ] || wget -q -O /tmp/update-center.properties "$SONAR_UPDATE_CENTER_URL"
+    url=$(awk -v k="$key.$version.downloadUrl" 'index($0, k "=") == 1 { print substr($0, length(k) + 2); exit }' /tmp/update-center.properties | sed 's/\\//g')
+    if [ -z "$url" ]; then
+      echo "plugin $key version $version not found in update center"
+      exit 1
+    fi
+  fi
+  file="$(basename "$url")"
+  echo "$file" >> /tmp/keep
+  if [ ! -f "$plugins/$file" ]; then
+    echo "downloading plugin $key from $url"
+    wget -q -O "/tmp/$file" "$url"
+    mv "/tmp/$file" "$plugins/$file"
+  fi
+  if [ -n "$checksum" ] && ! echo "$checksum  $plugins/$file" | sha256sum -c -s -; then
+    echo "checksum of plugin $key does not match $checksum"
+    rm -f "$plugins/$file"
+    exit 1
+  fi
+done
+while read -r file; do
+  [ -n "$file" ] || continue
+  if [ -f "$plugins/$file" ] && ! grep -qxF "$file" /tmp/keep; then
+    echo "removing plugin $file"
+    rm -f "$plugins/$file"
+  fi
+done < "$managed"
+mv /tmp/keep "$managed"
+`
+
+// newPluginsContainer returns the init container that installs plugins
+// An empty plugins list only prunes the plugins installed earlier by the operator
+func (r *ReconcileSonarQubeServer) newPluginsContainer(plugins []sonarsourcev1alpha1.Plugin) (*corev1.Container, error) {
+	var lines []string
+	for _, v := range plugins {
+		err := r.validatePlugin(v)
+		if err != nil {
+			return nil, err
+		}
+		var version, url, checksum string
+		if v.Version != nil {
+			version = *v.Version
+		}
+		if v.URL != nil {
+			url = *v.URL
+		}
+		if v.Checksum != nil {
+			checksum = strings.ToLower(*v.Checksum)
+		}
+		lines = append(lines, strings.Join([]string{v.Key, version, url, checksum}, "|"))
+	}
+
+	return &corev1.Container{
+		Name:    PluginsContainerName,
+		Image:   getEnv(PluginsImageEnv, PluginsImage),
+		Command: []string{"/bin/sh", "-c", pluginsScript},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "SONAR_PATH_EXTENSIONS",
+				Value: VolumePathExtensions,
+			},
+			{
+				Name:  "SONAR_UPDATE_CENTER_URL",
+				Value: getEnv(UpdateCenterURLEnv, UpdateCenterURL),
+			},
+			{
+				Name:  "SONAR_PLUGINS",
+				Value: strings.Join(lines, "\n"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "storage",
+				MountPath: VolumePathExtensions,
+				SubPath:   "extensions",
+			},
+		},
+		ImagePullPolicy: corev1.PullIfNotPresent,
+	}, nil
+}
+
+// pluginsPruned returns true when no plugins installed by the operator are left to prune from the storage of deployment
+// Plugins are left when the plugins container lists plugins, or pods without plugins haven't run yet
+func (r *ReconcileSonarQubeServer) pluginsPruned(deployment *appsv1.Deployment) bool {
+	for _, v := range deployment.Spec.Template.Spec.InitContainers {
+		if v.Name != PluginsContainerName {
+			continue
+		}
+		for _, e := range v.Env {
+			if e.Name == "SONAR_PLUGINS" && e.Value != "" {
+				return false
+			}
+		}
+		return deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.ReadyReplicas > 0 &&
+			deployment.Status.UpdatedReplicas == deployment.Status.Replicas
+	}
+	return true
+}
+
+func (r *ReconcileSonarQubeServer) validatePlugin(plugin sonarsourcev1alpha1.Plugin) error {
+	if !pluginFieldRegexp.MatchString(plugin.Key) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("plugin key %s is invalid", plugin.Key),
+		}
+	}
+
+	if plugin.URL == nil && plugin.Version == nil {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("plugin %s requires version or url", plugin.Key),
+		}
+	}
+
+	if plugin.Version != nil && !pluginFieldRegexp.MatchString(*plugin.Version) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("version %s of plugin %s is invalid", *plugin.Version, plugin.Key),
+		}
+	}
+
+	if plugin.URL != nil && (!strings.HasPrefix(*plugin.URL, "http://") && !strings.HasPrefix(*plugin.URL, "https://") || strings.ContainsAny(*plugin.URL, "| \t\n")) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("url of plugin %s must be a http or https url", plugin.Key),
+		}
+	}
+
+	if plugin.Checksum != nil && !pluginChecksumRegexp.MatchString(*plugin.Checksum) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("checksum of plugin %s must be a sha256 hex digest", plugin.Key),
+		}
+	}
+
+	return nil
+}
+
+// incompatiblePlugins returns the keys of incompatible plugins that would block an upgrade
+// When plugins are declared only declared plugins are considered, otherwise every installed plugin is
+func (r *ReconcileSonarQubeServer) incompatiblePlugins(cr *sonarsourcev1alpha1.SonarQubeServer, upgrade api_client.Upgrade) []string {
+	var keys []string
+	for _, v := range upgrade.Plugins.Incompatible {
+		if len(cr.Spec.Plugins) > 0 && !r.pluginDeclared(cr, v.Key) {
+			continue
+		}
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
+
+func (r *ReconcileSonarQubeServer) pluginDeclared(cr *sonarsourcev1alpha1.SonarQubeServer, key string) bool {
+	for _, v := range cr.Spec.Plugins {
+		if v.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnv returns the value of the operator environment variable key, or fallback when it isn't set
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
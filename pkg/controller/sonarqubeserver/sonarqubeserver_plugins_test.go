@@ -0,0 +1,100 @@
+package sonarqubeserver
+
+import (
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSonarQubeServerPlugins runs ReconcileSonarQubeServer.newPluginsContainer(), ReconcileSonarQubeServer.pluginsPruned() and
+// ReconcileSonarQubeServer.incompatiblePlugins() against declared plugins
+func TestSonarQubeServerPlugins(t *testing.T) {
+	r := &ReconcileSonarQubeServer{}
+	cr := &sonarsourcev1alpha1.SonarQubeServer{}
+
+	cr.Spec.Plugins = []sonarsourcev1alpha1.Plugin{
+		{Key: "java", Version: &[]string{"6.5.0.22421"}[0]},
+		{Key: "checkstyle", URL: &[]string{"https://example.com/checkstyle-sonar-plugin-4.33.jar"}[0], Checksum: &[]string{strings.Repeat("AB", 32)}[0]},
+	}
+	container, err := r.newPluginsContainer(cr.Spec.Plugins)
+	if err != nil {
+		t.Fatalf("newPluginsContainer: (%v)", err)
+	}
+	if container.Image != PluginsImage {
+		t.Errorf("newPluginsContainer: expected image %s got %s", PluginsImage, container.Image)
+	}
+	var plugins string
+	for _, v := range container.Env {
+		if v.Name == "SONAR_PLUGINS" {
+			plugins = v.Value
+		}
+	}
+	if plugins != "java|6.5.0.22421||\ncheckstyle||https://example.com/checkstyle-sonar-plugin-4.33.jar|"+strings.Repeat("ab", 32) {
+		t.Errorf("newPluginsContainer: unexpected plugin list %q", plugins)
+	}
+
+	os.Setenv(PluginsImageEnv, "registry.example.com/busybox:1.32")
+	defer os.Unsetenv(PluginsImageEnv)
+	container, err = r.newPluginsContainer(cr.Spec.Plugins)
+	if err != nil {
+		t.Fatalf("newPluginsContainer: (%v)", err)
+	}
+	if container.Image != "registry.example.com/busybox:1.32" {
+		t.Errorf("newPluginsContainer: image not overridden by %s got %s", PluginsImageEnv, container.Image)
+	}
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.InitContainers = []corev1.Container{*container}
+	if r.pluginsPruned(deployment) {
+		t.Error("pluginsPruned: installed plugins reported as pruned")
+	}
+	container, err = r.newPluginsContainer(nil)
+	if err != nil {
+		t.Fatalf("newPluginsContainer: (%v)", err)
+	}
+	deployment.Spec.Template.Spec.InitContainers = []corev1.Container{*container}
+	if r.pluginsPruned(deployment) {
+		t.Error("pluginsPruned: plugins reported as pruned before pods ran the plugins container")
+	}
+	deployment.Status.Replicas, deployment.Status.UpdatedReplicas, deployment.Status.ReadyReplicas = 1, 1, 1
+	if !r.pluginsPruned(deployment) {
+		t.Error("pluginsPruned: plugins not reported as pruned after pods ran the plugins container")
+	}
+
+	invalid := [][]sonarsourcev1alpha1.Plugin{
+		{{Key: "java"}},
+		{{Key: "java|x", Version: &[]string{"1.0"}[0]}},
+		{{Key: "java", URL: &[]string{"file:///tmp/java.jar"}[0]}},
+		{{Key: "java", Version: &[]string{"1.0"}[0], Checksum: &[]string{"abc"}[0]}},
+	}
+	for _, v := range invalid {
+		cr.Spec.Plugins = v
+		_, err = r.newPluginsContainer(cr.Spec.Plugins)
+		if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+			t.Errorf("newPluginsContainer: spec invalid error not thrown for %v", v[0].Key)
+		}
+	}
+
+	upgrade := api_client.Upgrade{
+		Plugins: api_client.Plugins{
+			Incompatible: []api_client.Plugin{{Key: "checkstyle"}},
+		},
+	}
+	cr.Spec.Plugins = nil
+	if len(r.incompatiblePlugins(cr, upgrade)) != 1 {
+		t.Error("incompatiblePlugins: installed plugin ignored without declared plugins")
+	}
+	cr.Spec.Plugins = []sonarsourcev1alpha1.Plugin{{Key: "java", Version: &[]string{"6.5.0.22421"}[0]}}
+	if len(r.incompatiblePlugins(cr, upgrade)) != 0 {
+		t.Error("incompatiblePlugins: undeclared plugin blocked upgrade")
+	}
+	cr.Spec.Plugins = append(cr.Spec.Plugins, sonarsourcev1alpha1.Plugin{Key: "checkstyle", Version: &[]string{"4.33"}[0]})
+	if len(r.incompatiblePlugins(cr, upgrade)) != 1 {
+		t.Error("incompatiblePlugins: declared plugin did not block upgrade")
+	}
+}
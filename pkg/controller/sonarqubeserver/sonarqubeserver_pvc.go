@@ -60,7 +60,7 @@ func (r *ReconcileSonarQubeServer) newPVC(cr *sonarsourcev1alpha1.SonarQubeServe
 
 	var storageSize string
 	if cr.Spec.NodeConfig.StorageSize == nil {
-		storageSize = DefaultVolumeSize
+		storageSize = utils.DefaultVolumeSize
 	} else {
 		storageSize = *cr.Spec.NodeConfig.StorageSize
 	}
@@ -71,6 +71,15 @@ func (r *ReconcileSonarQubeServer) newPVC(cr *sonarsourcev1alpha1.SonarQubeServe
 		dep.Spec.Resources.Requests[corev1.ResourceStorage] = size
 	}
 
+	// A claim created while rolling back is restored from the VolumeSnapshot taken before the upgrade
+	if snapshot := cr.Status.Snapshot; snapshot != nil && snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRollingBack && snapshot.VolumeSnapshot != "" {
+		dep.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &[]string{utils.VolumeSnapshotGroupVersionKind.Group}[0],
+			Kind:     utils.VolumeSnapshotGroupVersionKind.Kind,
+			Name:     snapshot.VolumeSnapshot,
+		}
+	}
+
 	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
 		return dep, err
 	}
@@ -79,7 +88,3 @@ func (r *ReconcileSonarQubeServer) newPVC(cr *sonarsourcev1alpha1.SonarQubeServe
 }
 
 type Volume string
-
-const (
-	DefaultVolumeSize = "1Gi"
-)
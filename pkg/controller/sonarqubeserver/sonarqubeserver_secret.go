@@ -14,8 +14,9 @@ import (
 // Returns: Secret, Error
 // If Error is non-nil, Service is not in expected state
 // Errors:
-//   ErrorReasonSpecUpdate: returned when spec does not have secret name
+//   ErrorReasonSpecInvalid: returned when properties can not be rendered into secret
 //   ErrorReasonResourceCreate: returned when secret does not exists
+//   ErrorReasonResourceWaiting: returned when a ConfigMap or Secret of propertiesFrom does not exist
 //   ErrorReasonResourceUpdate: returned when secret was updated to meet expected state
 //   ErrorReasonUnknown: returned when unhandled error from client occurs
 func (r *ReconcileSonarQubeServer) ReconcileSecret(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Secret, error) {
@@ -40,9 +41,18 @@ func (r *ReconcileSonarQubeServer) ReconcileSecret(cr *sonarsourcev1alpha1.Sonar
 		}
 	}
 
+	rendered, err := utils.GetPropertiesFrom(r.client, cr.Namespace, sonarsourcev1alpha1.ServerSecretAnnotation, cr.Name, cr.Spec.PropertiesFrom, cr.Spec.Properties)
+	if err != nil {
+		return foundSecret, err
+	}
+	err = utils.RenderProperties(r.client, cr, foundSecret, rendered)
+	if err != nil {
+		return foundSecret, err
+	}
+
 	err = r.verifySecret(cr, foundSecret)
 	if err != nil {
-		return foundSecret, nil
+		return foundSecret, err
 	}
 
 	return foundSecret, nil
@@ -74,12 +84,7 @@ func (r *ReconcileSonarQubeServer) newSecret(cr *sonarsourcev1alpha1.SonarQubeSe
 		Type: corev1.SecretTypeOpaque,
 	}
 
-	if cr.Spec.Secret == nil {
-		cr.Spec.Secret = &[]string{fmt.Sprintf("%s-config", cr.Name)}[0]
-		return dep, utils.UpdateResource(r.client, cr, utils.ErrorReasonSpecUpdate, "updated secret")
-	}
-
-	dep.Name = *cr.Spec.Secret
+	dep.Name = utils.SecretName(cr.Name, cr.Spec.Secret)
 
 	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
 		return dep, err
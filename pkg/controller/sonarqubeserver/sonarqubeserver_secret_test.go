@@ -25,12 +25,8 @@ func TestSonarQubeServerSecret(t *testing.T) {
 	logf.SetLogger(logf.ZapLogger(true))
 
 	var (
-		name           = "sonarqube-operator"
-		namespace      = "sonarqube"
-		namespacedName = types.NamespacedName{
-			Name:      name,
-			Namespace: namespace,
-		}
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
 	)
 
 	// A SonarQubeServer resource with metadata and spec.
@@ -56,23 +52,11 @@ func TestSonarQubeServerSecret(t *testing.T) {
 	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
 
 	_, err := r.ReconcileSecret(sonarqube)
-	if utils.ReasonForError(err) != utils.ErrorReasonSpecUpdate {
-		t.Error("reconcileSecret: spec update error not thrown when secret not set in spec")
-	}
-	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
-	if err != nil {
-		t.Fatalf("reconcileSecret: (%v)", err)
-	}
-	if sonarqube.Spec.Secret == nil {
-		t.Error("reconcileSecret: spec not updated with secret name")
-	}
-
-	_, err = r.ReconcileSecret(sonarqube)
 	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
 		t.Error("reconcileSecret: resource created error not thrown when creating secret")
 	}
 	secret := &corev1.Secret{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: *sonarqube.Spec.Secret, Namespace: sonarqube.Namespace}, secret)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
 	if err != nil && errors.IsNotFound(err) {
 		t.Error("reconcileSecret: secret not created")
 	} else if err != nil {
@@ -133,7 +117,7 @@ func TestSonarQubeServerSecretUnowned(t *testing.T) {
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: sonarqube.Namespace,
-			Name:      *sonarqube.Spec.Secret,
+			Name:      utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret),
 		},
 	}
 	err := r.client.Create(context.TODO(), secret)
@@ -165,3 +149,127 @@ func TestSonarQubeServerSecretUnowned(t *testing.T) {
 		t.Error("reconcileSecret: sonarqube2 name not appended to secret annotation")
 	}
 }
+
+// TestSonarQubeServerSecretProperties runs ReconcileSonarQubeServer.ReconcileSecret() against a
+// fake client with properties and propertiesFrom
+func TestSonarQubeServerSecretProperties(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Properties: map[string]string{"sonar.search.javaOpts": "-Xmx1G"},
+			PropertiesFrom: []sonarsourcev1alpha1.PropertiesSource{
+				{ConfigMapRef: &corev1.LocalObjectReference{Name: "sonar-properties"}},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	_, err := r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Error("reconcileSecret: resource created error not thrown when creating secret")
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSecret: resource waiting error not returned when configmap does not exist")
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: sonarqube.Namespace,
+			Name:      "sonar-properties",
+		},
+		Data: map[string]string{
+			"sonar.log.level":       "DEBUG",
+			"sonar.search.javaOpts": "-Xmx512m",
+		},
+	}
+	err = r.client.Create(context.TODO(), configMap)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: resource update error not returned when annotating configmap")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, configMap)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	if v, ok := configMap.GetAnnotations()[sonarsourcev1alpha1.ServerSecretAnnotation]; !ok || v != sonarqube.Name {
+		t.Error("reconcileSecret: configmap annotation isn't sonarqube name")
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: resource update error not returned when rendering properties")
+	}
+	secret := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: utils.SecretName(sonarqube.Name, sonarqube.Spec.Secret), Namespace: sonarqube.Namespace}, secret)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	sonarProperties, err := utils.GetProperties(secret, utils.SonarPropertiesFile)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	if v, _ := sonarProperties.Get("sonar.log.level"); v != "DEBUG" {
+		t.Errorf("reconcileSecret: sonar.log.level from configmap not rendered, got %s", v)
+	}
+	if v, _ := sonarProperties.Get("sonar.search.javaOpts"); v != "-Xmx1G" {
+		t.Errorf("reconcileSecret: sonar.search.javaOpts from properties does not override configmap, got %s", v)
+	}
+
+	_, err = r.ReconcileSecret(sonarqube)
+	if err != nil {
+		t.Error("reconcileSecret: returned error even though secret is in expected state")
+	}
+
+	sonarqube.Spec.PropertiesFrom = nil
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSecret: resource update error not returned when removing properties")
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, secret)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	sonarProperties, err = utils.GetProperties(secret, utils.SonarPropertiesFile)
+	if err != nil {
+		t.Fatalf("reconcileSecret: (%v)", err)
+	}
+	if _, ok := sonarProperties.Get("sonar.log.level"); ok {
+		t.Error("reconcileSecret: sonar.log.level not removed with configmap")
+	}
+
+	sonarqube.Spec.Properties["sonar.web.port"] = "9001"
+	_, err = r.ReconcileSecret(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("reconcileSecret: spec invalid error not returned for managed property")
+	}
+}
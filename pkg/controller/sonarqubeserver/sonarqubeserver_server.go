@@ -1,7 +1,6 @@
 package sonarqubeserver
 
 import (
-	"context"
 	"fmt"
 	"github.com/parflesh/sonarqube-operator/pkg/api_client"
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
@@ -20,7 +19,7 @@ func (r *ReconcileSonarQubeServer) ReconcileServer(cr *sonarsourcev1alpha1.Sonar
 	} else {
 		url = fmt.Sprintf("http://%s:%v", service.Spec.ClusterIP, service.Spec.Ports[0].Port)
 	}
-	apiClient := r.apiClient.New(url)
+	apiClient := r.apiClient.New(url, nil)
 
 	/*err = apiClient.Ping()
 	if err != nil {
@@ -31,17 +30,33 @@ func (r *ReconcileSonarQubeServer) ReconcileServer(cr *sonarsourcev1alpha1.Sonar
 	}*/
 
 	status, err := r.verifyServerStatus(cr, apiClient)
+	if status != nil && (status.Status == api_client.SystemDBMigrationNeeded || status.Status == api_client.SystemDBMigrationRunning) {
+		return r.verifyDBMigration(cr, apiClient)
+	}
 
 	err = r.verifyServerVersion(cr, status)
 	if err != nil {
 		return err
 	}
 
+	if status.Status == api_client.SystemUp {
+		auth, err := r.verifyAdminAuth(cr, url)
+		if err != nil {
+			return err
+		}
+		apiClient = r.apiClient.New(url, auth)
+	}
+
 	err = r.verifyUpgrades(cr, apiClient)
 	if err != nil {
 		return err
 	}
 
+	err = r.verifyAutoUpgrade(cr, status)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -89,29 +104,41 @@ func (r *ReconcileSonarQubeServer) verifyServerVersion(cr *sonarsourcev1alpha1.S
 		}
 	}
 
-	if cr.Spec.Version == nil {
-		cr.Spec.Version = &mmVersion
-		if cr.Spec.Edition == nil {
-			cr.Spec.Edition = &[]string{"community"}[0]
-		}
-		err := r.client.Update(context.TODO(), cr)
-		if err != nil {
-			return err
-		}
+	// The version is resolved in status so the image is pinned without writing to the spec
+	if r.version(cr) == nil {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Version = mmVersion
+		newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
+		utils.UpdateStatus(r.client, newStatus, cr)
 		return &utils.Error{
-			Reason:  utils.ErrorReasonSpecUpdate,
-			Message: "set version",
+			Reason:  utils.ErrorReasonResourceUpdate,
+			Message: fmt.Sprintf("resolved version %s", mmVersion),
 		}
 	}
 
 	version, _ := status.Version.MarshalJSON()
 	newStatus := cr.DeepCopy()
 	newStatus.Status.ObservedVersion = string(version)
+	newStatus.Status.Version = *r.version(cr)
+	newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
 	utils.UpdateStatus(r.client, newStatus, cr)
 
 	return nil
 }
 
+// version returns the version the image is built from, Spec.Version or the version resolved in status when it is newer
+// and Spec.Version did not change since it was resolved
+// Status.Version holds the version reported by the server when Spec.Version is not set and automatic upgrades
+// nil is returned when neither is set
+func (r *ReconcileSonarQubeServer) version(cr *sonarsourcev1alpha1.SonarQubeServer) *string {
+	// Spec.Version is skipped after the upgrade to it was rolled back
+	rolledBack := ""
+	if snapshot := cr.Status.Snapshot; snapshot != nil && snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRolledBack {
+		rolledBack = snapshot.To
+	}
+	return utils.ResolveVersion(cr.Spec.Version, cr.Status.SpecVersion, cr.Status.Version, rolledBack)
+}
+
 func (r *ReconcileSonarQubeServer) verifyUpgrades(cr *sonarsourcev1alpha1.SonarQubeServer, apiClient api_client.APIReader) error {
 	upgrades, err := apiClient.Upgrades()
 	if err != nil {
@@ -125,10 +152,11 @@ func (r *ReconcileSonarQubeServer) verifyUpgrades(cr *sonarsourcev1alpha1.SonarQ
 	newStatus.Status.Upgrades = sonarsourcev1alpha1.Upgrades{
 		Compatible:   []string{},
 		Incompatible: []string{},
+		History:      cr.Status.Upgrades.History,
 	}
 
 	for _, v := range upgrades.Upgrades {
-		if len(v.Plugins.Incompatible) > 0 {
+		if len(r.incompatiblePlugins(cr, v)) > 0 {
 			newStatus.Status.Upgrades.Incompatible = append(newStatus.Status.Upgrades.Incompatible, v.Version.MajorMinorPatch())
 		} else {
 			newStatus.Status.Upgrades.Compatible = append(newStatus.Status.Upgrades.Compatible, v.Version.MajorMinorPatch())
@@ -1,6 +1,7 @@
 package sonarqubeserver
 
 import (
+	"fmt"
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -8,6 +9,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+const (
+	HeadlessServiceSuffix string = "headless"
+)
+
 // Reconciles Service for SonarQubeServer
 // Returns: Service, Error
 // If Error is non-nil, Service is not in expected state
@@ -78,3 +83,78 @@ func (r *ReconcileSonarQubeServer) newService(cr *sonarsourcev1alpha1.SonarQubeS
 
 	return dep, nil
 }
+
+// Reconciles headless Service for clustered SonarQubeServer
+// The headless Service gives the node a stable DNS name that is published in Status.Host and survives the
+// Service being recreated, servers that are not part of a cluster don't have a headless Service
+// Returns: Service, Error
+// If Error is non-nil, Service is not in expected state
+// Errors:
+//   ErrorReasonResourceCreate: returned when Service does not exists
+//   ErrorReasonResourceUpdate: returned when Service was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeServer) ReconcileHeadlessService(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Service, error) {
+	if cr.Spec.Type == nil || *cr.Spec.Type == sonarsourcev1alpha1.AIO {
+		return nil, nil
+	}
+
+	newService, err := r.newHeadlessService(cr)
+	if err != nil {
+		return newService, err
+	}
+
+	service := &corev1.Service{}
+	err = utils.CreateResourceIfNotFound(r.client, newService, service)
+	if err != nil {
+		return service, err
+	}
+
+	if service.Spec.PublishNotReadyAddresses != newService.Spec.PublishNotReadyAddresses {
+		service.Spec.PublishNotReadyAddresses = newService.Spec.PublishNotReadyAddresses
+		return service, utils.UpdateResource(r.client, service, utils.ErrorReasonResourceUpdate, "updated headless service publish not ready addresses")
+	}
+
+	if err := utils.VerifyService(r.client, service, newService); err != nil {
+		return service, err
+	}
+
+	host := r.clusterHost(cr)
+	if cr.Status.Host != host {
+		newStatus := cr.DeepCopy()
+		newStatus.Status.Host = host
+		utils.UpdateStatus(r.client, newStatus, cr)
+	}
+
+	return service, nil
+}
+
+// clusterHost returns the stable DNS name other cluster members use to reach the server
+func (r *ReconcileSonarQubeServer) clusterHost(cr *sonarsourcev1alpha1.SonarQubeServer) string {
+	return fmt.Sprintf("%s-%s.%s.svc", cr.Name, HeadlessServiceSuffix, cr.Namespace)
+}
+
+func (r *ReconcileSonarQubeServer) newHeadlessService(cr *sonarsourcev1alpha1.SonarQubeServer) (*corev1.Service, error) {
+	labels := r.Labels(cr)
+
+	dep := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      fmt.Sprintf("%s-%s", cr.Name, HeadlessServiceSuffix),
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:  labels,
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+			Ports:     utils.ServicePorts(*cr.Spec.Type),
+			// Cluster members have to find each other before any of them is ready
+			PublishNotReadyAddresses: true,
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
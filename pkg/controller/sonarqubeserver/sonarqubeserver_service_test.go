@@ -2,6 +2,7 @@ package sonarqubeserver
 
 import (
 	"context"
+	"fmt"
 	"github.com/parflesh/sonarqube-operator/pkg/api_client"
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
 	"github.com/parflesh/sonarqube-operator/pkg/utils"
@@ -87,5 +88,27 @@ func TestSonarQubeServerService(t *testing.T) {
 		if err != nil {
 			t.Error("reconcileService: returned error even though Service is in expected state")
 		}
+
+		headless, err := r.ReconcileHeadlessService(sonarqube)
+		if sonarqube.Spec.Type == nil {
+			if err != nil || headless != nil {
+				t.Error("reconcileHeadlessService: headless Service created for server that is not clustered")
+			}
+			continue
+		}
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+			t.Error("reconcileHeadlessService: resource created error not thrown when creating Service")
+		}
+		headless, err = r.ReconcileHeadlessService(sonarqube)
+		if err != nil {
+			t.Error("reconcileHeadlessService: returned error even though Service is in expected state")
+		}
+		if headless.Spec.ClusterIP != corev1.ClusterIPNone || !headless.Spec.PublishNotReadyAddresses {
+			t.Error("reconcileHeadlessService: Service is not headless")
+		}
+		host := fmt.Sprintf("%s-headless.%s.svc", sonarqube.Name, sonarqube.Namespace)
+		if sonarqube.Status.Host != host {
+			t.Errorf("reconcileHeadlessService: expected host %s got %s", host, sonarqube.Status.Host)
+		}
 	}
 }
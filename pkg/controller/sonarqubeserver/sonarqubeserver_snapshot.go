@@ -0,0 +1,528 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"time"
+)
+
+const (
+	SnapshotVolume     = "volume"
+	SnapshotVolumePath = "/volume"
+	SnapshotDirectory  = "snapshots"
+)
+
+// snapshotScript keeps only the snapshot being taken, dumps the database, and archives the extensions
+// The whole volume is archived when it is not snapshot with a VolumeSnapshot, search indexes are rebuilt by the server
+// The VolumeSnapshot is taken after the Job so it holds the dump
+var snapshotScript = fmt.Sprintf(`set -e
+mkdir -p %[1]s/%[2]s
+find %[1]s/%[2]s -mindepth 1 -maxdepth 1 ! -name "${SNAPSHOT_NAME}" -exec rm -rf {} +
+mkdir -p "%[1]s/%[2]s/${SNAPSHOT_NAME}"
+pg_dump --format=custom --no-owner --file="%[1]s/%[2]s/${SNAPSHOT_NAME}/%[3]s"
+tar -czf "%[1]s/%[2]s/${SNAPSHOT_NAME}/%[4]s" -C %[1]s/extensions .
+if [ "${ARCHIVE_VOLUME}" = "true" ]; then
+  tar -czf "%[1]s/%[2]s/${SNAPSHOT_NAME}/%[5]s" -C %[1]s --exclude=./%[2]s --exclude='./data/es*' .
+fi
+`, SnapshotVolumePath, SnapshotDirectory, sonarsourcev1alpha1.BackupDatabaseFile, sonarsourcev1alpha1.BackupExtensionsFile, sonarsourcev1alpha1.SnapshotVolumeFile)
+
+// rollbackScript replaces the database in a single transaction, then replaces the volume with its archive
+// A volume snapshot with a VolumeSnapshot is not archived, the claim was already restored from the VolumeSnapshot
+var rollbackScript = fmt.Sprintf(`set -e
+pg_restore --clean --if-exists --no-owner --single-transaction --dbname="${PGDATABASE}" "%[1]s/%[2]s/${SNAPSHOT_NAME}/%[3]s"
+if [ -f "%[1]s/%[2]s/${SNAPSHOT_NAME}/%[4]s" ]; then
+  find %[1]s -mindepth 1 -maxdepth 1 ! -name %[2]s -exec rm -rf {} +
+  tar -xzf "%[1]s/%[2]s/${SNAPSHOT_NAME}/%[4]s" -C %[1]s
+fi
+rm -rf %[1]s/data/es*
+`, SnapshotVolumePath, SnapshotDirectory, sonarsourcev1alpha1.BackupDatabaseFile, sonarsourcev1alpha1.SnapshotVolumeFile)
+
+// Reconciles the snapshot taken before the version of the image changes for SonarQubeServer
+// Returns: Error
+// If Error is non-nil, the Deployment must not be updated to a new version
+// Errors:
+//   ErrorReasonResourceCreate: returned when VolumeSnapshot, PersistentVolumeClaim, Secret, or Job does not exists
+//   ErrorReasonResourceUpdate: returned when a snapshot was started, a previous snapshot was deleted, or a rollback progressed
+//   ErrorReasonResourceWaiting: returned when VolumeSnapshot or Job has not completed, or pods or the
+//     PersistentVolumeClaim are terminating for a rollback
+//   ErrorReasonResourceInvalid: returned when VolumeSnapshot or Job failed
+//   ErrorReasonSpecInvalid: returned when the database is not PostgreSQL
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeServer) ReconcileSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer) error {
+	if cr.Status.Snapshot != nil && cr.Status.Snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRollingBack {
+		return r.reconcileRollback(cr)
+	}
+
+	if cr.Spec.Snapshot == nil {
+		return nil
+	}
+
+	from, err := r.upgradePending(cr)
+	if err != nil || from == "" {
+		return err
+	}
+
+	snapshot := cr.Status.Snapshot
+	if snapshot == nil || snapshot.To != *r.version(cr) || snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRolledBack {
+		return r.startSnapshot(cr, from)
+	}
+	if snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseReady {
+		return nil
+	}
+
+	job, err := r.findSnapshotJob(cr, snapshot.Name, snapshotScript)
+	if err != nil {
+		return err
+	}
+
+	err = utils.VerifyJob(job, "snapshot job")
+	if err != nil {
+		return err
+	}
+
+	if snapshot.VolumeSnapshot != "" {
+		err = r.verifyVolumeSnapshot(cr, snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Snapshot.Phase = sonarsourcev1alpha1.SnapshotPhaseReady
+	newStatus.Status.Snapshot.Time = &now
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return nil
+}
+
+// rollbackSnapshot starts the rollback to the snapshot taken before the upgrade when err is a failed database
+// migration and rollback is enabled, otherwise err is returned
+func (r *ReconcileSonarQubeServer) rollbackSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, err error) error {
+	snapshot := cr.Status.Snapshot
+	if utils.ReasonForError(err) != utils.ErrorReasonServerMigrationFailed || cr.Spec.Snapshot == nil || cr.Spec.Snapshot.Rollback == nil || !*cr.Spec.Snapshot.Rollback {
+		return err
+	}
+	if snapshot == nil || snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseReady || r.version(cr) == nil || snapshot.To != *r.version(cr) {
+		return err
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Snapshot.Phase = sonarsourcev1alpha1.SnapshotPhaseRollingBack
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("rolling back to %s with snapshot %s (%s)", snapshot.From, snapshot.Name, err.Error()),
+	}
+}
+
+// reconcileRollback stops the server, restores the claim from the VolumeSnapshot, restores the database and archived
+// volume, and sets Status.Version back to the version of the snapshot. The version the upgrade failed for is skipped
+// by version until Spec.Version changes, owners of the server decide on its version with the RolledBack phase
+func (r *ReconcileSonarQubeServer) reconcileRollback(cr *sonarsourcev1alpha1.SonarQubeServer) error {
+	snapshot := cr.Status.Snapshot
+
+	deployment := &appsv1.Deployment{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	} else if err == nil {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0 {
+			deployment.Spec.Replicas = &[]int32{0}[0]
+			return utils.UpdateResource(r.client, deployment, utils.ErrorReasonResourceUpdate, fmt.Sprintf("stopping server to roll back to %s", snapshot.From))
+		}
+		if deployment.Status.Replicas > 0 {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for pods of deployment %s to terminate before rolling back", deployment.Name),
+			}
+		}
+	}
+
+	if snapshot.VolumeSnapshot != "" {
+		err = r.restoreVolumeSnapshot(cr, snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	job, err := r.findSnapshotJob(cr, fmt.Sprintf("%s-rollback", snapshot.Name), rollbackScript)
+	if err != nil {
+		return err
+	}
+
+	err = utils.VerifyJob(job, "rollback job")
+	if err != nil {
+		return err
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Snapshot.Phase = sonarsourcev1alpha1.SnapshotPhaseRolledBack
+	newStatus.Status.Version = snapshot.From
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("rolled back from %s to %s with snapshot %s", snapshot.To, snapshot.From, snapshot.Name),
+	}
+}
+
+// restoreVolumeSnapshot replaces the PersistentVolumeClaim of the stopped server with a claim restored from the
+// VolumeSnapshot of snapshot, the claim is created again by findPVC with the VolumeSnapshot as data source
+func (r *ReconcileSonarQubeServer) restoreVolumeSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, snapshot *sonarsourcev1alpha1.UpgradeSnapshotStatus) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, pvc)
+	if err != nil && errors.IsNotFound(err) {
+		_, err = r.findPVC(cr)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if dataSource := pvc.Spec.DataSource; dataSource != nil && dataSource.Kind == utils.VolumeSnapshotGroupVersionKind.Kind && dataSource.Name == snapshot.VolumeSnapshot {
+		return nil
+	}
+	if pvc.DeletionTimestamp != nil {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for persistent volume claim %s to be deleted before restoring volume snapshot %s", pvc.Name, snapshot.VolumeSnapshot),
+		}
+	}
+	if !utils.IsOwner(cr, pvc) {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceInvalid,
+			Message: fmt.Sprintf("persistent volume claim %s is not owned by sonarqube server %s and can not be restored from volume snapshot %s", pvc.Name, cr.Name, snapshot.VolumeSnapshot),
+		}
+	}
+
+	// The pod of the snapshot Job keeps the claim in use
+	err = r.deleteSnapshotJobs(cr, snapshot.Name)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.Delete(context.TODO(), pvc)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("deleted persistent volume claim %s to restore volume snapshot %s", pvc.Name, snapshot.VolumeSnapshot),
+	}
+}
+
+// upgradePending returns the version the Deployment runs when the resolved version is newer, otherwise an empty string
+// Images without a version are pinned to the version reported by the server and are not upgraded
+func (r *ReconcileSonarQubeServer) upgradePending(cr *sonarsourcev1alpha1.SonarQubeServer) (string, error) {
+	version := r.version(cr)
+	if version == nil {
+		return "", nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, deployment)
+	if err != nil && errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	for _, v := range deployment.Spec.Template.Spec.Containers {
+		if v.Name != "sonarqube" {
+			continue
+		}
+		if from := utils.ImageVersion(v.Image); from != "" && utils.VersionNewer(*version, from) {
+			return from, nil
+		}
+	}
+
+	return "", nil
+}
+
+// startSnapshot deletes the previous snapshot, then records a new snapshot of the upgrade from the version the
+// Deployment runs to the resolved version
+// The VolumeSnapshotClass is looked up once and recorded with the snapshot, the VolumeSnapshot is created by
+// verifyVolumeSnapshot after the database was dumped
+func (r *ReconcileSonarQubeServer) startSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, from string) error {
+	if previous := cr.Status.Snapshot; previous != nil {
+		err := r.deleteSnapshot(cr, previous)
+		if err != nil {
+			return err
+		}
+	}
+
+	volumeSnapshotClass := ""
+	if cr.Spec.Snapshot.VolumeSnapshotClass != nil {
+		volumeSnapshotClass = *cr.Spec.Snapshot.VolumeSnapshotClass
+	} else {
+		class, err := utils.FindVolumeSnapshotClass(r.client, cr.Namespace, cr.Name)
+		if err != nil && errors.IsNotFound(err) {
+			return &utils.Error{
+				Reason:  utils.ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for persistent volume claim %s", cr.Name),
+			}
+		} else if err != nil {
+			return err
+		}
+		volumeSnapshotClass = class
+	}
+
+	snapshot := &sonarsourcev1alpha1.UpgradeSnapshotStatus{
+		Name:  fmt.Sprintf("%s-snapshot-%d", cr.Name, time.Now().Unix()),
+		From:  from,
+		To:    *r.version(cr),
+		Phase: sonarsourcev1alpha1.SnapshotPhasePending,
+	}
+
+	message := fmt.Sprintf("archiving volume and database for upgrade from %s to %s", snapshot.From, snapshot.To)
+	if volumeSnapshotClass != "" {
+		snapshot.VolumeSnapshot = snapshot.Name
+		snapshot.VolumeSnapshotClass = volumeSnapshotClass
+		message = fmt.Sprintf("dumping database and snapshotting volume for upgrade from %s to %s", snapshot.From, snapshot.To)
+	}
+
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Snapshot = snapshot
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: message,
+	}
+}
+
+// deleteSnapshot deletes the VolumeSnapshot and Jobs of snapshot, the dump in the volume is replaced by the next snapshot
+func (r *ReconcileSonarQubeServer) deleteSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, snapshot *sonarsourcev1alpha1.UpgradeSnapshotStatus) error {
+	if snapshot.VolumeSnapshot != "" {
+		err := utils.DeleteResourceIfOwned(r.client, cr, "VolumeSnapshot", types.NamespacedName{Name: snapshot.VolumeSnapshot, Namespace: cr.Namespace}, utils.NewVolumeSnapshotObject())
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.deleteSnapshotJobs(cr, snapshot.Name, fmt.Sprintf("%s-rollback", snapshot.Name))
+}
+
+// deleteSnapshotJobs deletes the Jobs names owned by cr together with their pods
+func (r *ReconcileSonarQubeServer) deleteSnapshotJobs(cr *sonarsourcev1alpha1.SonarQubeServer, names ...string) error {
+	for _, name := range names {
+		job := &batchv1.Job{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, job)
+		if err != nil && errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !utils.IsOwner(cr, job) {
+			continue
+		}
+		err = r.client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQubeServer) newVolumeSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, snapshot *sonarsourcev1alpha1.UpgradeSnapshotStatus) (*unstructured.Unstructured, error) {
+	dep := utils.NewVolumeSnapshot(metav1.ObjectMeta{
+		Namespace: cr.Namespace,
+		Name:      snapshot.VolumeSnapshot,
+		Labels:    r.snapshotLabels(cr, snapshot.Name),
+	}, snapshot.VolumeSnapshotClass, cr.Name)
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+// verifyVolumeSnapshot creates the VolumeSnapshot of snapshot and waits for it to be ready to use
+func (r *ReconcileSonarQubeServer) verifyVolumeSnapshot(cr *sonarsourcev1alpha1.SonarQubeServer, snapshot *sonarsourcev1alpha1.UpgradeSnapshotStatus) error {
+	volumeSnapshot := utils.NewVolumeSnapshotObject()
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: snapshot.VolumeSnapshot, Namespace: cr.Namespace}, volumeSnapshot)
+	if err != nil && errors.IsNotFound(err) {
+		newVolumeSnapshot, err := r.newVolumeSnapshot(cr, snapshot)
+		if err != nil {
+			return err
+		}
+		err = r.client.Create(context.TODO(), newVolumeSnapshot)
+		if err != nil {
+			return err
+		}
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceCreate,
+			Message: fmt.Sprintf("created volume snapshot %s for upgrade from %s to %s", snapshot.VolumeSnapshot, snapshot.From, snapshot.To),
+		}
+	} else if err != nil {
+		return err
+	}
+
+	ready, err := utils.VolumeSnapshotReady(volumeSnapshot)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return &utils.Error{
+			Reason:  utils.ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for volume snapshot %s to be ready", snapshot.VolumeSnapshot),
+		}
+	}
+
+	return nil
+}
+
+func (r *ReconcileSonarQubeServer) findSnapshotJob(cr *sonarsourcev1alpha1.SonarQubeServer, name, script string) (*batchv1.Job, error) {
+	connection, err := utils.GetDatabaseConnection(r.client, cr.Namespace, utils.SecretName(cr.Name, cr.Spec.Secret), cr.Spec.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = utils.ReconcileCredentialsSecret(r.client, r.scheme, cr, r.snapshotSecretName(cr), r.Labels(cr), connection)
+	if err != nil {
+		return nil, err
+	}
+
+	// The claim can only be mounted next to a running server
+	running := false
+	deployment := &appsv1.Deployment{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		running = deployment.Status.Replicas > 0
+	}
+
+	newJob, err := r.newSnapshotJob(cr, name, script, connection, running)
+	if err != nil {
+		return newJob, err
+	}
+
+	foundJob := &batchv1.Job{}
+
+	return foundJob, utils.CreateResourceIfNotFound(r.client, newJob, foundJob)
+}
+
+func (r *ReconcileSonarQubeServer) snapshotSecretName(cr *sonarsourcev1alpha1.SonarQubeServer) string {
+	return fmt.Sprintf("%s-snapshot", cr.Name)
+}
+
+// newSnapshotJob returns the Job running script, the Job is required to run next to the pod of the server when running
+func (r *ReconcileSonarQubeServer) newSnapshotJob(cr *sonarsourcev1alpha1.SonarQubeServer, name, script string, connection *utils.DatabaseConnection, running bool) (*batchv1.Job, error) {
+	snapshot := cr.Status.Snapshot
+	labels := r.snapshotLabels(cr, snapshot.Name)
+
+	image := utils.DefaultDatabaseImage
+	if cr.Spec.Snapshot != nil && cr.Spec.Snapshot.Image != nil {
+		image = *cr.Spec.Snapshot.Image
+	}
+
+	secretEnv := func(env, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: r.snapshotSecretName(cr)},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	dep := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cr.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &[]int32{0}[0],
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Volumes: []corev1.Volume{
+						{
+							Name: SnapshotVolume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: cr.Name,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "snapshot",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", script},
+							Env: []corev1.EnvVar{
+								{Name: "SNAPSHOT_NAME", Value: snapshot.Name},
+								{Name: "ARCHIVE_VOLUME", Value: fmt.Sprint(snapshot.VolumeSnapshot == "")},
+								{Name: "PGHOST", Value: connection.Host},
+								{Name: "PGPORT", Value: connection.Port},
+								{Name: "PGDATABASE", Value: connection.Database},
+								secretEnv("PGUSER", sonarsourcev1alpha1.DatabaseSecretUsername),
+								secretEnv("PGPASSWORD", sonarsourcev1alpha1.DatabaseSecretPassword),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: SnapshotVolume, MountPath: SnapshotVolumePath},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if running {
+		dep.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{sonarsourcev1alpha1.ServerTypeLabel: cr.Name},
+						},
+						TopologyKey: corev1.LabelHostname,
+					},
+				},
+			},
+		}
+	}
+
+	if err := controllerutil.SetControllerReference(cr, dep, r.scheme); err != nil {
+		return dep, err
+	}
+
+	return dep, nil
+}
+
+func (r *ReconcileSonarQubeServer) snapshotLabels(cr *sonarsourcev1alpha1.SonarQubeServer, name string) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range cr.Labels {
+		labels[k] = v
+	}
+
+	labels[sonarsourcev1alpha1.SnapshotLabel] = name
+	labels[sonarsourcev1alpha1.KubeAppInstance] = cr.Name
+	labels[sonarsourcev1alpha1.KubeAppManagedby] = r.Labels(cr)[sonarsourcev1alpha1.KubeAppManagedby]
+
+	return labels
+}
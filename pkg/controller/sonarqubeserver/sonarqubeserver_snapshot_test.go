@@ -0,0 +1,342 @@
+package sonarqubeserver
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// newSnapshotObjects returns a server upgrading from 8.4 to 8.5 with the Deployment, claim, and config secret of 8.4
+func newSnapshotObjects(name, namespace string, snapshot *sonarsourcev1alpha1.UpgradeSnapshot) (*sonarsourcev1alpha1.SonarQubeServer, []runtime.Object) {
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(name),
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Version:  &[]string{"8.5"}[0],
+			Snapshot: snapshot,
+		},
+		Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+			Version: "8.4",
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &[]int32{1}[0],
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "sonarqube",
+							Image: utils.GetImage(nil, &[]string{"8.4"}[0], nil),
+						},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas: 1,
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{{Name: name, UID: sonarqube.UID}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SecretName(name, nil),
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			utils.SonarPropertiesFile: []byte("sonar.jdbc.url=jdbc:postgresql://postgres/sonar\nsonar.jdbc.username=sonar\nsonar.jdbc.password=secret\n"),
+		},
+	}
+
+	return sonarqube, []runtime.Object{sonarqube, deployment, pvc, secret}
+}
+
+// setJobComplete reports the Job named name as completed
+func setJobComplete(t *testing.T, r *ReconcileSonarQubeServer, name, namespace string) *batchv1.Job {
+	job := &batchv1.Job{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, job)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:   batchv1.JobComplete,
+		Status: corev1.ConditionTrue,
+	}}
+	err = r.client.Status().Update(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	return job
+}
+
+// TestSonarQubeServerSnapshot runs ReconcileSonarQubeServer.ReconcileSnapshot() against a
+// fake client that tracks an upgrade with a VolumeSnapshot through its rollback
+func TestSonarQubeServerSnapshot(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name           = "sonarqube-operator"
+		namespace      = "sonarqube"
+		namespacedName = types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}
+	)
+
+	sonarqube, objs := newSnapshotObjects(name, namespace, &sonarsourcev1alpha1.UpgradeSnapshot{
+		VolumeSnapshotClass: &[]string{"csi"}[0],
+		Rollback:            &[]bool{true}[0],
+	})
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s}
+
+	err := r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Errorf("reconcileSnapshot: resource update error not returned when starting snapshot (%v)", err)
+	}
+	snapshot := sonarqube.Status.Snapshot
+	if snapshot == nil || snapshot.From != "8.4" || snapshot.To != "8.5" || snapshot.Phase != sonarsourcev1alpha1.SnapshotPhasePending {
+		t.Fatalf("reconcileSnapshot: pending snapshot from 8.4 to 8.5 not recorded in status %v", snapshot)
+	}
+
+	if snapshot.VolumeSnapshot == "" || snapshot.VolumeSnapshotClass != "csi" {
+		t.Fatalf("reconcileSnapshot: volume snapshot with class csi not recorded in status %v", snapshot)
+	}
+
+	// Secret and Job are created, then the Job is waited on before the volume is snapshot
+	for _, v := range []string{"Secret", "Job"} {
+		err = r.ReconcileSnapshot(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+			t.Errorf("reconcileSnapshot: resource create error not returned when creating %s", v)
+		}
+	}
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSnapshot: resource waiting error not returned when job has not completed")
+	}
+
+	job := setJobComplete(t, r, snapshot.Name, namespace)
+	for _, v := range job.Spec.Template.Spec.Containers[0].Env {
+		if v.Name == "ARCHIVE_VOLUME" && v.Value != "false" {
+			t.Error("reconcileSnapshot: volume is archived even though it has a volume snapshot")
+		}
+	}
+	if affinity := job.Spec.Template.Spec.Affinity; affinity == nil || affinity.PodAffinity == nil || len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) == 0 {
+		t.Error("reconcileSnapshot: snapshot job is not required to run next to the running server")
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Errorf("reconcileSnapshot: resource create error not returned when creating volume snapshot (%v)", err)
+	}
+
+	volumeSnapshot := utils.NewVolumeSnapshotObject()
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: snapshot.VolumeSnapshot, Namespace: namespace}, volumeSnapshot)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	if class, _, _ := unstructured.NestedString(volumeSnapshot.Object, "spec", "volumeSnapshotClassName"); class != "csi" {
+		t.Errorf("reconcileSnapshot: expected volume snapshot class csi got %s", class)
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSnapshot: resource waiting error not returned when volume snapshot is not ready")
+	}
+
+	err = unstructured.SetNestedField(volumeSnapshot.Object, true, "status", "readyToUse")
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	err = r.client.Update(context.TODO(), volumeSnapshot)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	if sonarqube.Status.Snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseReady || sonarqube.Status.Snapshot.Time == nil {
+		t.Errorf("reconcileSnapshot: expected phase %s got %s", sonarsourcev1alpha1.SnapshotPhaseReady, sonarqube.Status.Snapshot.Phase)
+	}
+
+	migrationErr := &utils.Error{Reason: utils.ErrorReasonServerMigrationFailed, Message: "database migration failed"}
+	err = r.rollbackSnapshot(sonarqube, migrationErr)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("rollbackSnapshot: resource update error not returned when starting rollback")
+	}
+	if sonarqube.Status.Snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseRollingBack {
+		t.Errorf("rollbackSnapshot: expected phase %s got %s", sonarsourcev1alpha1.SnapshotPhaseRollingBack, sonarqube.Status.Snapshot.Phase)
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSnapshot: resource update error not returned when stopping server")
+	}
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceWaiting {
+		t.Error("reconcileSnapshot: resource waiting error not returned when pods are terminating")
+	}
+
+	deployment := &appsv1.Deployment{}
+	err = r.client.Get(context.TODO(), namespacedName, deployment)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	deployment.Status.Replicas = 0
+	err = r.client.Status().Update(context.TODO(), deployment)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+
+	// The claim is replaced by a claim restored from the VolumeSnapshot
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Errorf("reconcileSnapshot: resource update error not returned when deleting claim (%v)", err)
+	}
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Errorf("reconcileSnapshot: resource create error not returned when restoring claim (%v)", err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = r.client.Get(context.TODO(), namespacedName, pvc)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Name != snapshot.VolumeSnapshot {
+		t.Errorf("reconcileSnapshot: claim not restored from volume snapshot %s", snapshot.VolumeSnapshot)
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+		t.Errorf("reconcileSnapshot: resource create error not returned when creating rollback job (%v)", err)
+	}
+	job = setJobComplete(t, r, snapshot.Name+"-rollback", namespace)
+	if job.Spec.Template.Spec.Affinity != nil {
+		t.Error("reconcileSnapshot: rollback job is required to run next to a stopped server")
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("reconcileSnapshot: resource update error not returned when rolled back")
+	}
+	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+	if sonarqube.Status.Version != "8.4" {
+		t.Errorf("reconcileSnapshot: expected status version 8.4 got %s", sonarqube.Status.Version)
+	}
+	if sonarqube.Spec.Version == nil || *sonarqube.Spec.Version != "8.5" {
+		t.Error("reconcileSnapshot: spec version changed by rollback")
+	}
+	if version := r.version(sonarqube); version == nil || *version != "8.4" {
+		t.Error("reconcileSnapshot: rolled back version not skipped")
+	}
+	if sonarqube.Status.Snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseRolledBack {
+		t.Errorf("reconcileSnapshot: expected phase %s got %s", sonarsourcev1alpha1.SnapshotPhaseRolledBack, sonarqube.Status.Snapshot.Phase)
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if err != nil {
+		t.Error("reconcileSnapshot: returned error even though no upgrade is pending")
+	}
+}
+
+// TestSonarQubeServerSnapshotArchive runs ReconcileSonarQubeServer.ReconcileSnapshot() against a
+// fake client without snapshot support
+func TestSonarQubeServerSnapshotArchive(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name      = "sonarqube-operator"
+		namespace = "sonarqube"
+	)
+
+	sonarqube, objs := newSnapshotObjects(name, namespace, &sonarsourcev1alpha1.UpgradeSnapshot{})
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s}
+
+	err := r.ReconcileSnapshot(sonarqube)
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Errorf("reconcileSnapshot: resource update error not returned when starting snapshot (%v)", err)
+	}
+	snapshot := sonarqube.Status.Snapshot
+	if snapshot == nil || snapshot.VolumeSnapshot != "" {
+		t.Fatal("reconcileSnapshot: volume snapshot recorded even though no volume snapshot class exists")
+	}
+
+	for _, v := range []string{"Secret", "Job"} {
+		err = r.ReconcileSnapshot(sonarqube)
+		if utils.ReasonForError(err) != utils.ErrorReasonResourceCreate {
+			t.Errorf("reconcileSnapshot: resource create error not returned when creating %s", v)
+		}
+	}
+
+	job := setJobComplete(t, r, snapshot.Name, namespace)
+	env := make(map[string]string)
+	for _, v := range job.Spec.Template.Spec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	for k, v := range map[string]string{
+		"ARCHIVE_VOLUME": "true",
+		"SNAPSHOT_NAME":  snapshot.Name,
+		"PGHOST":         "postgres",
+		"PGDATABASE":     "sonar",
+	} {
+		if env[k] != v {
+			t.Errorf("reconcileSnapshot: expected %s to be %s got %s", k, v, env[k])
+		}
+	}
+
+	err = r.ReconcileSnapshot(sonarqube)
+	if err != nil {
+		t.Fatalf("reconcileSnapshot: (%v)", err)
+	}
+
+	// A failed migration is returned as is when rollback is not enabled
+	migrationErr := &utils.Error{Reason: utils.ErrorReasonServerMigrationFailed, Message: "database migration failed"}
+	err = r.rollbackSnapshot(sonarqube, migrationErr)
+	if err != migrationErr || sonarqube.Status.Snapshot.Phase != sonarsourcev1alpha1.SnapshotPhaseReady {
+		t.Error("rollbackSnapshot: rolled back even though rollback is not enabled")
+	}
+}
@@ -0,0 +1,63 @@
+package sonarqubeserver
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Applies automatic upgrades for SonarQubeServer based on UpdatesMinor and UpdatesMajor
+// The upgrade is recorded in Status.Version, Spec.Version is left as written by the user
+// Returns: Error
+// If Error is non-nil, the resolved version was changed and the Deployment will be rolled
+// Errors:
+//   ErrorReasonResourceUpdate: returned when Status.Version was bumped to a newer compatible version
+//   ErrorReasonSpecInvalid: returned when the resolved version can not be parsed
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func (r *ReconcileSonarQubeServer) verifyAutoUpgrade(cr *sonarsourcev1alpha1.SonarQubeServer, status *api_client.Status) error {
+	if status == nil || status.Status != api_client.SystemUp || r.version(cr) == nil {
+		return nil
+	}
+
+	target, err := r.findUpgrade(cr)
+	if err != nil || target == "" {
+		return err
+	}
+
+	from := *r.version(cr)
+	newStatus := cr.DeepCopy()
+	newStatus.Status.Version = target
+	newStatus.Status.SpecVersion = utils.SpecVersion(cr.Spec.Version)
+	newStatus.Status.Upgrades.History = append(newStatus.Status.Upgrades.History, sonarsourcev1alpha1.UpgradeStep{
+		From: from,
+		To:   target,
+		Time: metav1.Now(),
+	})
+	utils.UpdateStatus(r.client, newStatus, cr)
+
+	return &utils.Error{
+		Reason:  utils.ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("upgrading from %s to %s", from, target),
+	}
+}
+
+// findUpgrade returns the newest compatible version allowed by the upgrade policy or an empty string
+func (r *ReconcileSonarQubeServer) findUpgrade(cr *sonarsourcev1alpha1.SonarQubeServer) (string, error) {
+	allowMinor := cr.Spec.UpdatesMinor != nil && *cr.Spec.UpdatesMinor
+	allowMajor := cr.Spec.UpdatesMajor != nil && *cr.Spec.UpdatesMajor
+
+	// A version that was rolled back after its database migration failed is not upgraded to again
+	compatible := cr.Status.Upgrades.Compatible
+	if snapshot := cr.Status.Snapshot; snapshot != nil && snapshot.Phase == sonarsourcev1alpha1.SnapshotPhaseRolledBack {
+		compatible = nil
+		for _, v := range cr.Status.Upgrades.Compatible {
+			if v != snapshot.To {
+				compatible = append(compatible, v)
+			}
+		}
+	}
+
+	return utils.FindUpgrade(*r.version(cr), compatible, allowMinor, allowMajor)
+}
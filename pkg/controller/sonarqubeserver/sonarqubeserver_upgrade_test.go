@@ -0,0 +1,167 @@
+package sonarqubeserver
+
+import (
+	"context"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"testing"
+)
+
+// TestSonarQubeServerFindUpgrade runs ReconcileSonarQubeServer.findUpgrade() against
+// combinations of upgrade policies
+func TestSonarQubeServerFindUpgrade(t *testing.T) {
+	compatible := []string{"8.3.1", "8.4", "9.0.1", "7.9"}
+
+	tests := []struct {
+		version  string
+		minor    bool
+		major    bool
+		expected string
+	}{
+		{"8.3", false, false, ""},
+		{"8.3", true, false, "8.4"},
+		{"8.3", false, true, "9.0.1"},
+		{"8.3", true, true, "9.0.1"},
+		{"8.4", true, false, ""},
+		{"9.0.1", true, true, ""},
+	}
+
+	r := &ReconcileSonarQubeServer{}
+	for _, test := range tests {
+		cr := &sonarsourcev1alpha1.SonarQubeServer{
+			Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+				Version:      &[]string{test.version}[0],
+				UpdatesMinor: &[]bool{test.minor}[0],
+				UpdatesMajor: &[]bool{test.major}[0],
+			},
+			Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+				Upgrades: sonarsourcev1alpha1.Upgrades{
+					Compatible: compatible,
+				},
+			},
+		}
+		target, err := r.findUpgrade(cr)
+		if err != nil {
+			t.Fatalf("findUpgrade: (%v)", err)
+		}
+		if target != test.expected {
+			t.Errorf("findUpgrade: version %s minor %v major %v got %s expected %s", test.version, test.minor, test.major, target, test.expected)
+		}
+	}
+
+	cr := &sonarsourcev1alpha1.SonarQubeServer{
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Version:      &[]string{"latest"}[0],
+			UpdatesMinor: &[]bool{true}[0],
+		},
+	}
+	if _, err := r.findUpgrade(cr); utils.ReasonForError(err) != utils.ErrorReasonSpecInvalid {
+		t.Error("findUpgrade: spec invalid error not returned for unparsable version")
+	}
+
+	cr = &sonarsourcev1alpha1.SonarQubeServer{
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Version:      &[]string{"8.3"}[0],
+			UpdatesMinor: &[]bool{true}[0],
+		},
+		Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+			Upgrades: sonarsourcev1alpha1.Upgrades{
+				Compatible: compatible,
+			},
+			Snapshot: &sonarsourcev1alpha1.UpgradeSnapshotStatus{
+				From:  "8.3",
+				To:    "8.4",
+				Phase: sonarsourcev1alpha1.SnapshotPhaseRolledBack,
+			},
+		},
+	}
+	if target, _ := r.findUpgrade(cr); target != "8.3.1" {
+		t.Errorf("findUpgrade: expected 8.3.1 after rollback of 8.4 got %s", target)
+	}
+}
+
+// TestSonarQubeServerAutoUpgrade runs ReconcileSonarQubeServer.verifyAutoUpgrade() against a
+// fake client
+func TestSonarQubeServerAutoUpgrade(t *testing.T) {
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(logf.ZapLogger(true))
+
+	var (
+		name           = "sonarqube-operator"
+		namespace      = "sonarqube"
+		namespacedName = types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}
+	)
+
+	// A SonarQubeServer resource with metadata and spec.
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: sonarsourcev1alpha1.SonarQubeServerSpec{
+			Version:      &[]string{"8.3"}[0],
+			UpdatesMinor: &[]bool{true}[0],
+		},
+		Status: sonarsourcev1alpha1.SonarQubeServerStatus{
+			Upgrades: sonarsourcev1alpha1.Upgrades{
+				Compatible: []string{"8.4"},
+			},
+		},
+	}
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		sonarqube,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, sonarqube)
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	// Create a ReconcileSonarQubeServer object with the scheme and fake client.
+	apiMock := &api_client.APIClientMock{}
+	r := &ReconcileSonarQubeServer{client: cl, scheme: s, apiClient: apiMock}
+
+	err := r.verifyAutoUpgrade(sonarqube, &api_client.Status{Status: api_client.SystemStarting})
+	if err != nil {
+		t.Error("verifyAutoUpgrade: upgraded while server is not up")
+	}
+
+	err = r.verifyAutoUpgrade(sonarqube, &api_client.Status{Status: api_client.SystemUp})
+	if utils.ReasonForError(err) != utils.ErrorReasonResourceUpdate {
+		t.Error("verifyAutoUpgrade: resource update error not returned when upgrading")
+	}
+	err = r.client.Get(context.TODO(), namespacedName, sonarqube)
+	if err != nil {
+		t.Fatalf("verifyAutoUpgrade: (%v)", err)
+	}
+	if sonarqube.Spec.Version == nil || *sonarqube.Spec.Version != "8.3" {
+		t.Error("verifyAutoUpgrade: spec version changed by upgrade")
+	}
+	if sonarqube.Status.Version != "8.4" || *r.version(sonarqube) != "8.4" {
+		t.Errorf("verifyAutoUpgrade: upgrade target not resolved from status, got %s", sonarqube.Status.Version)
+	}
+	if len(sonarqube.Status.Upgrades.History) != 1 || sonarqube.Status.Upgrades.History[0].From != "8.3" || sonarqube.Status.Upgrades.History[0].To != "8.4" {
+		t.Error("verifyAutoUpgrade: upgrade not recorded in status")
+	}
+
+	err = r.verifyAutoUpgrade(sonarqube, &api_client.Status{Status: api_client.SystemUp})
+	if err != nil {
+		t.Error("verifyAutoUpgrade: returned error even though server is on newest allowed version")
+	}
+
+	sonarqube.Spec.Version = &[]string{"8.2"}[0]
+	if version := r.version(sonarqube); version == nil || *version != "8.2" {
+		t.Errorf("version: changed spec version older than the resolved version ignored, got %v", version)
+	}
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// BackupTarget is the data and database of a SonarQubeServer or SonarQube that is backed up or restored
+type BackupTarget struct {
+	// SonarQubeServer or SonarQube
+	Kind string
+	Name string
+	// SonarQubeServer whose PersistentVolumeClaim holds the extensions, the first application node of a SonarQube
+	Server string
+	// PersistentVolumeClaim of Server
+	Claim string
+	// Server has pods, the claim can only be mounted next to them
+	Running bool
+	// Version of SonarQube reported by the server
+	Version string
+	// Database sonar.jdbc.url points to
+	Database *DatabaseConnection
+}
+
+// DatabaseConnection is a PostgreSQL database parsed from sonar.jdbc.* properties
+type DatabaseConnection struct {
+	Host     string
+	Port     string
+	Database string
+	Username string
+	Password string
+}
+
+// GetBackupTarget resolves the server and database of the SonarQubeServer or SonarQube referenced by ref
+// Errors:
+//   ErrorReasonSpecInvalid: returned when ref is a search node or the database is not PostgreSQL
+//   ErrorReasonResourceWaiting: returned when server, config secret, or sonar.jdbc.url does not exist
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetBackupTarget(c client.Client, namespace string, ref sonarsourcev1alpha1.ServerReference) (*BackupTarget, error) {
+	target := &BackupTarget{
+		Kind: sonarsourcev1alpha1.ServerKindSonarQubeServer,
+		Name: ref.Name,
+	}
+	if ref.Kind != nil {
+		target.Kind = *ref.Kind
+	}
+
+	var (
+		secret   string
+		database *sonarsourcev1alpha1.Database
+	)
+	switch target.Kind {
+	case sonarsourcev1alpha1.ServerKindSonarQubeServer:
+		server := &sonarsourcev1alpha1.SonarQubeServer{}
+		err := getServerResource(c, target.Kind, types.NamespacedName{Name: ref.Name, Namespace: namespace}, server)
+		if err != nil {
+			return target, err
+		}
+		if server.Spec.Type != nil && *server.Spec.Type == sonarsourcev1alpha1.Search {
+			return target, &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("%s %s is a search node", target.Kind, ref.Name),
+			}
+		}
+		target.Server, target.Claim = server.Name, server.Name
+		target.Version = server.Status.Version
+		if target.Version == "" {
+			target.Version = server.Status.ObservedVersion
+		}
+		secret, database = SecretName(server.Name, server.Spec.Secret), server.Spec.Database
+	case sonarsourcev1alpha1.ServerKindSonarQube:
+		sonarqube := &sonarsourcev1alpha1.SonarQube{}
+		err := getServerResource(c, target.Kind, types.NamespacedName{Name: ref.Name, Namespace: namespace}, sonarqube)
+		if err != nil {
+			return target, err
+		}
+		// Extensions are the same on all application nodes
+		target.Server = fmt.Sprintf("%s-%s-0", sonarqube.Name, sonarsourcev1alpha1.Application)
+		target.Claim = target.Server
+		target.Version = sonarqube.Status.Version
+		secret, database = SecretName(sonarqube.Name, sonarqube.Spec.Secret), sonarqube.Spec.Database
+	default:
+		return target, &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("server kind must be %s or %s", sonarsourcev1alpha1.ServerKindSonarQubeServer, sonarsourcev1alpha1.ServerKindSonarQube),
+		}
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: target.Server, Namespace: namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return target, err
+	} else if err == nil {
+		target.Running = deployment.Status.Replicas > 0
+	}
+
+	connection, err := GetDatabaseConnection(c, namespace, secret, database)
+	if err != nil {
+		return target, err
+	}
+	target.Database = connection
+
+	return target, nil
+}
+
+// GetDatabaseConnection parses the sonar.jdbc.* properties of the config secret, secret key references of database take precedence
+// The user and password parameters of sonar.jdbc.url are used when no username or password is set
+// Errors:
+//   ErrorReasonSpecInvalid: returned when sonar.jdbc.url is not a PostgreSQL url
+//   ErrorReasonResourceWaiting: returned when a secret, key, or sonar.jdbc.url does not exist
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetDatabaseConnection(c client.Client, namespace, secretName string, database *sonarsourcev1alpha1.Database) (*DatabaseConnection, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		return nil, &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on config secret %s", secretName),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if sonarProperties, err := GetProperties(secret, SonarPropertiesFile); err == nil {
+		for _, k := range []string{"sonar.jdbc.url", "sonar.jdbc.username", "sonar.jdbc.password"} {
+			if v, ok := sonarProperties.Get(k); ok {
+				values[k] = v
+			}
+		}
+	}
+
+	if database != nil {
+		for k, ref := range map[string]*corev1.SecretKeySelector{
+			"sonar.jdbc.url":      database.URLFrom,
+			"sonar.jdbc.username": database.UsernameFrom,
+			"sonar.jdbc.password": database.PasswordFrom,
+		} {
+			if ref == nil {
+				continue
+			}
+			v, err := getDatabaseSecretKey(c, namespace, ref)
+			if err != nil {
+				return nil, err
+			}
+			values[k] = v
+		}
+	}
+
+	if values["sonar.jdbc.url"] == "" {
+		return nil, &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on sonar.jdbc.url in config secret %s", secretName),
+		}
+	}
+
+	connection, err := ParseJDBCURL(values["sonar.jdbc.url"])
+	if err != nil {
+		return nil, err
+	}
+	if v := values["sonar.jdbc.username"]; v != "" {
+		connection.Username = v
+	}
+	if v := values["sonar.jdbc.password"]; v != "" {
+		connection.Password = v
+	}
+
+	return connection, nil
+}
+
+// ParseJDBCURL returns the host, port, database, and the user and password parameters of a jdbc:postgresql:// url
+// Errors:
+//   ErrorReasonSpecInvalid: returned when jdbcURL is not a PostgreSQL url
+func ParseJDBCURL(jdbcURL string) (*DatabaseConnection, error) {
+	invalidErr := &Error{
+		Reason:  ErrorReasonSpecInvalid,
+		Message: "only PostgreSQL databases can be backed up, sonar.jdbc.url must start with jdbc:postgresql://",
+	}
+	if !strings.HasPrefix(jdbcURL, "jdbc:postgresql://") {
+		return nil, invalidErr
+	}
+	u, err := url.Parse(strings.TrimPrefix(jdbcURL, "jdbc:"))
+	if err != nil || u.Hostname() == "" {
+		return nil, invalidErr
+	}
+
+	connection := &DatabaseConnection{
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		Database: strings.TrimPrefix(u.Path, "/"),
+		Username: u.Query().Get("user"),
+		Password: u.Query().Get("password"),
+	}
+	if connection.Port == "" {
+		connection.Port = fmt.Sprint(sonarsourcev1alpha1.DatabasePort)
+	}
+	return connection, nil
+}
+
+// BackupLocation returns the url of the directory backups of storage are written to
+func BackupLocation(storage sonarsourcev1alpha1.BackupStorage) string {
+	switch {
+	case storage.PersistentVolumeClaim != nil:
+		return fmt.Sprintf("pvc://%s", *storage.PersistentVolumeClaim)
+	case storage.S3 != nil:
+		return fmt.Sprintf("s3://%s", S3Path(storage.S3))
+	}
+	return ""
+}
+
+// S3Path returns the bucket and prefix backups are uploaded to
+func S3Path(s3 *sonarsourcev1alpha1.S3Storage) string {
+	if s3.Prefix == nil || strings.Trim(*s3.Prefix, "/") == "" {
+		return s3.Bucket
+	}
+	return fmt.Sprintf("%s/%s", s3.Bucket, strings.Trim(*s3.Prefix, "/"))
+}
+
+func getDatabaseSecretKey(c client.Client, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		return "", &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on database secret %s", ref.Name),
+		}
+	} else if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok && (ref.Optional == nil || !*ref.Optional) {
+		return "", &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on key %s of database secret %s", ref.Key, ref.Name),
+		}
+	}
+	return string(value), nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+)
+
+// TestParseJDBCURL checks the connection parsed from PostgreSQL urls
+func TestParseJDBCURL(t *testing.T) {
+	cases := map[string]struct {
+		url        string
+		connection DatabaseConnection
+		invalid    bool
+	}{
+		"default port": {
+			url:        "jdbc:postgresql://postgres/sonar",
+			connection: DatabaseConnection{Host: "postgres", Port: "5432", Database: "sonar"},
+		},
+		"credentials in query": {
+			url:        "jdbc:postgresql://postgres:5433/sonar?user=sonar&password=secret&ssl=true",
+			connection: DatabaseConnection{Host: "postgres", Port: "5433", Database: "sonar", Username: "sonar", Password: "secret"},
+		},
+		"not postgresql": {
+			url:     "jdbc:sqlserver://mssql;databaseName=sonar",
+			invalid: true,
+		},
+	}
+
+	for name, c := range cases {
+		connection, err := ParseJDBCURL(c.url)
+		if c.invalid {
+			if ReasonForError(err) != ErrorReasonSpecInvalid {
+				t.Errorf("parseJDBCURL: %s: spec invalid error not returned", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseJDBCURL: %s: (%v)", name, err)
+			continue
+		}
+		if *connection != c.connection {
+			t.Errorf("parseJDBCURL: %s: expected %v got %v", name, c.connection, *connection)
+		}
+	}
+}
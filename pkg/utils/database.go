@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type databaseSecretRef struct {
+	env string
+	ref *corev1.SecretKeySelector
+}
+
+// databaseSecretRefs returns the secret key references of database with the env var they are set as
+func databaseSecretRefs(database *sonarsourcev1alpha1.Database) []databaseSecretRef {
+	var output []databaseSecretRef
+	if database == nil {
+		return output
+	}
+	for _, v := range []databaseSecretRef{
+		{"SONAR_JDBC_URL", database.URLFrom},
+		{"SONAR_JDBC_USERNAME", database.UsernameFrom},
+		{"SONAR_JDBC_PASSWORD", database.PasswordFrom},
+	} {
+		if v.ref != nil {
+			output = append(output, v)
+		}
+	}
+	return output
+}
+
+// HasDatabaseSecretRefs returns true when database references secret keys
+func HasDatabaseSecretRefs(database *sonarsourcev1alpha1.Database) bool {
+	return len(databaseSecretRefs(database)) > 0
+}
+
+// DatabaseEnv returns the SONAR_JDBC_* env vars of the secret key references of database
+func DatabaseEnv(database *sonarsourcev1alpha1.Database) []corev1.EnvVar {
+	var output []corev1.EnvVar
+	for _, v := range databaseSecretRefs(database) {
+		output = append(output, corev1.EnvVar{
+			Name: v.env,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: v.ref,
+			},
+		})
+	}
+	return output
+}
+
+// GetDatabaseSecrets returns the values of the secret keys referenced by database
+// Referenced Secrets are annotated with annotation so changes requeue name
+// Errors:
+//   ErrorReasonResourceWaiting: returned when a referenced Secret or key does not exist
+//   ErrorReasonResourceUpdate: returned when a referenced Secret was annotated
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetDatabaseSecrets(c client.Client, namespace, annotation, name string, database *sonarsourcev1alpha1.Database) ([][]byte, error) {
+	var output [][]byte
+	for _, v := range databaseSecretRefs(database) {
+		secret := &corev1.Secret{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: v.ref.Name, Namespace: namespace}, secret)
+		if err != nil && errors.IsNotFound(err) {
+			return output, &Error{
+				Reason:  ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting on database secret %s", v.ref.Name),
+			}
+		} else if err != nil {
+			return output, err
+		}
+
+		if err := AddAnnotationValue(c, secret, annotation, name, fmt.Sprintf("updated database secret %s annotation", secret.Name)); err != nil {
+			return output, err
+		}
+
+		value, ok := secret.Data[v.ref.Key]
+		if !ok && (v.ref.Optional == nil || !*v.ref.Optional) {
+			return output, &Error{
+				Reason:  ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting on key %s of database secret %s", v.ref.Key, v.ref.Name),
+			}
+		}
+		output = append(output, value)
+	}
+	return output, nil
+}
+
+// ValidateDatabase returns ErrorReasonSpecInvalid when a secret key reference of database is incomplete
+func ValidateDatabase(database *sonarsourcev1alpha1.Database) error {
+	for _, v := range databaseSecretRefs(database) {
+		if v.ref.Name == "" || v.ref.Key == "" {
+			return &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("database secret reference of %s requires name and key", v.env),
+			}
+		}
+	}
+	if database != nil && database.Managed != nil && HasDatabaseSecretRefs(database) {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "database.managed can not be combined with urlFrom, usernameFrom, or passwordFrom",
+		}
+	}
+	return nil
+}
+
+// Reconciles Secret name owned by owner with the database credentials of connection for the Jobs of owner
+// The Secret is kept in sync with the server when the credentials are rotated
+// Returns: *Secret, Error
+// If Error is non-nil, Secret is not in expected state
+// Errors:
+//   ErrorReasonResourceCreate: returned when Secret does not exists
+//   ErrorReasonResourceUpdate: returned when Secret was updated to meet expected state
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func ReconcileCredentialsSecret(c client.Client, scheme *runtime.Scheme, owner metav1.Object, name string, labels map[string]string, connection *DatabaseConnection) (*corev1.Secret, error) {
+	newSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: owner.GetNamespace(),
+			Name:      name,
+			Labels:    labels,
+		},
+		Data: map[string][]byte{
+			sonarsourcev1alpha1.DatabaseSecretUsername: []byte(connection.Username),
+			sonarsourcev1alpha1.DatabaseSecretPassword: []byte(connection.Password),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+
+	if err := controllerutil.SetControllerReference(owner, newSecret, scheme); err != nil {
+		return newSecret, err
+	}
+
+	secret := &corev1.Secret{}
+	err := CreateResourceIfNotFound(c, newSecret, secret)
+	if err != nil {
+		return secret, err
+	}
+
+	if !reflect.DeepEqual(secret.Data, newSecret.Data) {
+		secret.Data = newSecret.Data
+		return secret, UpdateResource(c, secret, ErrorReasonResourceUpdate, fmt.Sprintf("updated database credentials of secret %s", name))
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+)
+
+const (
+	DefaultVolumeSize        = "1Gi"
+	DefaultDatabaseImage     = "postgres:12"
+	DefaultBackupUploadImage = "minio/mc:RELEASE.2020-10-03T02-54-56Z"
+	DefaultBackupRetention   = int32(7)
+)
+
+// DefaultSonarQube sets the defaults of SonarQube that are not set in its spec
+func DefaultSonarQube(cr *sonarsourcev1alpha1.SonarQube) {
+	if cr.Spec.Secret == nil {
+		cr.Spec.Secret = &[]string{SecretName(cr.Name, nil)}[0]
+	}
+
+	if cr.Spec.Edition == nil {
+		cr.Spec.Edition = &[]string{EditionDatacenter}[0]
+	}
+}
+
+// DefaultSonarQubeServer sets the defaults of SonarQubeServer that are not set in its spec
+func DefaultSonarQubeServer(cr *sonarsourcev1alpha1.SonarQubeServer) {
+	if cr.Spec.Secret == nil {
+		cr.Spec.Secret = &[]string{SecretName(cr.Name, nil)}[0]
+	}
+
+	if cr.Spec.Type == nil {
+		cr.Spec.Type = &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.AIO}[0]
+	}
+
+	if cr.Spec.Edition == nil {
+		cr.Spec.Edition = &[]string{DefaultEdition(cr.Spec.Type)}[0]
+	}
+
+	if cr.Spec.NodeConfig.StorageSize == nil {
+		cr.Spec.NodeConfig.StorageSize = &[]string{DefaultVolumeSize}[0]
+	}
+}
+
+// SecretName returns secret or the name of the config secret created for name when secret is not set
+func SecretName(name string, secret *string) string {
+	if secret != nil {
+		return *secret
+	}
+	return fmt.Sprintf("%s-config", name)
+}
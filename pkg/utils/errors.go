@@ -7,16 +7,18 @@ import (
 type ErrorType string
 
 const (
-	ErrorReasonSpecUpdate       ErrorType = "SpecUpdate"
-	ErrorReasonSpecInvalid      ErrorType = "SpecInvalid"
-	ErrorReasonResourceCreate   ErrorType = "ResourceCreate"
-	ErrorReasonResourceUpdate   ErrorType = "ResourceUpdate"
-	ErrorReasonResourceWaiting  ErrorType = "ResourceWaiting"
-	ErrorReasonResourceInvalid  ErrorType = "ResourceInvalid"
-	ErrorReasonResourceShutdown ErrorType = "ResourceShutdown"
-	ErrorReasonServerWaiting    ErrorType = "ServerWaiting"
-	ErrorReasonServerDown       ErrorType = "ServerDown"
-	ErrorReasonUnknown          ErrorType = "Unknown"
+	ErrorReasonSpecUpdate            ErrorType = "SpecUpdate"
+	ErrorReasonSpecInvalid           ErrorType = "SpecInvalid"
+	ErrorReasonResourceCreate        ErrorType = "ResourceCreate"
+	ErrorReasonResourceUpdate        ErrorType = "ResourceUpdate"
+	ErrorReasonResourceWaiting       ErrorType = "ResourceWaiting"
+	ErrorReasonResourceInvalid       ErrorType = "ResourceInvalid"
+	ErrorReasonResourceShutdown      ErrorType = "ResourceShutdown"
+	ErrorReasonServerWaiting         ErrorType = "ServerWaiting"
+	ErrorReasonServerDown            ErrorType = "ServerDown"
+	ErrorReasonServerMigrating       ErrorType = "ServerMigrating"
+	ErrorReasonServerMigrationFailed ErrorType = "ServerMigrationFailed"
+	ErrorReasonUnknown               ErrorType = "Unknown"
 )
 
 type SQError interface {
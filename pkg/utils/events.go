@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventReasonReconciled is recorded when a resource finished progressing or recovered from an invalid state
+	EventReasonReconciled = "Reconciled"
+)
+
+// EventTypeForError returns the type of the event recorded for err
+// Errors that need the user to act are recorded as warnings
+func EventTypeForError(err error) string {
+	switch ReasonForError(err) {
+	case ErrorReasonSpecInvalid, ErrorReasonResourceInvalid, ErrorReasonServerDown, ErrorReasonServerMigrationFailed, ErrorReasonUnknown:
+		return corev1.EventTypeWarning
+	default:
+		return corev1.EventTypeNormal
+	}
+}
+
+// RecordEventForError records an event for err on object, the reason of the event is the ErrorType of err
+// Repeated events are aggregated by the recorder, recorder may be nil when events are not recorded
+func RecordEventForError(recorder record.EventRecorder, object runtime.Object, err error) {
+	if recorder == nil || err == nil {
+		return
+	}
+
+	message := err.Error()
+	if sqErr, ok := err.(*Error); ok {
+		message = sqErr.Message
+	}
+
+	recorder.Event(object, EventTypeForError(err), string(ReasonForError(err)), message)
+}
+
+// RecordEventForReconciled records an event on object when conditions still report the previous reconcile as
+// progressing or invalid
+func RecordEventForReconciled(recorder record.EventRecorder, object runtime.Object, conditions status.Conditions, message string) {
+	if recorder == nil {
+		return
+	}
+
+	if conditions.IsTrueFor(sonarsourcev1alpha1.ConditionProgressing) || conditions.IsTrueFor(sonarsourcev1alpha1.ConditionInvalid) {
+		recorder.Event(object, corev1.EventTypeNormal, EventReasonReconciled, message)
+	}
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	IngressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+// RouteGroupVersionKind is the OpenShift Route kind, Routes are handled as unstructured objects so the operator
+// does not depend on the OpenShift api
+var RouteGroupVersionKind = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+
+// ExposeTLS returns true when SonarQube is served over https through expose
+func ExposeTLS(expose *sonarsourcev1alpha1.Expose) bool {
+	if expose.TLS != nil {
+		return *expose.TLS
+	}
+	return expose.TLSSecret != nil
+}
+
+// ExposeURL returns the url SonarQube is reachable on through expose
+func ExposeURL(expose *sonarsourcev1alpha1.Expose) string {
+	if ExposeTLS(expose) {
+		return fmt.Sprintf("https://%s", expose.Host)
+	}
+	return fmt.Sprintf("http://%s", expose.Host)
+}
+
+// NewRouteObject returns an empty Route that can be used as output of client.Get
+func NewRouteObject() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(RouteGroupVersionKind)
+	return route
+}
+
+// NewIngress returns the Ingress routing expose.Host to the web port of service
+func NewIngress(objectMeta metav1.ObjectMeta, expose *sonarsourcev1alpha1.Expose, service *corev1.Service) *networkingv1beta1.Ingress {
+	objectMeta.Annotations = make(map[string]string)
+	for k, v := range expose.Annotations {
+		objectMeta.Annotations[k] = v
+	}
+	if expose.IngressClass != nil {
+		objectMeta.Annotations[IngressClassAnnotation] = *expose.IngressClass
+	}
+
+	ingress := &networkingv1beta1.Ingress{
+		ObjectMeta: objectMeta,
+		Spec: networkingv1beta1.IngressSpec{
+			Rules: []networkingv1beta1.IngressRule{
+				{
+					Host: expose.Host,
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{
+						HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{
+								{
+									Path: "/",
+									Backend: networkingv1beta1.IngressBackend{
+										ServiceName: service.Name,
+										ServicePort: intstr.FromInt(int(sonarsourcev1alpha1.ApplicationWebPort)),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if ExposeTLS(expose) {
+		tls := networkingv1beta1.IngressTLS{
+			Hosts: []string{expose.Host},
+		}
+		if expose.TLSSecret != nil {
+			tls.SecretName = *expose.TLSSecret
+		}
+		ingress.Spec.TLS = []networkingv1beta1.IngressTLS{tls}
+	}
+
+	return ingress
+}
+
+// NewRoute returns the OpenShift Route routing expose.Host to the web port of service
+// When tlsSecret is non-nil its certificate and key are used for edge termination
+func NewRoute(objectMeta metav1.ObjectMeta, expose *sonarsourcev1alpha1.Expose, service *corev1.Service, tlsSecret *corev1.Secret) *unstructured.Unstructured {
+	route := NewRouteObject()
+	route.SetNamespace(objectMeta.Namespace)
+	route.SetName(objectMeta.Name)
+	route.SetLabels(objectMeta.Labels)
+	if len(expose.Annotations) > 0 {
+		route.SetAnnotations(expose.Annotations)
+	}
+
+	spec := map[string]interface{}{
+		"host": expose.Host,
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": service.Name,
+		},
+		"port": map[string]interface{}{
+			"targetPort": "web",
+		},
+	}
+
+	if ExposeTLS(expose) {
+		policy := "Redirect"
+		if expose.Route != nil && expose.Route.InsecureEdgeTerminationPolicy != nil {
+			policy = *expose.Route.InsecureEdgeTerminationPolicy
+		}
+		tls := map[string]interface{}{
+			"termination":                   "edge",
+			"insecureEdgeTerminationPolicy": policy,
+		}
+		if tlsSecret != nil {
+			tls["certificate"] = string(tlsSecret.Data[corev1.TLSCertKey])
+			tls["key"] = string(tlsSecret.Data[corev1.TLSPrivateKeyKey])
+		}
+		spec["tls"] = tls
+	}
+
+	route.Object["spec"] = spec
+
+	return route
+}
+
+// GetExposeTLSSecret returns the secret referenced by expose.TLSSecret or nil when not set
+// The Secret is annotated with annotation so certificate rotations requeue name
+// Errors:
+//   ErrorReasonResourceWaiting: returned when the Secret does not exist
+//   ErrorReasonResourceUpdate: returned when the Secret was annotated
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetExposeTLSSecret(c client.Client, namespace, annotation, name string, expose *sonarsourcev1alpha1.Expose) (*corev1.Secret, error) {
+	if expose.TLSSecret == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: *expose.TLSSecret, Namespace: namespace}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		return nil, &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on tls secret %s", *expose.TLSSecret),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := AddAnnotationValue(c, secret, annotation, name, fmt.Sprintf("updated tls secret %s annotation", secret.Name)); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// ValidateExpose returns ErrorReasonSpecInvalid when expose can not be applied
+func ValidateExpose(expose *sonarsourcev1alpha1.Expose) error {
+	if expose.Host == "" {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "expose requires host",
+		}
+	}
+
+	if expose.Route != nil && expose.IngressClass != nil {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "expose ingressClass can not be used with route",
+		}
+	}
+
+	if expose.Route != nil && expose.Route.InsecureEdgeTerminationPolicy != nil && !ContainsString([]string{"None", "Allow", "Redirect"}, *expose.Route.InsecureEdgeTerminationPolicy) {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "expose route insecureEdgeTerminationPolicy must be None, Allow, or Redirect",
+		}
+	}
+
+	return nil
+}
+
+// VerifyIngress updates ingress1 to match ingress2
+// Annotations that are not in ingress2 are left in place as they are commonly added by ingress controllers
+func VerifyIngress(client client.Client, ingress1, ingress2 *networkingv1beta1.Ingress) error {
+	if !reflect.DeepEqual(ingress2.Spec.Rules, ingress1.Spec.Rules) {
+		ingress1.Spec.Rules = ingress2.Spec.Rules
+		return UpdateResource(client, ingress1, ErrorReasonResourceUpdate, "updated ingress rules")
+	}
+
+	if !reflect.DeepEqual(ingress2.Spec.TLS, ingress1.Spec.TLS) {
+		ingress1.Spec.TLS = ingress2.Spec.TLS
+		return UpdateResource(client, ingress1, ErrorReasonResourceUpdate, "updated ingress tls")
+	}
+
+	if !annotationsContain(ingress1.Annotations, ingress2.Annotations) {
+		if ingress1.Annotations == nil {
+			ingress1.Annotations = make(map[string]string)
+		}
+		for k, v := range ingress2.Annotations {
+			ingress1.Annotations[k] = v
+		}
+		return UpdateResource(client, ingress1, ErrorReasonResourceUpdate, "updated ingress annotations")
+	}
+
+	if !reflect.DeepEqual(ingress2.Labels, ingress1.Labels) {
+		ingress1.Labels = ingress2.Labels
+		return UpdateResource(client, ingress1, ErrorReasonResourceUpdate, "updated ingress labels")
+	}
+
+	return nil
+}
+
+// VerifyRoute updates route1 to match route2
+// Only fields set by NewRoute are compared as the router defaults the remaining fields
+func VerifyRoute(client client.Client, route1, route2 *unstructured.Unstructured) error {
+	for _, field := range []string{"host", "to", "port", "tls"} {
+		expected, _, _ := unstructured.NestedFieldCopy(route2.Object, "spec", field)
+		found, _, _ := unstructured.NestedFieldCopy(route1.Object, "spec", field)
+		if fieldContains(found, expected) {
+			continue
+		}
+		if expected == nil {
+			unstructured.RemoveNestedField(route1.Object, "spec", field)
+		} else if err := unstructured.SetNestedField(route1.Object, expected, "spec", field); err != nil {
+			return err
+		}
+		return UpdateResource(client, route1, ErrorReasonResourceUpdate, fmt.Sprintf("updated route %s", field))
+	}
+
+	if !annotationsContain(route1.GetAnnotations(), route2.GetAnnotations()) {
+		annotations := route1.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		for k, v := range route2.GetAnnotations() {
+			annotations[k] = v
+		}
+		route1.SetAnnotations(annotations)
+		return UpdateResource(client, route1, ErrorReasonResourceUpdate, "updated route annotations")
+	}
+
+	if !reflect.DeepEqual(route2.GetLabels(), route1.GetLabels()) {
+		route1.SetLabels(route2.GetLabels())
+		return UpdateResource(client, route1, ErrorReasonResourceUpdate, "updated route labels")
+	}
+
+	return nil
+}
+
+// DeleteResourceIfOwned deletes the object named name when it exists and is controlled by owner
+// Missing objects and kinds that are not served by the cluster are ignored
+func DeleteResourceIfOwned(c client.Client, owner metav1.Object, kind string, name types.NamespacedName, object runtime.Object) error {
+	err := c.Get(context.TODO(), name, object)
+	if err != nil && (errors.IsNotFound(err) || meta.IsNoMatchError(err)) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !IsOwner(owner, object.(metav1.Object)) {
+		return nil
+	}
+
+	err = c.Delete(context.TODO(), object)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	return &Error{
+		Reason:  ErrorReasonResourceUpdate,
+		Message: fmt.Sprintf("deleted %s %s", kind, name.Name),
+	}
+}
+
+// fieldContains returns true when every value set in expected is set to the same value in found
+func fieldContains(found, expected interface{}) bool {
+	expectedMap, ok := expected.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(found, expected)
+	}
+	foundMap, ok := found.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, v := range expectedMap {
+		if !fieldContains(foundMap[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func annotationsContain(annotations, expected map[string]string) bool {
+	for k, v := range expected {
+		if value, ok := annotations[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/magiconair/properties"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+const (
+	SonarPropertiesFile = "sonar.properties"
+)
+
+// ManagedProperties are sonar.properties entries set by the operator, entries ending with a dot are prefixes
+var ManagedProperties = []string{"sonar.cluster.", "sonar.web.port", "sonar.path.", "sonar.search.host", "sonar.search.port"}
+
+// ValidateProperties returns ErrorReasonSpecInvalid when properties set an entry managed by the operator
+func ValidateProperties(props map[string]string) error {
+	for k := range props {
+		if IsManagedProperty(k) {
+			return &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("property %s is managed by the operator", k),
+			}
+		}
+	}
+	return nil
+}
+
+// IsManagedProperty returns true when key is set by the operator and can not be set in properties
+func IsManagedProperty(key string) bool {
+	for _, v := range ManagedProperties {
+		if key == v || (strings.HasSuffix(v, ".") && strings.HasPrefix(key, v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPropertiesFrom merges the entries of sources with props, props take precedence over sources
+// Referenced ConfigMaps and Secrets are annotated with annotation so changes requeue name
+// Errors:
+//   ErrorReasonSpecInvalid: returned when an entry is managed by the operator
+//   ErrorReasonResourceWaiting: returned when a source does not exist
+//   ErrorReasonResourceUpdate: returned when a source was annotated
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetPropertiesFrom(c client.Client, namespace, annotation, name string, sources []sonarsourcev1alpha1.PropertiesSource, props map[string]string) (map[string]string, error) {
+	output := make(map[string]string)
+
+	for _, v := range sources {
+		var data map[string]string
+		var object metav1.Object
+		var kind, sourceName string
+		switch {
+		case v.ConfigMapRef != nil:
+			configMap := &corev1.ConfigMap{}
+			kind, sourceName, object = "configmap", v.ConfigMapRef.Name, configMap
+			err := getPropertiesSource(c, kind, types.NamespacedName{Name: sourceName, Namespace: namespace}, configMap)
+			if err != nil {
+				return output, err
+			}
+			data = configMap.Data
+		case v.SecretRef != nil:
+			secret := &corev1.Secret{}
+			kind, sourceName, object = "secret", v.SecretRef.Name, secret
+			err := getPropertiesSource(c, kind, types.NamespacedName{Name: sourceName, Namespace: namespace}, secret)
+			if err != nil {
+				return output, err
+			}
+			data = make(map[string]string)
+			for k, v := range secret.Data {
+				data[k] = string(v)
+			}
+		default:
+			return output, &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: "propertiesFrom requires configMapRef or secretRef",
+			}
+		}
+
+		if err := ValidateProperties(data); err != nil {
+			return output, &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("%s %s: %s", kind, sourceName, err.(*Error).Message),
+			}
+		}
+
+		if err := AddAnnotationValue(c, object, annotation, name, fmt.Sprintf("updated %s %s annotation", kind, sourceName)); err != nil {
+			return output, err
+		}
+
+		for k, v := range data {
+			output[k] = v
+		}
+	}
+
+	if err := ValidateProperties(props); err != nil {
+		return output, err
+	}
+	for k, v := range props {
+		output[k] = v
+	}
+
+	return output, nil
+}
+
+func getPropertiesSource(c client.Client, kind string, name types.NamespacedName, object runtime.Object) error {
+	err := c.Get(context.TODO(), name, object)
+	if err != nil && errors.IsNotFound(err) {
+		return &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on properties %s %s", kind, name.Name),
+		}
+	}
+	return err
+}
+
+// RenderProperties sets rendered as sonar.properties entries of secret
+// Entries rendered before that are no longer in rendered are removed, other entries of the secret are left in place
+// Errors:
+//   ErrorReasonSpecInvalid: returned when rendered is not empty and secret is not owned by owner or can not be parsed
+//   ErrorReasonResourceUpdate: returned when secret was updated with rendered
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func RenderProperties(c client.Client, owner metav1.Object, secret *corev1.Secret, rendered map[string]string) error {
+	// Don't make changes to unowned resources
+	if !IsOwner(owner, secret) {
+		if len(rendered) == 0 {
+			return nil
+		}
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("properties can only be rendered into a secret managed by the operator, %s is not", secret.Name),
+		}
+	}
+
+	previous := make([]string, 0)
+	if v, ok := secret.GetAnnotations()[sonarsourcev1alpha1.PropertiesAnnotation]; ok && v != "" {
+		previous = strings.Split(v, ",")
+	}
+	if len(rendered) == 0 && len(previous) == 0 {
+		return nil
+	}
+
+	sonarProperties := properties.NewProperties()
+	sonarProperties.DisableExpansion = true
+	if err := sonarProperties.Load(secret.Data[SonarPropertiesFile], properties.UTF8); err != nil {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("%s in secret %s can not be parsed (%s)", SonarPropertiesFile, secret.Name, err.Error()),
+		}
+	}
+
+	keys := make([]string, 0, len(rendered))
+	for k := range rendered {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changed := strings.Join(keys, ",") != strings.Join(previous, ",")
+	for _, k := range previous {
+		if _, ok := rendered[k]; !ok {
+			sonarProperties.Delete(k)
+		}
+	}
+	for _, k := range keys {
+		if v, ok := sonarProperties.Get(k); !ok || v != rendered[k] {
+			if _, _, err := sonarProperties.Set(k, rendered[k]); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := sonarProperties.Write(buf, properties.UTF8); err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[SonarPropertiesFile] = buf.Bytes()
+
+	annotations := secret.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[sonarsourcev1alpha1.PropertiesAnnotation] = strings.Join(keys, ",")
+	secret.SetAnnotations(annotations)
+
+	return UpdateResource(c, secret, ErrorReasonResourceUpdate, fmt.Sprintf("rendered properties into secret %s", secret.Name))
+}
+
+// AddAnnotationValue adds value to the comma separated list in annotation of object
+// Errors:
+//   ErrorReasonResourceUpdate: returned when object was updated with value
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func AddAnnotationValue(c client.Client, object metav1.Object, annotation, value, message string) error {
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	val, ok := annotations[annotation]
+	if ok && ContainsString(strings.Split(val, ","), value) {
+		return nil
+	} else if ok {
+		annotations[annotation] = fmt.Sprintf("%s,%s", val, value)
+	} else {
+		annotations[annotation] = value
+	}
+	object.SetAnnotations(annotations)
+	return UpdateResource(c, object.(runtime.Object), ErrorReasonResourceUpdate, message)
+}
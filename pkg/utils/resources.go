@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"fmt"
 	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"reflect"
@@ -99,3 +101,29 @@ func VerifyService(client client.Client, service1, service2 *corev1.Service) err
 
 	return nil
 }
+
+// VerifyJob waits for job to complete, description names the job in the returned error
+// Errors:
+//   ErrorReasonResourceInvalid: returned when job failed
+//   ErrorReasonResourceWaiting: returned when job did not complete yet
+func VerifyJob(job *batchv1.Job, description string) error {
+	for _, v := range job.Status.Conditions {
+		if v.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch v.Type {
+		case batchv1.JobFailed:
+			return &Error{
+				Reason:  ErrorReasonResourceInvalid,
+				Message: fmt.Sprintf("%s %s failed: %s", description, job.Name, v.Message),
+			}
+		case batchv1.JobComplete:
+			return nil
+		}
+	}
+
+	return &Error{
+		Reason:  ErrorReasonResourceWaiting,
+		Message: fmt.Sprintf("waiting for %s %s to complete", description, job.Name),
+	}
+}
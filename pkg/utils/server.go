@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// Resolves base url and admin credentials of the SonarQubeServer or SonarQube referenced by ref
+// Returns: URL, Auth, Error
+// Errors:
+//   ErrorReasonSpecInvalid: returned when ref can not be used for admin api calls
+//   ErrorReasonResourceWaiting: returned when server, service, or admin token is not ready
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func GetServerConnection(c client.Client, namespace string, ref sonarsourcev1alpha1.ServerReference) (string, *api_client.Auth, error) {
+	var (
+		adminSecret, externalURL *string
+		serviceName              string
+	)
+
+	kind := sonarsourcev1alpha1.ServerKindSonarQubeServer
+	if ref.Kind != nil {
+		kind = *ref.Kind
+	}
+
+	switch kind {
+	case sonarsourcev1alpha1.ServerKindSonarQubeServer:
+		server := &sonarsourcev1alpha1.SonarQubeServer{}
+		err := getServerResource(c, kind, types.NamespacedName{Name: ref.Name, Namespace: namespace}, server)
+		if err != nil {
+			return "", nil, err
+		}
+		if server.Spec.Type != nil && *server.Spec.Type == sonarsourcev1alpha1.Search {
+			return "", nil, &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("%s %s is a search node", kind, ref.Name),
+			}
+		}
+		if (server.Spec.Shutdown != nil && *server.Spec.Shutdown) || server.Status.ObservedVersion == "" || !isReady(server.Status.Conditions) {
+			return "", nil, &Error{
+				Reason:  ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for %s %s to be ready", kind, ref.Name),
+			}
+		}
+		adminSecret, externalURL, serviceName = server.Spec.AdminSecret, server.Spec.ExternalURL, server.Status.Service
+	case sonarsourcev1alpha1.ServerKindSonarQube:
+		sonarqube := &sonarsourcev1alpha1.SonarQube{}
+		err := getServerResource(c, kind, types.NamespacedName{Name: ref.Name, Namespace: namespace}, sonarqube)
+		if err != nil {
+			return "", nil, err
+		}
+		if (sonarqube.Spec.Shutdown != nil && *sonarqube.Spec.Shutdown) || !isReady(sonarqube.Status.Conditions) {
+			return "", nil, &Error{
+				Reason:  ErrorReasonResourceWaiting,
+				Message: fmt.Sprintf("waiting for %s %s to be ready", kind, ref.Name),
+			}
+		}
+		adminSecret, serviceName = sonarqube.Spec.AdminSecret, sonarqube.Status.Service
+	default:
+		return "", nil, &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("server kind must be %s or %s", sonarsourcev1alpha1.ServerKindSonarQubeServer, sonarsourcev1alpha1.ServerKindSonarQube),
+		}
+	}
+
+	if adminSecret == nil {
+		return "", nil, &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("%s %s has no admin secret", kind, ref.Name),
+		}
+	}
+
+	url, err := ServerURL(c, namespace, kind, ref.Name, externalURL, serviceName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret := &corev1.Secret{}
+	err = c.Get(context.TODO(), types.NamespacedName{Name: *adminSecret, Namespace: namespace}, secret)
+	if err != nil && errors.IsNotFound(err) {
+		return "", nil, &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting on admin secret %s", *adminSecret),
+		}
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	if token, ok := secret.Data[sonarsourcev1alpha1.AdminSecretToken]; ok && len(token) > 0 {
+		return url, &api_client.Auth{Token: string(token)}, nil
+	}
+
+	// Secrets created by the operator only hold the bootstrap password until a token is stored
+	if metav1.GetControllerOf(secret) != nil || len(secret.Data[sonarsourcev1alpha1.AdminSecretUsername]) == 0 || len(secret.Data[sonarsourcev1alpha1.AdminSecretPassword]) == 0 {
+		return "", nil, &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for admin token in secret %s", *adminSecret),
+		}
+	}
+
+	return url, &api_client.Auth{
+		Username: string(secret.Data[sonarsourcev1alpha1.AdminSecretUsername]),
+		Password: string(secret.Data[sonarsourcev1alpha1.AdminSecretPassword]),
+	}, nil
+}
+
+// Resolves base url of server name of kind from externalURL or the cluster ip of its service
+// Returns: URL, Error
+// Errors:
+//   ErrorReasonResourceWaiting: returned when service is not ready
+//   ErrorReasonUnknown: returned when unhandled error from client occurs
+func ServerURL(c client.Client, namespace, kind, name string, externalURL *string, serviceName string) (string, error) {
+	if externalURL != nil {
+		return *externalURL, nil
+	}
+
+	waitingErr := &Error{
+		Reason:  ErrorReasonResourceWaiting,
+		Message: fmt.Sprintf("waiting on service for %s %s", kind, name),
+	}
+	if serviceName == "" {
+		return "", waitingErr
+	}
+	service := &corev1.Service{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: namespace}, service)
+	if err != nil && errors.IsNotFound(err) {
+		return "", waitingErr
+	} else if err != nil {
+		return "", err
+	}
+	if service.Spec.ClusterIP == "" || len(service.Spec.Ports) == 0 {
+		return "", waitingErr
+	}
+
+	return fmt.Sprintf("http://%s:%v", service.Spec.ClusterIP, service.Spec.Ports[0].Port), nil
+}
+
+func getServerResource(c client.Client, kind string, name types.NamespacedName, output runtime.Object) error {
+	err := c.Get(context.TODO(), name, output)
+	if err != nil && errors.IsNotFound(err) {
+		return &Error{
+			Reason:  ErrorReasonResourceWaiting,
+			Message: fmt.Sprintf("waiting for %s %s to exist", kind, name.Name),
+		}
+	}
+
+	return err
+}
+
+// isReady returns true when conditions were reported and none is progressing, invalid or shutdown
+func isReady(conditions status.Conditions) bool {
+	return len(conditions) > 0 &&
+		!conditions.IsTrueFor(sonarsourcev1alpha1.ConditionProgressing) &&
+		!conditions.IsTrueFor(sonarsourcev1alpha1.ConditionInvalid) &&
+		!conditions.IsTrueFor(sonarsourcev1alpha1.ConditionShutdown)
+}
+
+// ServerName returns the name of the i-th SonarQubeServer of component created for the SonarQube cluster named cluster
+func ServerName(cluster string, component sonarsourcev1alpha1.ServerType, i int32) string {
+	return fmt.Sprintf("%s-%s-%v", cluster, component, i)
+}
+
+// FindUpgrade returns the newest version of compatible allowed by the upgrade policy or an empty string
+// Minor and patch upgrades require allowMinor, upgrades to a new major version require allowMajor
+func FindUpgrade(version string, compatible []string, allowMinor, allowMajor bool) (string, error) {
+	if !allowMinor && !allowMajor {
+		return "", nil
+	}
+
+	current, err := api_client.ParseSystemVersion(version)
+	if err != nil {
+		return "", &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("unable to parse version %s (%s)", version, err.Error()),
+		}
+	}
+
+	var target string
+	var newest *api_client.SystemVersion
+	for _, v := range compatible {
+		candidate, err := api_client.ParseSystemVersion(v)
+		if err != nil || candidate.Compare(current) <= 0 {
+			continue
+		}
+		if candidate.Major == current.Major && !allowMinor {
+			continue
+		}
+		if candidate.Major != current.Major && !allowMajor {
+			continue
+		}
+		if newest == nil || candidate.Compare(newest) > 0 {
+			newest = candidate
+			target = v
+		}
+	}
+
+	return target, nil
+}
+
+// ResolveVersion returns the version the image of a server is built from, the newer of the version set in the spec
+// and the version resolved in status. A spec version that changed from observed, the spec version recorded with the
+// resolved version by SpecVersion, is returned even when it is older. The spec version is skipped when it equals
+// skip, nil is returned when neither is set
+func ResolveVersion(version, observed *string, resolved, skip string) *string {
+	if version != nil && *version == skip {
+		version = nil
+	}
+	if resolved == "" {
+		return version
+	}
+	if version != nil && (observed != nil && *observed != *version || VersionNewer(*version, resolved)) {
+		return version
+	}
+	return &resolved
+}
+
+// SpecVersion returns version to be recorded as the observed spec version of ResolveVersion, an empty string when
+// version is not set
+func SpecVersion(version *string) *string {
+	if version == nil {
+		return &[]string{""}[0]
+	}
+	return &[]string{*version}[0]
+}
+
+// VersionNewer returns true when version is newer than current, versions that can not be parsed are handled as newer
+// so they are applied and reported by validation
+func VersionNewer(version, current string) bool {
+	newer, err := api_client.ParseSystemVersion(version)
+	if err != nil {
+		return version != current
+	}
+	old, err := api_client.ParseSystemVersion(current)
+	if err != nil {
+		return version != current
+	}
+	return newer.Compare(old) > 0
+}
+
+// ImageVersion returns the version in the tag of an image created by GetImage or an empty string for images without
+// a version
+func ImageVersion(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i < 0 {
+		return ""
+	}
+	version := strings.SplitN(image[i+1:], "-", 2)[0]
+	if _, err := api_client.ParseSystemVersion(version); err != nil {
+		return ""
+	}
+	return version
+}
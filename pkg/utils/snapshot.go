@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultStorageClassAnnotation        = "storageclass.kubernetes.io/is-default-class"
+	DefaultVolumeSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+// VolumeSnapshotGroupVersionKind is the CSI VolumeSnapshot kind, VolumeSnapshots are handled as unstructured objects
+// so the operator runs on clusters without the snapshot CRDs
+var VolumeSnapshotGroupVersionKind = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1beta1", Kind: "VolumeSnapshot"}
+
+// VolumeSnapshotClassListGroupVersionKind is the list of CSI VolumeSnapshotClasses
+var VolumeSnapshotClassListGroupVersionKind = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1beta1", Kind: "VolumeSnapshotClassList"}
+
+// NewVolumeSnapshotObject returns an empty VolumeSnapshot that can be used as output of client.Get
+func NewVolumeSnapshotObject() *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(VolumeSnapshotGroupVersionKind)
+	return snapshot
+}
+
+// NewVolumeSnapshot returns the VolumeSnapshot of the PersistentVolumeClaim claim taken with volumeSnapshotClass
+func NewVolumeSnapshot(objectMeta metav1.ObjectMeta, volumeSnapshotClass, claim string) *unstructured.Unstructured {
+	snapshot := NewVolumeSnapshotObject()
+	snapshot.SetNamespace(objectMeta.Namespace)
+	snapshot.SetName(objectMeta.Name)
+	snapshot.SetLabels(objectMeta.Labels)
+
+	snapshot.Object["spec"] = map[string]interface{}{
+		"volumeSnapshotClassName": volumeSnapshotClass,
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": claim,
+		},
+	}
+
+	return snapshot
+}
+
+// VolumeSnapshotReady returns true when the snapshot controller reports snapshot as ready to use
+// Errors:
+//   ErrorReasonResourceInvalid: returned when the snapshot controller reports an error for snapshot
+func VolumeSnapshotReady(snapshot *unstructured.Unstructured) (bool, error) {
+	if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && message != "" {
+		return false, &Error{
+			Reason:  ErrorReasonResourceInvalid,
+			Message: fmt.Sprintf("volume snapshot %s failed: %s", snapshot.GetName(), message),
+		}
+	}
+
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready, nil
+}
+
+// FindVolumeSnapshotClass returns the VolumeSnapshotClass whose driver provisions the StorageClass of claim
+// The class annotated as default is preferred when several match
+// An empty string is returned when the claim can not be snapshot, snapshot CRDs that are not installed or can not
+// be read by the operator are handled as not supported
+func FindVolumeSnapshotClass(c client.Client, namespace, claim string) (string, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: claim, Namespace: namespace}, pvc)
+	if err != nil {
+		return "", err
+	}
+
+	storageClass, err := getStorageClass(c, pvc.Spec.StorageClassName)
+	if err != nil || storageClass == nil {
+		return "", err
+	}
+
+	classes := &unstructured.UnstructuredList{}
+	classes.SetGroupVersionKind(VolumeSnapshotClassListGroupVersionKind)
+	err = c.List(context.TODO(), classes)
+	if err != nil && snapshotsUnsupported(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	var found string
+	for _, v := range classes.Items {
+		if driver, _, _ := unstructured.NestedString(v.Object, "driver"); driver != storageClass.Provisioner {
+			continue
+		}
+		if v.GetAnnotations()[DefaultVolumeSnapshotClassAnnotation] == "true" {
+			return v.GetName(), nil
+		}
+		if found == "" {
+			found = v.GetName()
+		}
+	}
+
+	return found, nil
+}
+
+// getStorageClass returns the StorageClass named name or the default StorageClass when name is nil
+func getStorageClass(c client.Client, name *string) (*storagev1.StorageClass, error) {
+	if name != nil && *name != "" {
+		storageClass := &storagev1.StorageClass{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: *name}, storageClass)
+		if err != nil && snapshotsUnsupported(err) {
+			return nil, nil
+		}
+		return storageClass, err
+	}
+
+	storageClasses := &storagev1.StorageClassList{}
+	err := c.List(context.TODO(), storageClasses)
+	if err != nil && snapshotsUnsupported(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for i, v := range storageClasses.Items {
+		if v.Annotations[DefaultStorageClassAnnotation] == "true" {
+			return &storageClasses.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func snapshotsUnsupported(err error) bool {
+	return meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) || errors.IsNotFound(err) || errors.IsForbidden(err)
+}
@@ -19,12 +19,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
 	DefaultImage = "sonarqube"
+	// ResyncPeriod is how often resources that live on the SonarQube server are checked for changes made outside
+	// the operator
+	ResyncPeriod = 10 * time.Minute
 )
 
 var log = logf.Log.WithName("controller_sonarqube")
@@ -68,6 +72,7 @@ func GetDeploymentCondition(deployment *appsv1.Deployment, condition appsv1.Depl
 	return corev1.ConditionUnknown
 }
 
+// UpdateResource updates object and returns an error with reason, the kind and name of object are appended to message
 func UpdateResource(client client.Writer, object runtime.Object, reason ErrorType, message string) error {
 	err := client.Update(context.TODO(), object)
 	if err != nil {
@@ -75,8 +80,16 @@ func UpdateResource(client client.Writer, object runtime.Object, reason ErrorTyp
 	}
 	return &Error{
 		Reason:  reason,
-		Message: message,
+		Message: fmt.Sprintf("%s (%s %s)", message, KindForObject(object), object.(metav1.Object).GetName()),
+	}
+}
+
+// KindForObject returns the kind of object, typed objects read from the client do not have their kind set
+func KindForObject(object runtime.Object) string {
+	if kind := object.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
 	}
+	return reflect.Indirect(reflect.ValueOf(object)).Type().Name()
 }
 
 func CreateResourceIfNotFound(client client.Client, object, output runtime.Object) error {
@@ -89,7 +102,7 @@ func CreateResourceIfNotFound(client client.Client, object, output runtime.Objec
 		}
 		return &Error{
 			Reason:  ErrorReasonResourceCreate,
-			Message: fmt.Sprintf("created %s %s", object.GetObjectKind().GroupVersionKind().Kind, metaObject.GetName()),
+			Message: fmt.Sprintf("created %s %s", KindForObject(object), metaObject.GetName()),
 		}
 	} else if err != nil {
 		return err
@@ -98,13 +111,75 @@ func CreateResourceIfNotFound(client client.Client, object, output runtime.Objec
 	return nil
 }
 
+// SetAvailableCondition sets Available and its inverse Unavailable
+func SetAvailableCondition(conditions *status.Conditions, available bool, message string) {
+	availableStatus, unavailableStatus := corev1.ConditionTrue, corev1.ConditionFalse
+	reason := sonarsourcev1alpha1.ConditionDeploymentAvailable
+	if !available {
+		availableStatus, unavailableStatus = corev1.ConditionFalse, corev1.ConditionTrue
+		reason = sonarsourcev1alpha1.ConditionDeploymentUnavailable
+	}
+
+	conditions.SetCondition(status.Condition{
+		Type:    sonarsourcev1alpha1.ConditionAvailable,
+		Status:  availableStatus,
+		Reason:  reason,
+		Message: message,
+	})
+	conditions.SetCondition(status.Condition{
+		Type:    sonarsourcev1alpha1.ConditionUnavailable,
+		Status:  unavailableStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// SetReadyCondition reports that the spec was applied and the application is up
+func SetReadyCondition(conditions *status.Conditions, reason status.ConditionReason, message string) {
+	conditions.SetCondition(status.Condition{
+		Type:    sonarsourcev1alpha1.ConditionReady,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// ServerPhaseForError returns the phase of a SonarQubeServer whose reconcile returned err
+func ServerPhaseForError(err error) sonarsourcev1alpha1.ServerPhase {
+	if err == nil {
+		return sonarsourcev1alpha1.ServerPhaseRunning
+	}
+
+	switch ReasonForError(err) {
+	case ErrorReasonSpecInvalid, ErrorReasonResourceInvalid, ErrorReasonServerMigrationFailed:
+		return sonarsourcev1alpha1.ServerPhaseInvalid
+	case ErrorReasonServerMigrating:
+		return sonarsourcev1alpha1.ServerPhaseMigrating
+	case ErrorReasonResourceShutdown:
+		return sonarsourcev1alpha1.ServerPhaseShutdown
+	default:
+		return sonarsourcev1alpha1.ServerPhaseProgressing
+	}
+}
+
 func ClearConditions(conditions status.Conditions) status.Conditions {
+	// Phases of a restore are only set once they completed and are kept, readiness is set by the reconcilers
+	excluded := []status.ConditionType{
+		sonarsourcev1alpha1.ConditionReady,
+		sonarsourcev1alpha1.ConditionAvailable,
+		sonarsourcev1alpha1.ConditionUnavailable,
+		sonarsourcev1alpha1.ConditionServerStopped,
+		sonarsourcev1alpha1.ConditionDataRestored,
+		sonarsourcev1alpha1.ConditionServerStarted,
+	}
+
 	var cList []status.ConditionType
+conditions:
 	for _, c := range conditions {
 		// Filter out excluded condition types
-		for _, e := range []status.ConditionType{sonarsourcev1alpha1.ConditionUnavailable} {
+		for _, e := range excluded {
 			if e == c.Type {
-				break
+				continue conditions
 			}
 		}
 		cList = append(cList, c.Type)
@@ -126,21 +201,58 @@ func ParseErrorForReconcileResult(client client.Client, object interface{}, err
 	reqLogger := log.WithValues("SonarQube.Namespace", objectMeta.GetNamespace(), "SonarQube.Name", objectMeta.GetName())
 	newStatus := objectRuntime.DeepCopyObject()
 	var statusConditions *status.Conditions
+	// Ready is only reported by kinds that run SonarQube
+	var reportsReady bool
 	switch t := newStatus.(type) {
 	case *sonarsourcev1alpha1.SonarQubeServer:
 		statusConditions = &t.Status.Conditions
+		reportsReady = true
+		t.Status.Phase = ServerPhaseForError(err)
 	case *sonarsourcev1alpha1.SonarQube:
 		statusConditions = &t.Status.Conditions
+		reportsReady = true
+	case *sonarsourcev1alpha1.SonarQubeProject:
+		statusConditions = &t.Status.Conditions
+	case *sonarsourcev1alpha1.SonarQubeQualityGate:
+		statusConditions = &t.Status.Conditions
+	case *sonarsourcev1alpha1.SonarQubeQualityProfile:
+		statusConditions = &t.Status.Conditions
+	case *sonarsourcev1alpha1.SonarQubeBackup:
+		statusConditions = &t.Status.Conditions
+	case *sonarsourcev1alpha1.SonarQubeRestore:
+		statusConditions = &t.Status.Conditions
 	}
 
 	if statusConditions == nil {
 		statusConditions = &status.Conditions{}
 	}
 
-	if err != nil && ReasonForError(err) != ErrorReasonUnknown {
+	// Unhandled errors are returned to be retried with backoff, the server is not ready until they are resolved
+	if err != nil && ReasonForError(err) == ErrorReasonUnknown {
+		if reportsReady {
+			statusConditions.SetCondition(status.Condition{
+				Type:    sonarsourcev1alpha1.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  status.ConditionReason(ErrorReasonUnknown),
+				Message: err.Error(),
+			})
+			UpdateStatus(client, newStatus, object)
+		}
+		return reconcile.Result{}, err
+	}
+
+	if err != nil {
 		sqErr := err.(*Error)
+		if reportsReady {
+			statusConditions.SetCondition(status.Condition{
+				Type:    sonarsourcev1alpha1.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  status.ConditionReason(sqErr.Type()),
+				Message: sqErr.Message,
+			})
+		}
 		switch sqErr.Type() {
-		case ErrorReasonSpecUpdate, ErrorReasonResourceCreate, ErrorReasonResourceUpdate, ErrorReasonResourceWaiting, ErrorReasonServerWaiting:
+		case ErrorReasonSpecUpdate, ErrorReasonResourceCreate, ErrorReasonResourceUpdate, ErrorReasonResourceWaiting, ErrorReasonServerWaiting, ErrorReasonServerMigrating:
 			*statusConditions = ClearConditions(*statusConditions)
 			var reason status.ConditionReason
 			switch sqErr.Type() {
@@ -150,6 +262,14 @@ func ParseErrorForReconcileResult(client client.Client, object interface{}, err
 				reason = sonarsourcev1alpha1.ConditionResourcesCreating
 			case ErrorReasonResourceUpdate, ErrorReasonResourceWaiting:
 				reason = sonarsourcev1alpha1.ConditionReasourcesUpdating
+			case ErrorReasonServerMigrating:
+				reason = sonarsourcev1alpha1.ConditionDBMigrationRunning
+				statusConditions.SetCondition(status.Condition{
+					Type:    sonarsourcev1alpha1.ConditionDBMigration,
+					Status:  corev1.ConditionTrue,
+					Reason:  reason,
+					Message: sqErr.Message,
+				})
 			}
 			statusConditions.SetCondition(status.Condition{
 				Type:    sonarsourcev1alpha1.ConditionProgressing,
@@ -160,12 +280,12 @@ func ParseErrorForReconcileResult(client client.Client, object interface{}, err
 			UpdateStatus(client, newStatus, object)
 			reqLogger.Info(sqErr.Error())
 			switch sqErr.Type() {
-			case ErrorReasonServerWaiting, ErrorReasonResourceWaiting:
+			case ErrorReasonServerWaiting, ErrorReasonResourceWaiting, ErrorReasonServerMigrating:
 				return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
 			default:
 				return reconcile.Result{Requeue: true}, nil
 			}
-		case ErrorReasonSpecInvalid, ErrorReasonResourceInvalid:
+		case ErrorReasonSpecInvalid, ErrorReasonResourceInvalid, ErrorReasonServerMigrationFailed:
 			*statusConditions = ClearConditions(*statusConditions)
 			var reason status.ConditionReason
 			switch sqErr.Type() {
@@ -173,6 +293,14 @@ func ParseErrorForReconcileResult(client client.Client, object interface{}, err
 				reason = sonarsourcev1alpha1.ConditionSpecInvalid
 			case ErrorReasonResourceInvalid:
 				reason = sonarsourcev1alpha1.ConditionReasourcesInvalid
+			case ErrorReasonServerMigrationFailed:
+				reason = sonarsourcev1alpha1.ConditionDBMigrationFailed
+				statusConditions.SetCondition(status.Condition{
+					Type:    sonarsourcev1alpha1.ConditionDBMigration,
+					Status:  corev1.ConditionTrue,
+					Reason:  reason,
+					Message: sqErr.Message,
+				})
 			}
 			statusConditions.SetCondition(status.Condition{
 				Type:    sonarsourcev1alpha1.ConditionInvalid,
@@ -229,6 +357,36 @@ func UpdateStatus(client client.Client, newObject interface{}, object interface{
 			t.Status = *newSonarQube.Status.DeepCopy()
 			requiresUpdate = true
 		}
+	case *sonarsourcev1alpha1.SonarQubeProject:
+		newSonarQubeProject := newObject.(*sonarsourcev1alpha1.SonarQubeProject)
+		if !reflect.DeepEqual(newSonarQubeProject.Status, t.Status) {
+			t.Status = *newSonarQubeProject.Status.DeepCopy()
+			requiresUpdate = true
+		}
+	case *sonarsourcev1alpha1.SonarQubeQualityGate:
+		newSonarQubeQualityGate := newObject.(*sonarsourcev1alpha1.SonarQubeQualityGate)
+		if !reflect.DeepEqual(newSonarQubeQualityGate.Status, t.Status) {
+			t.Status = *newSonarQubeQualityGate.Status.DeepCopy()
+			requiresUpdate = true
+		}
+	case *sonarsourcev1alpha1.SonarQubeQualityProfile:
+		newSonarQubeQualityProfile := newObject.(*sonarsourcev1alpha1.SonarQubeQualityProfile)
+		if !reflect.DeepEqual(newSonarQubeQualityProfile.Status, t.Status) {
+			t.Status = *newSonarQubeQualityProfile.Status.DeepCopy()
+			requiresUpdate = true
+		}
+	case *sonarsourcev1alpha1.SonarQubeBackup:
+		newSonarQubeBackup := newObject.(*sonarsourcev1alpha1.SonarQubeBackup)
+		if !reflect.DeepEqual(newSonarQubeBackup.Status, t.Status) {
+			t.Status = *newSonarQubeBackup.Status.DeepCopy()
+			requiresUpdate = true
+		}
+	case *sonarsourcev1alpha1.SonarQubeRestore:
+		newSonarQubeRestore := newObject.(*sonarsourcev1alpha1.SonarQubeRestore)
+		if !reflect.DeepEqual(newSonarQubeRestore.Status, t.Status) {
+			t.Status = *newSonarQubeRestore.Status.DeepCopy()
+			requiresUpdate = true
+		}
 	}
 	reqLogger := log.WithValues("SonarQube.Namespace", objectMetav1.GetNamespace(), "SonarQube.Name", objectMetav1.GetName())
 
@@ -265,12 +423,19 @@ func (r *SecretMapper) Map(o handler.MapObject) []reconcile.Request {
 	return output
 }
 
-func GenVersion(spec interface{}, secret []byte) (string, error) {
+// GenVersion returns a hash of spec, the contents of secret and the contents of extra secrets
+// Each extra secret is prefixed with its length so moving bytes between adjacent secrets changes the hash, the hash
+// of spec and secret alone is unchanged from earlier releases so existing revisions don't roll the pods
+func GenVersion(spec interface{}, secret []byte, extra ...[]byte) (string, error) {
 	toBeHashed, err := json.Marshal(spec)
 	if err != nil {
 		return "", err
 	}
 	toBeHashed = append(toBeHashed, secret...)
+	for _, v := range extra {
+		toBeHashed = append(toBeHashed, strconv.Itoa(len(v))+":"...)
+		toBeHashed = append(toBeHashed, v...)
+	}
 
 	h := sha1.New()
 
@@ -279,13 +444,22 @@ func GenVersion(spec interface{}, secret []byte) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-func GetImage(edition, version *string) string {
+// GetImage returns the SonarQube image of edition and version
+// The datacenter edition has separate images for application and search nodes
+func GetImage(edition, version *string, serverType *sonarsourcev1alpha1.ServerType) string {
 	var sqImage, sqEdition string
 
 	if edition != nil {
 		sqEdition = *edition
 	} else {
-		sqEdition = "community"
+		sqEdition = DefaultEdition(serverType)
+	}
+	if sqEdition == EditionDatacenter {
+		if serverType != nil && *serverType == sonarsourcev1alpha1.Search {
+			sqEdition = fmt.Sprintf("%s-search", sqEdition)
+		} else {
+			sqEdition = fmt.Sprintf("%s-app", sqEdition)
+		}
 	}
 
 	if version != nil {
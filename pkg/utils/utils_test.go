@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/operator-framework/operator-sdk/pkg/status"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"testing"
+)
+
+// TestClearConditions checks ClearConditions sets every condition to false except excluded condition types
+func TestClearConditions(t *testing.T) {
+	conditions := status.Conditions{}
+	conditions.SetCondition(status.Condition{Type: sonarsourcev1alpha1.ConditionUnavailable, Status: corev1.ConditionTrue})
+	conditions.SetCondition(status.Condition{Type: sonarsourcev1alpha1.ConditionInvalid, Status: corev1.ConditionTrue})
+
+	conditions = ClearConditions(conditions)
+	if !conditions.IsTrueFor(sonarsourcev1alpha1.ConditionUnavailable) {
+		t.Errorf("clearConditions: excluded condition %s was cleared", sonarsourcev1alpha1.ConditionUnavailable)
+	}
+	if !conditions.IsFalseFor(sonarsourcev1alpha1.ConditionInvalid) {
+		t.Errorf("clearConditions: condition %s was not cleared", sonarsourcev1alpha1.ConditionInvalid)
+	}
+}
+
+// TestGenVersion checks GenVersion keeps the hash of a spec and secret and hashes the boundaries between extra secrets
+func TestGenVersion(t *testing.T) {
+	version, err := GenVersion(map[string]string{"version": "8.4"}, []byte("sonar.web.port=9000\n"))
+	if err != nil {
+		t.Fatalf("genVersion: (%v)", err)
+	}
+	if version != "297b7b0ec8da5014d5bb50046b33647fd50bbb6a" {
+		t.Errorf("genVersion: hash of spec and secret changed, got %s", version)
+	}
+
+	a, err := GenVersion(nil, nil, []byte("ab"), []byte("c"))
+	if err != nil {
+		t.Fatalf("genVersion: (%v)", err)
+	}
+	b, err := GenVersion(nil, nil, []byte("a"), []byte("bc"))
+	if err != nil {
+		t.Fatalf("genVersion: (%v)", err)
+	}
+	if a == b {
+		t.Error("genVersion: same hash for different secrets")
+	}
+
+	c, err := GenVersion(nil, nil, []byte("a"), []byte("bc"))
+	if err != nil {
+		t.Fatalf("genVersion: (%v)", err)
+	}
+	if b != c {
+		t.Error("genVersion: different hash for same secrets")
+	}
+}
+
+// TestGetExposeTLSSecret checks GetExposeTLSSecret annotates the tls secret so SecretMapper requeues its owner
+func TestGetExposeTLSSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-tls", Namespace: "sonarqube"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, secret)
+	expose := &sonarsourcev1alpha1.Expose{TLSSecret: &[]string{"sonarqube-tls"}[0]}
+
+	_, err := GetExposeTLSSecret(cl, "sonarqube", sonarsourcev1alpha1.ServerSecretAnnotation, "sonarqube", expose)
+	if ReasonForError(err) != ErrorReasonResourceUpdate {
+		t.Fatalf("getExposeTLSSecret: resource updated error not thrown when annotating tls secret (%v)", err)
+	}
+	found, err := GetExposeTLSSecret(cl, "sonarqube", sonarsourcev1alpha1.ServerSecretAnnotation, "sonarqube", expose)
+	if err != nil {
+		t.Fatalf("getExposeTLSSecret: (%v)", err)
+	}
+	if string(found.Data[corev1.TLSCertKey]) != "cert" {
+		t.Error("getExposeTLSSecret: unexpected tls secret returned")
+	}
+
+	mapper := &SecretMapper{Annotation: sonarsourcev1alpha1.ServerSecretAnnotation}
+	requests := mapper.Map(handler.MapObject{Meta: found, Object: found})
+	if len(requests) != 1 || requests[0].Name != "sonarqube" {
+		t.Errorf("secretMapper: expected request for sonarqube got %v", requests)
+	}
+}
+
+// TestResolveVersion checks ResolveVersion keeps resolved versions newer than an unchanged spec version and applies a
+// changed spec version even when it is older
+func TestResolveVersion(t *testing.T) {
+	for _, v := range []struct {
+		version, observed    *string
+		resolved, skip, want string
+	}{
+		{version: &[]string{"8.3"}[0], observed: &[]string{"8.3"}[0], resolved: "8.5", want: "8.5"},
+		{version: &[]string{"8.2"}[0], observed: &[]string{"8.3"}[0], resolved: "8.5", want: "8.2"},
+		{version: &[]string{"8.2"}[0], observed: &[]string{""}[0], resolved: "8.5", want: "8.2"},
+		{version: &[]string{"8.2"}[0], resolved: "8.5", want: "8.5"},
+		{version: &[]string{"8.6"}[0], observed: &[]string{"8.6"}[0], resolved: "8.5", skip: "8.6", want: "8.5"},
+		{observed: &[]string{""}[0], resolved: "8.5", want: "8.5"},
+	} {
+		version := ResolveVersion(v.version, v.observed, v.resolved, v.skip)
+		if version == nil || *version != v.want {
+			t.Errorf("resolveVersion: expected %s got %v", v.want, version)
+		}
+	}
+}
+
+// TestParseErrorForReconcileResult checks ParseErrorForReconcileResult returns unhandled errors and reports Ready false
+func TestParseErrorForReconcileResult(t *testing.T) {
+	server := &sonarsourcev1alpha1.SonarQubeServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "sonarqube", Namespace: "sonarqube"},
+	}
+	server.Status.Conditions.SetCondition(status.Condition{Type: sonarsourcev1alpha1.ConditionReady, Status: corev1.ConditionTrue})
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, server)
+	cl := fake.NewFakeClientWithScheme(s, server)
+
+	_, err := ParseErrorForReconcileResult(cl, server, fmt.Errorf("connection refused"))
+	if err == nil {
+		t.Error("parseErrorForReconcileResult: unhandled error not returned")
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: server.Name, Namespace: server.Namespace}, server); err != nil {
+		t.Fatalf("parseErrorForReconcileResult: (%v)", err)
+	}
+	if c := server.Status.Conditions.GetCondition(sonarsourcev1alpha1.ConditionReady); c == nil || c.Status != corev1.ConditionFalse || c.Reason != status.ConditionReason(ErrorReasonUnknown) {
+		t.Errorf("parseErrorForReconcileResult: ready not set false with reason %s, got %v", ErrorReasonUnknown, c)
+	}
+}
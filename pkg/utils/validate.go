@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/parflesh/sonarqube-operator/pkg/api_client"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"strings"
+)
+
+const (
+	EditionCommunity  = "community"
+	EditionDeveloper  = "developer"
+	EditionEnterprise = "enterprise"
+	EditionDatacenter = "datacenter"
+)
+
+// Editions are the SonarQube editions published as docker images
+var Editions = []string{EditionCommunity, EditionDeveloper, EditionEnterprise, EditionDatacenter}
+
+// DefaultEdition returns the edition used when a SonarQubeServer of serverType does not set one
+func DefaultEdition(serverType *sonarsourcev1alpha1.ServerType) string {
+	if serverType != nil && *serverType != sonarsourcev1alpha1.AIO {
+		return EditionDatacenter
+	}
+	return EditionCommunity
+}
+
+// ValidateSonarQube returns ErrorReasonSpecInvalid when the SonarQube cluster spec can not be applied
+func ValidateSonarQube(cr *sonarsourcev1alpha1.SonarQube) error {
+	if cr.Spec.Edition != nil {
+		if err := ValidateEdition(*cr.Spec.Edition); err != nil {
+			return err
+		}
+		if *cr.Spec.Edition != EditionDatacenter {
+			return &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("clustering requires the %s edition, %s edition can only run as a SonarQubeServer", EditionDatacenter, *cr.Spec.Edition),
+			}
+		}
+	}
+
+	if cr.Spec.Version != nil {
+		if err := ValidateVersion(*cr.Spec.Version); err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.SearchSize != nil && (*cr.Spec.SearchSize < 3 || *cr.Spec.SearchSize%2 == 0) {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("searchSize must be an odd number of at least 3, got %d", *cr.Spec.SearchSize),
+		}
+	}
+
+	if err := ValidateProperties(cr.Spec.Properties); err != nil {
+		return err
+	}
+	if err := ValidatePropertiesSources(cr.Spec.PropertiesFrom); err != nil {
+		return err
+	}
+
+	if err := ValidateDatabase(cr.Spec.Database); err != nil {
+		return err
+	}
+	if cr.Spec.Database != nil && cr.Spec.Database.Managed != nil {
+		for k := range cr.Spec.Properties {
+			if strings.HasPrefix(k, "sonar.jdbc.") {
+				return &Error{
+					Reason:  ErrorReasonSpecInvalid,
+					Message: fmt.Sprintf("property %s is set by the managed database", k),
+				}
+			}
+		}
+		if cr.Spec.Database.Managed.StorageSize != nil {
+			if err := ValidateStorageSize(*cr.Spec.Database.Managed.StorageSize); err != nil {
+				return &Error{
+					Reason:  ErrorReasonSpecInvalid,
+					Message: fmt.Sprintf("database: %s", err.(*Error).Message),
+				}
+			}
+		}
+	}
+
+	for _, v := range cr.Spec.NodeConfig {
+		if v.StorageSize != nil {
+			if err := ValidateStorageSize(*v.StorageSize); err != nil {
+				return &Error{
+					Reason:  ErrorReasonSpecInvalid,
+					Message: fmt.Sprintf("nodeConfig %s: %s", v.Type, err.(*Error).Message),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateSonarQubeServer returns ErrorReasonSpecInvalid when the SonarQubeServer spec can not be applied
+func ValidateSonarQubeServer(cr *sonarsourcev1alpha1.SonarQubeServer) error {
+	clustered := cr.Spec.Type != nil && *cr.Spec.Type != sonarsourcev1alpha1.AIO
+
+	// Validate the edition the server runs with, which is defaulted from its type when not set
+	edition := DefaultEdition(cr.Spec.Type)
+	if cr.Spec.Edition != nil {
+		if err := ValidateEdition(*cr.Spec.Edition); err != nil {
+			return err
+		}
+		edition = *cr.Spec.Edition
+	}
+	if clustered && edition != EditionDatacenter {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("%s nodes require the %s edition", *cr.Spec.Type, EditionDatacenter),
+		}
+	}
+	if !clustered && edition == EditionDatacenter {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("%s edition requires type %s or %s", EditionDatacenter, sonarsourcev1alpha1.Application, sonarsourcev1alpha1.Search),
+		}
+	}
+
+	// Application nodes are created shutdown and only need search hosts to start
+	shutdown := cr.Spec.Shutdown != nil && *cr.Spec.Shutdown
+	if cr.Spec.Type != nil && *cr.Spec.Type == sonarsourcev1alpha1.Application && len(cr.Spec.SearchHosts) == 0 && !shutdown {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "application nodes require searchHosts",
+		}
+	}
+
+	if cr.Spec.Version != nil {
+		if err := ValidateVersion(*cr.Spec.Version); err != nil {
+			return err
+		}
+	}
+
+	// Search nodes hold no database to snapshot
+	if cr.Spec.Snapshot != nil && cr.Spec.Type != nil && *cr.Spec.Type == sonarsourcev1alpha1.Search {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "snapshot is not supported for search nodes",
+		}
+	}
+
+	if cr.Spec.NodeConfig.StorageSize != nil {
+		if err := ValidateStorageSize(*cr.Spec.NodeConfig.StorageSize); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateProperties(cr.Spec.Properties); err != nil {
+		return err
+	}
+	if err := ValidatePropertiesSources(cr.Spec.PropertiesFrom); err != nil {
+		return err
+	}
+
+	if cr.Spec.Database != nil && cr.Spec.Database.Managed != nil {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "database.managed is only supported by SonarQube clusters",
+		}
+	}
+	if err := ValidateDatabase(cr.Spec.Database); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateSonarQubeBackup returns ErrorReasonSpecInvalid when the SonarQubeBackup spec can not be applied
+func ValidateSonarQubeBackup(cr *sonarsourcev1alpha1.SonarQubeBackup) error {
+	if kind := cr.Spec.ServerRef.Kind; kind != nil && *kind != sonarsourcev1alpha1.ServerKindSonarQubeServer && *kind != sonarsourcev1alpha1.ServerKindSonarQube {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("server kind must be %s or %s", sonarsourcev1alpha1.ServerKindSonarQubeServer, sonarsourcev1alpha1.ServerKindSonarQube),
+		}
+	}
+
+	if (cr.Spec.Storage.PersistentVolumeClaim == nil) == (cr.Spec.Storage.S3 == nil) {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "storage requires one of persistentVolumeClaim or s3",
+		}
+	}
+	if s3 := cr.Spec.Storage.S3; s3 != nil && (s3.Endpoint == "" || s3.Bucket == "" || s3.CredentialsSecret == "") {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "storage.s3 requires endpoint, bucket, and credentialsSecret",
+		}
+	}
+
+	if cr.Spec.Retention != nil && *cr.Spec.Retention < 1 {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("retention must be at least 1, got %v", *cr.Spec.Retention),
+		}
+	}
+
+	return nil
+}
+
+// ValidateEdition returns ErrorReasonSpecInvalid when edition is not a known SonarQube edition
+func ValidateEdition(edition string) error {
+	if !ContainsString(Editions, edition) {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("edition %s must be one of %s", edition, strings.Join(Editions, ", ")),
+		}
+	}
+	return nil
+}
+
+// ValidateVersion returns ErrorReasonSpecInvalid when version is not a SonarQube version
+func ValidateVersion(version string) error {
+	if _, err := api_client.ParseSystemVersion(version); err != nil {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("version %s is malformed (%s)", version, err.Error()),
+		}
+	}
+	return nil
+}
+
+// ValidateStorageSize returns ErrorReasonSpecInvalid when size is not a resource quantity
+func ValidateStorageSize(size string) error {
+	if _, err := resource.ParseQuantity(size); err != nil {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("storageSize %s is not a valid quantity (%s)", size, err.Error()),
+		}
+	}
+	return nil
+}
+
+// ValidatePropertiesSources returns ErrorReasonSpecInvalid when a source does not reference exactly one ConfigMap or Secret
+func ValidatePropertiesSources(sources []sonarsourcev1alpha1.PropertiesSource) error {
+	for i, v := range sources {
+		if (v.ConfigMapRef == nil) == (v.SecretRef == nil) {
+			return &Error{
+				Reason:  ErrorReasonSpecInvalid,
+				Message: fmt.Sprintf("propertiesFrom[%d] requires one of configMapRef or secretRef", i),
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateSonarQubeRestore returns ErrorReasonSpecInvalid when the SonarQubeRestore spec can not be applied
+func ValidateSonarQubeRestore(cr *sonarsourcev1alpha1.SonarQubeRestore) error {
+	if kind := cr.Spec.ServerRef.Kind; kind != nil && *kind != sonarsourcev1alpha1.ServerKindSonarQubeServer {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: fmt.Sprintf("server kind must be %s, servers of a %s can not be restored", sonarsourcev1alpha1.ServerKindSonarQubeServer, sonarsourcev1alpha1.ServerKindSonarQube),
+		}
+	}
+
+	if cr.Spec.Backup == "" {
+		return &Error{
+			Reason:  ErrorReasonSpecInvalid,
+			Message: "backup is required",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/parflesh/sonarqube-operator/pkg/webhook/validate"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, validate.Add)
+}
@@ -0,0 +1,79 @@
+package mutate
+
+import (
+	"context"
+	"encoding/json"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	SonarQubePath       = "/mutate-sonarsource-parflesh-github-io-v1alpha1-sonarqube"
+	SonarQubeServerPath = "/mutate-sonarsource-parflesh-github-io-v1alpha1-sonarqubeserver"
+)
+
+// Add registers the defaulting webhooks for SonarQube and SonarQubeServer with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	server.Register(SonarQubePath, &webhook.Admission{Handler: &SonarQubeDefaulter{}})
+	server.Register(SonarQubeServerPath, &webhook.Admission{Handler: &SonarQubeServerDefaulter{}})
+	return nil
+}
+
+// SonarQubeDefaulter sets the defaults of SonarQube on admission so they are not written to the spec by the controller
+type SonarQubeDefaulter struct {
+	decoder *admission.Decoder
+}
+
+func (d *SonarQubeDefaulter) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQube{}
+	if err := d.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	utils.DefaultSonarQube(cr)
+
+	return patch(req, cr)
+}
+
+// InjectDecoder injects the decoder into SonarQubeDefaulter
+func (d *SonarQubeDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// SonarQubeServerDefaulter sets the defaults of SonarQubeServer on admission so they are not written to the spec by
+// the controller
+type SonarQubeServerDefaulter struct {
+	decoder *admission.Decoder
+}
+
+func (d *SonarQubeServerDefaulter) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQubeServer{}
+	if err := d.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	utils.DefaultSonarQubeServer(cr)
+
+	return patch(req, cr)
+}
+
+// InjectDecoder injects the decoder into SonarQubeServerDefaulter
+func (d *SonarQubeServerDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// patch returns the json patch from the object of req to obj
+func patch(req admission.Request, obj interface{}) admission.Response {
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
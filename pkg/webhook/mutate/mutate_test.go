@@ -0,0 +1,128 @@
+package mutate
+
+import (
+	"context"
+	"encoding/json"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"testing"
+)
+
+// TestSonarQubeDefaulter runs SonarQubeDefaulter.Handle() against SonarQube clusters
+func TestSonarQubeDefaulter(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQube{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	d := &SonarQubeDefaulter{}
+	if err := d.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	sonarqube := &sonarsourcev1alpha1.SonarQube{
+		TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQube"},
+		ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-operator", Namespace: "sonarqube"},
+		Spec:       sonarsourcev1alpha1.SonarQubeSpec{Size: 1},
+	}
+	patches := patchedPaths(t, d.Handle(context.TODO(), request(t, sonarqube)))
+	if patches["/spec/secret"] != "sonarqube-operator-config" {
+		t.Errorf("handle: secret not defaulted, got %v", patches["/spec/secret"])
+	}
+	if patches["/spec/edition"] != "datacenter" {
+		t.Errorf("handle: edition not defaulted, got %v", patches["/spec/edition"])
+	}
+
+	sonarqube.Spec.Secret = &[]string{"sonarqube-config"}[0]
+	sonarqube.Spec.Edition = &[]string{"datacenter"}[0]
+	patches = patchedPaths(t, d.Handle(context.TODO(), request(t, sonarqube)))
+	if len(patches) != 0 {
+		t.Errorf("handle: spec patched even though defaults are set (%v)", patches)
+	}
+}
+
+// TestSonarQubeServerDefaulter runs SonarQubeServerDefaulter.Handle() against SonarQubeServers
+func TestSonarQubeServerDefaulter(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQubeServer{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	d := &SonarQubeServerDefaulter{}
+	if err := d.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	sonarqube := &sonarsourcev1alpha1.SonarQubeServer{
+		TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQubeServer"},
+		ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-operator", Namespace: "sonarqube"},
+	}
+	resp := d.Handle(context.TODO(), request(t, sonarqube))
+	if !resp.Allowed {
+		t.Fatalf("handle: (%s)", resp.Result.Message)
+	}
+	patched := &sonarsourcev1alpha1.SonarQubeServer{}
+	sonarqube.DeepCopyInto(patched)
+	for k, v := range patchedPaths(t, resp) {
+		switch k {
+		case "/spec/secret":
+			patched.Spec.Secret = &[]string{v.(string)}[0]
+		case "/spec/type":
+			patched.Spec.Type = &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.ServerType(v.(string))}[0]
+		case "/spec/edition":
+			patched.Spec.Edition = &[]string{v.(string)}[0]
+		case "/spec/nodeConfig/storageSize":
+			patched.Spec.NodeConfig.StorageSize = &[]string{v.(string)}[0]
+		}
+	}
+	if patched.Spec.Secret == nil || *patched.Spec.Secret != "sonarqube-operator-config" {
+		t.Error("handle: secret not defaulted")
+	}
+	if patched.Spec.Type == nil || *patched.Spec.Type != sonarsourcev1alpha1.AIO {
+		t.Error("handle: type not defaulted")
+	}
+	if patched.Spec.Edition == nil || *patched.Spec.Edition != "community" {
+		t.Error("handle: edition not defaulted")
+	}
+	if patched.Spec.NodeConfig.StorageSize == nil || *patched.Spec.NodeConfig.StorageSize != "1Gi" {
+		t.Error("handle: storage size not defaulted")
+	}
+
+	sonarqube.Spec.Type = &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search}[0]
+	patches := patchedPaths(t, d.Handle(context.TODO(), request(t, sonarqube)))
+	if patches["/spec/edition"] != "datacenter" {
+		t.Errorf("handle: edition of search node not defaulted to datacenter, got %v", patches["/spec/edition"])
+	}
+}
+
+// patchedPaths returns the values of the json patch operations of resp by path
+func patchedPaths(t *testing.T, resp admission.Response) map[string]interface{} {
+	if !resp.Allowed {
+		t.Fatalf("handle: (%s)", resp.Result.Message)
+	}
+	output := make(map[string]interface{})
+	for _, v := range resp.Patches {
+		output[v.Path] = v.Value
+	}
+	return output
+}
+
+// request returns an admission request creating obj
+func request(t *testing.T, obj runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("marshal: (%v)", err)
+	}
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
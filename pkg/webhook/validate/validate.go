@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"context"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	"github.com/parflesh/sonarqube-operator/pkg/utils"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	SonarQubePath        = "/validate-sonarsource-parflesh-github-io-v1alpha1-sonarqube"
+	SonarQubeServerPath  = "/validate-sonarsource-parflesh-github-io-v1alpha1-sonarqubeserver"
+	SonarQubeBackupPath  = "/validate-sonarsource-parflesh-github-io-v1alpha1-sonarqubebackup"
+	SonarQubeRestorePath = "/validate-sonarsource-parflesh-github-io-v1alpha1-sonarquberestore"
+)
+
+// Add registers the validating webhooks for SonarQube, SonarQubeServer, SonarQubeBackup, and SonarQubeRestore with the webhook server of the Manager
+func Add(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	server.Register(SonarQubePath, &webhook.Admission{Handler: &SonarQubeValidator{}})
+	server.Register(SonarQubeServerPath, &webhook.Admission{Handler: &SonarQubeServerValidator{}})
+	server.Register(SonarQubeBackupPath, &webhook.Admission{Handler: &SonarQubeBackupValidator{}})
+	server.Register(SonarQubeRestorePath, &webhook.Admission{Handler: &SonarQubeRestoreValidator{}})
+	return nil
+}
+
+// SonarQubeValidator rejects SonarQube clusters that can not be reconciled
+type SonarQubeValidator struct {
+	decoder *admission.Decoder
+}
+
+func (v *SonarQubeValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQube{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	return response(utils.ValidateSonarQube(cr))
+}
+
+// InjectDecoder injects the decoder into SonarQubeValidator
+func (v *SonarQubeValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// SonarQubeServerValidator rejects SonarQubeServers that can not be reconciled
+type SonarQubeServerValidator struct {
+	decoder *admission.Decoder
+}
+
+func (v *SonarQubeServerValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQubeServer{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	return response(utils.ValidateSonarQubeServer(cr))
+}
+
+// InjectDecoder injects the decoder into SonarQubeServerValidator
+func (v *SonarQubeServerValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// SonarQubeBackupValidator rejects SonarQubeBackups that can not be reconciled
+type SonarQubeBackupValidator struct {
+	decoder *admission.Decoder
+}
+
+func (v *SonarQubeBackupValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQubeBackup{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	return response(utils.ValidateSonarQubeBackup(cr))
+}
+
+// InjectDecoder injects the decoder into SonarQubeBackupValidator
+func (v *SonarQubeBackupValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// SonarQubeRestoreValidator rejects SonarQubeRestores that can not be reconciled
+type SonarQubeRestoreValidator struct {
+	decoder *admission.Decoder
+}
+
+func (v *SonarQubeRestoreValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	cr := &sonarsourcev1alpha1.SonarQubeRestore{}
+	if err := v.decoder.Decode(req, cr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	return response(utils.ValidateSonarQubeRestore(cr))
+}
+
+// InjectDecoder injects the decoder into SonarQubeRestoreValidator
+func (v *SonarQubeRestoreValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// response denies the request with the message of err
+func response(err error) admission.Response {
+	if err == nil {
+		return admission.Allowed("")
+	}
+	if sqErr, ok := err.(*utils.Error); ok {
+		return admission.Denied(sqErr.Message)
+	}
+	return admission.Errored(http.StatusInternalServerError, err)
+}
@@ -0,0 +1,215 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	sonarsourcev1alpha1 "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"testing"
+)
+
+// TestSonarQubeValidator runs SonarQubeValidator.Handle() against SonarQube clusters
+func TestSonarQubeValidator(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQube{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	v := &SonarQubeValidator{}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    sonarsourcev1alpha1.SonarQubeSpec
+		allowed bool
+	}{
+		{"default edition", sonarsourcev1alpha1.SonarQubeSpec{Size: 2}, true},
+		{"datacenter edition", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Edition: &[]string{"datacenter"}[0], Version: &[]string{"8.3.1"}[0]}, true},
+		{"community edition", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Edition: &[]string{"community"}[0]}, false},
+		{"unknown edition", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Edition: &[]string{"free"}[0]}, false},
+		{"malformed version", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Version: &[]string{"latest"}[0]}, false},
+		{"even search size", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, SearchSize: &[]int32{4}[0]}, false},
+		{"storage size", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, NodeConfig: []sonarsourcev1alpha1.ClusterNodeConfig{{Type: "search", StorageSize: &[]string{"10Gi"}[0]}}}, true},
+		{"unparseable storage size", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, NodeConfig: []sonarsourcev1alpha1.ClusterNodeConfig{{Type: "search", StorageSize: &[]string{"10 gigs"}[0]}}}, false},
+		{"properties", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Properties: map[string]string{"sonar.search.javaOpts": "-Xmx1G"}}, true},
+		{"managed properties", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Properties: map[string]string{"sonar.cluster.enabled": "false"}}, false},
+		{"properties from configmap", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, PropertiesFrom: []sonarsourcev1alpha1.PropertiesSource{{ConfigMapRef: &corev1.LocalObjectReference{Name: "sonar"}}}}, true},
+		{"managed database", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Database: &sonarsourcev1alpha1.Database{Managed: &sonarsourcev1alpha1.ManagedDatabase{StorageSize: &[]string{"10Gi"}[0]}}}, true},
+		{"managed database with jdbc properties", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Properties: map[string]string{"sonar.jdbc.url": "jdbc:postgresql://postgres/sonar"}, Database: &sonarsourcev1alpha1.Database{Managed: &sonarsourcev1alpha1.ManagedDatabase{}}}, false},
+		{"managed database unparseable storage size", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Database: &sonarsourcev1alpha1.Database{Managed: &sonarsourcev1alpha1.ManagedDatabase{StorageSize: &[]string{"lots"}[0]}}}, false},
+		{"properties from nothing", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, PropertiesFrom: []sonarsourcev1alpha1.PropertiesSource{{}}}, false},
+		{"database url from secret", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Database: &sonarsourcev1alpha1.Database{URLFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"}, Key: "url"}}}, true},
+		{"database url from secret without key", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Database: &sonarsourcev1alpha1.Database{URLFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"}}}}, false},
+		{"managed database with url from secret", sonarsourcev1alpha1.SonarQubeSpec{Size: 2, Database: &sonarsourcev1alpha1.Database{Managed: &sonarsourcev1alpha1.ManagedDatabase{}, URLFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"}, Key: "url"}}}, false},
+	}
+
+	for _, test := range tests {
+		cr := &sonarsourcev1alpha1.SonarQube{
+			TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQube"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-operator", Namespace: "sonarqube"},
+			Spec:       test.spec,
+		}
+		resp := v.Handle(context.TODO(), request(t, cr))
+		if resp.Allowed != test.allowed {
+			t.Errorf("handle: %s expected allowed %v got %v (%s)", test.name, test.allowed, resp.Allowed, resp.Result.Reason)
+		}
+		if !resp.Allowed && resp.Result.Reason == "" {
+			t.Errorf("handle: %s denied without reason", test.name)
+		}
+	}
+}
+
+// TestSonarQubeServerValidator runs SonarQubeServerValidator.Handle() against SonarQubeServers
+func TestSonarQubeServerValidator(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQubeServer{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	v := &SonarQubeServerValidator{}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	application := &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Application}[0]
+	search := &[]sonarsourcev1alpha1.ServerType{sonarsourcev1alpha1.Search}[0]
+	datacenter := &[]string{"datacenter"}[0]
+
+	tests := []struct {
+		name    string
+		spec    sonarsourcev1alpha1.SonarQubeServerSpec
+		allowed bool
+	}{
+		{"aio", sonarsourcev1alpha1.SonarQubeServerSpec{Version: &[]string{"8.3"}[0]}, true},
+		{"aio developer edition", sonarsourcev1alpha1.SonarQubeServerSpec{Edition: &[]string{"developer"}[0]}, true},
+		{"aio datacenter edition", sonarsourcev1alpha1.SonarQubeServerSpec{Edition: datacenter}, false},
+		{"search community edition", sonarsourcev1alpha1.SonarQubeServerSpec{Type: search, Edition: &[]string{"community"}[0]}, false},
+		{"search default edition", sonarsourcev1alpha1.SonarQubeServerSpec{Type: search}, true},
+		{"application default edition", sonarsourcev1alpha1.SonarQubeServerSpec{Type: application, SearchHosts: []string{"search-0"}}, true},
+		{"search datacenter edition", sonarsourcev1alpha1.SonarQubeServerSpec{Type: search, Edition: datacenter}, true},
+		{"application with search hosts", sonarsourcev1alpha1.SonarQubeServerSpec{Type: application, Edition: datacenter, SearchHosts: []string{"search-0"}}, true},
+		{"application without search hosts", sonarsourcev1alpha1.SonarQubeServerSpec{Type: application, Edition: datacenter}, false},
+		{"shutdown application without search hosts", sonarsourcev1alpha1.SonarQubeServerSpec{Type: application, Edition: datacenter, Shutdown: &[]bool{true}[0]}, true},
+		{"malformed version", sonarsourcev1alpha1.SonarQubeServerSpec{Version: &[]string{"8.x"}[0]}, false},
+		{"unparseable storage size", sonarsourcev1alpha1.SonarQubeServerSpec{NodeConfig: sonarsourcev1alpha1.NodeConfig{StorageSize: &[]string{"large"}[0]}}, false},
+		{"managed properties", sonarsourcev1alpha1.SonarQubeServerSpec{Properties: map[string]string{"sonar.web.port": "8080"}}, false},
+		{"properties from configmap and secret", sonarsourcev1alpha1.SonarQubeServerSpec{PropertiesFrom: []sonarsourcev1alpha1.PropertiesSource{{ConfigMapRef: &corev1.LocalObjectReference{Name: "sonar"}, SecretRef: &corev1.LocalObjectReference{Name: "sonar"}}}}, false},
+		{"database password from secret", sonarsourcev1alpha1.SonarQubeServerSpec{Database: &sonarsourcev1alpha1.Database{PasswordFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "jdbc"}, Key: "password"}}}, true},
+		{"managed database", sonarsourcev1alpha1.SonarQubeServerSpec{Database: &sonarsourcev1alpha1.Database{Managed: &sonarsourcev1alpha1.ManagedDatabase{}}}, false},
+	}
+
+	for _, test := range tests {
+		cr := &sonarsourcev1alpha1.SonarQubeServer{
+			TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQubeServer"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-operator", Namespace: "sonarqube"},
+			Spec:       test.spec,
+		}
+		resp := v.Handle(context.TODO(), request(t, cr))
+		if resp.Allowed != test.allowed {
+			t.Errorf("handle: %s expected allowed %v got %v (%s)", test.name, test.allowed, resp.Allowed, resp.Result.Reason)
+		}
+	}
+}
+
+// TestSonarQubeBackupValidator runs SonarQubeBackupValidator.Handle() against SonarQubeBackups
+func TestSonarQubeBackupValidator(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQubeBackup{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	v := &SonarQubeBackupValidator{}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	serverRef := sonarsourcev1alpha1.ServerReference{Name: "sonarqube"}
+	pvc := sonarsourcev1alpha1.BackupStorage{PersistentVolumeClaim: &[]string{"backups"}[0]}
+	s3 := &sonarsourcev1alpha1.S3Storage{Endpoint: "http://minio:9000", Bucket: "sonarqube", CredentialsSecret: "minio"}
+
+	tests := []struct {
+		name    string
+		spec    sonarsourcev1alpha1.SonarQubeBackupSpec
+		allowed bool
+	}{
+		{"pvc", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef, Storage: pvc}, true},
+		{"s3", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef, Storage: sonarsourcev1alpha1.BackupStorage{S3: s3}, Schedule: &[]string{"0 2 * * *"}[0]}, true},
+		{"pvc and s3", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef, Storage: sonarsourcev1alpha1.BackupStorage{PersistentVolumeClaim: pvc.PersistentVolumeClaim, S3: s3}}, false},
+		{"no storage", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef}, false},
+		{"s3 without bucket", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef, Storage: sonarsourcev1alpha1.BackupStorage{S3: &sonarsourcev1alpha1.S3Storage{Endpoint: "http://minio:9000", CredentialsSecret: "minio"}}}, false},
+		{"zero retention", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: serverRef, Storage: pvc, Retention: &[]int32{0}[0]}, false},
+		{"unknown server kind", sonarsourcev1alpha1.SonarQubeBackupSpec{ServerRef: sonarsourcev1alpha1.ServerReference{Kind: &[]string{"Deployment"}[0], Name: "sonarqube"}, Storage: pvc}, false},
+	}
+
+	for _, test := range tests {
+		cr := &sonarsourcev1alpha1.SonarQubeBackup{
+			TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQubeBackup"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-backup", Namespace: "sonarqube"},
+			Spec:       test.spec,
+		}
+		resp := v.Handle(context.TODO(), request(t, cr))
+		if resp.Allowed != test.allowed {
+			t.Errorf("handle: %s expected allowed %v got %v (%s)", test.name, test.allowed, resp.Allowed, resp.Result.Reason)
+		}
+	}
+}
+
+// TestSonarQubeRestoreValidator runs SonarQubeRestoreValidator.Handle() against SonarQubeRestores
+func TestSonarQubeRestoreValidator(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(sonarsourcev1alpha1.SchemeGroupVersion, &sonarsourcev1alpha1.SonarQubeRestore{})
+	decoder, err := admission.NewDecoder(s)
+	if err != nil {
+		t.Fatalf("newDecoder: (%v)", err)
+	}
+	v := &SonarQubeRestoreValidator{}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("injectDecoder: (%v)", err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    sonarsourcev1alpha1.SonarQubeRestoreSpec
+		allowed bool
+	}{
+		{"server", sonarsourcev1alpha1.SonarQubeRestoreSpec{ServerRef: sonarsourcev1alpha1.ServerReference{Name: "sonarqube"}, Backup: "sonarqube"}, true},
+		{"no backup", sonarsourcev1alpha1.SonarQubeRestoreSpec{ServerRef: sonarsourcev1alpha1.ServerReference{Name: "sonarqube"}}, false},
+		{"cluster", sonarsourcev1alpha1.SonarQubeRestoreSpec{ServerRef: sonarsourcev1alpha1.ServerReference{Kind: &[]string{sonarsourcev1alpha1.ServerKindSonarQube}[0], Name: "sonarqube"}, Backup: "sonarqube"}, false},
+	}
+
+	for _, test := range tests {
+		cr := &sonarsourcev1alpha1.SonarQubeRestore{
+			TypeMeta:   metav1.TypeMeta{APIVersion: sonarsourcev1alpha1.SchemeGroupVersion.String(), Kind: "SonarQubeRestore"},
+			ObjectMeta: metav1.ObjectMeta{Name: "sonarqube-restore", Namespace: "sonarqube"},
+			Spec:       test.spec,
+		}
+		resp := v.Handle(context.TODO(), request(t, cr))
+		if resp.Allowed != test.allowed {
+			t.Errorf("handle: %s expected allowed %v got %v (%s)", test.name, test.allowed, resp.Allowed, resp.Result.Reason)
+		}
+	}
+}
+
+// request returns an admission request creating obj
+func request(t *testing.T, obj runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("marshal: (%v)", err)
+	}
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
@@ -7,13 +7,7 @@ import (
 	"github.com/operator-framework/operator-sdk/pkg/test/e2eutil"
 	"github.com/parflesh/sonarqube-operator/pkg/apis"
 	operator "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
-	"golang.org/x/net/context"
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"testing"
 )
 
@@ -35,20 +29,6 @@ func sonarqubeDeployTest(t *testing.T, f *framework.Framework, ctx *framework.Co
 		return fmt.Errorf("could not get namespace: %v", err)
 	}
 
-	dbDeployment, dbService := setupDependencies("database", namespace)
-	err = f.Client.Create(goctx.TODO(), dbDeployment, &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
-	if err != nil {
-		return err
-	}
-	err = f.Client.Create(goctx.TODO(), dbService, &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
-	if err != nil {
-		return err
-	}
-	err = e2eutil.WaitForDeployment(t, f.KubeClient, namespace, dbDeployment.Name, 1, retryInterval, timeout)
-	if err != nil {
-		return err
-	}
-
 	// create sonarqube custom resource
 	exampleSonarQube := &operator.SonarQube{
 		ObjectMeta: metav1.ObjectMeta{
@@ -57,6 +37,9 @@ func sonarqubeDeployTest(t *testing.T, f *framework.Framework, ctx *framework.Co
 		},
 		Spec: operator.SonarQubeSpec{
 			Size: 1,
+			Database: &operator.Database{
+				Managed: &operator.ManagedDatabase{},
+			},
 		},
 	}
 	// use TestCtx's create helper to create the object and add a cleanup function for the new object
@@ -65,29 +48,6 @@ func sonarqubeDeployTest(t *testing.T, f *framework.Framework, ctx *framework.Co
 		return err
 	}
 
-	// Wait for secret to be created and add sonar.jdbc.url
-	for {
-		sonarQube := &operator.SonarQube{}
-		err := f.Client.Get(context.TODO(), types.NamespacedName{Name: exampleSonarQube.Name, Namespace: exampleSonarQube.Namespace}, sonarQube)
-		if err != nil {
-			return err
-		}
-		if sonarQube.Spec.Secret != nil {
-			secret := &corev1.Secret{}
-			err := f.Client.Get(context.TODO(), types.NamespacedName{Name: *sonarQube.Spec.Secret, Namespace: sonarQube.Namespace}, secret)
-			if err != nil && !errors.IsNotFound(err) {
-				return err
-			} else if err == nil {
-				secret.Data["sonar.properties"] = append(secret.Data["sonar.properties"], "\nsonar.jdbc.url=jdbc:postgresql://postgresql/sonar?user=sonar&password=sonar"...)
-				err := f.Client.Update(context.TODO(), secret)
-				if err != nil {
-					return err
-				}
-				break
-			}
-		}
-	}
-
 	// Wait for search servers to startup
 	for i := 0; i < 3; i++ {
 		err := e2eutil.WaitForDeployment(t, f.KubeClient, namespace, fmt.Sprintf("%s-%s-%v", exampleSonarQube.Name, operator.Search, i), 1, retryInterval, timeout)
@@ -100,121 +60,6 @@ func sonarqubeDeployTest(t *testing.T, f *framework.Framework, ctx *framework.Co
 	return nil
 }
 
-func setupDependencies(name, namespace string) (*appsv1.Deployment, *corev1.Service) {
-	dbDeployment := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Labels: map[string]string{
-				"database": name,
-			},
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &[]int32{1}[0],
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"database": name,
-				},
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "example-sonarqube-database",
-					Namespace: namespace,
-					Labels: map[string]string{
-						"database": name,
-					},
-				},
-				Spec: corev1.PodSpec{
-					Volumes: []corev1.Volume{
-						{
-							Name: "data",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{},
-							},
-						},
-					},
-					Containers: []corev1.Container{
-						{
-							Name:  "sonarqube",
-							Image: "postgres",
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "data",
-									MountPath: "/var/lib/postgresql/data",
-								},
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "PGDATA",
-									Value: "/var/lib/postgresql/data/pgdata",
-								},
-								{
-									Name:  "POSTGRES_PASSWORD",
-									Value: "sonar",
-								},
-								{
-									Name:  "POSTGRES_USER",
-									Value: "sonar",
-								},
-								{
-									Name:  "POSTGRES_DB",
-									Value: "sonar",
-								},
-							},
-							LivenessProbe: &corev1.Probe{
-								Handler: corev1.Handler{
-									TCPSocket: &corev1.TCPSocketAction{
-										Port: intstr.IntOrString{
-											Type:   intstr.Int,
-											IntVal: 5432,
-											StrVal: "",
-										},
-									},
-								},
-							},
-							ReadinessProbe: &corev1.Probe{
-								Handler: corev1.Handler{
-									TCPSocket: &corev1.TCPSocketAction{
-										Port: intstr.IntOrString{
-											Type:   intstr.Int,
-											IntVal: 5432,
-											StrVal: "",
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		},
-	}
-
-	dbService := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Labels: map[string]string{
-				"database": name,
-			},
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Name:     "db",
-					Protocol: corev1.ProtocolTCP,
-					Port:     5432,
-				},
-			},
-			Selector: map[string]string{
-				"database": name,
-			},
-			Type: corev1.ServiceTypeClusterIP,
-		},
-	}
-	return dbDeployment, dbService
-}
-
 func SonarQube(t *testing.T) {
 	t.Parallel()
 	ctx := framework.NewContext(t)
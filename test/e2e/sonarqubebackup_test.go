@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	goctx "context"
+	"fmt"
+	framework "github.com/operator-framework/operator-sdk/pkg/test"
+	"github.com/operator-framework/operator-sdk/pkg/test/e2eutil"
+	"github.com/parflesh/sonarqube-operator/pkg/apis"
+	operator "github.com/parflesh/sonarqube-operator/pkg/apis/sonarsource/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"testing"
+	"time"
+)
+
+func TestSonarQubeBackup(t *testing.T) {
+	sonarqubeBackupList := &operator.SonarQubeBackupList{}
+	err := framework.AddToFrameworkScheme(apis.AddToScheme, sonarqubeBackupList)
+	if err != nil {
+		t.Fatalf("failed to add custom resource scheme to framework: %v", err)
+	}
+	// run subtests
+	t.Run("sonarqubebackup-group", func(t *testing.T) {
+		t.Run("minio", SonarQubeBackup)
+	})
+}
+
+// setupMinIO deploys a MinIO server with a sonarqube bucket and a Secret with its credentials
+func setupMinIO(f *framework.Framework, ctx *framework.Context, namespace string) error {
+	labels := map[string]string{"app": "minio"}
+	cleanup := &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "minio",
+			Namespace: namespace,
+		},
+		StringData: map[string]string{
+			"AWS_ACCESS_KEY_ID":     "minio",
+			"AWS_SECRET_ACCESS_KEY": "minio123",
+		},
+	}
+	err := f.Client.Create(goctx.TODO(), secret, cleanup)
+	if err != nil {
+		return err
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "minio",
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &[]int32{1}[0],
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "minio",
+							Image: "minio/minio",
+							// Directories of the data path are served as buckets
+							Command: []string{"/bin/sh", "-c", "mkdir -p /data/sonarqube && minio server /data"},
+							Env: []corev1.EnvVar{
+								{Name: "MINIO_ACCESS_KEY", Value: "minio"},
+								{Name: "MINIO_SECRET_KEY", Value: "minio123"},
+							},
+							Ports: []corev1.ContainerPort{{ContainerPort: 9000}},
+						},
+					},
+				},
+			},
+		},
+	}
+	err = f.Client.Create(goctx.TODO(), deployment, cleanup)
+	if err != nil {
+		return err
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "minio",
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Port: 9000}},
+		},
+	}
+	return f.Client.Create(goctx.TODO(), service, cleanup)
+}
+
+func sonarqubeBackupTest(t *testing.T, f *framework.Framework, ctx *framework.Context) error {
+	namespace, err := ctx.GetWatchNamespace()
+	if err != nil {
+		return fmt.Errorf("could not get namespace: %v", err)
+	}
+
+	if err := setupMinIO(f, ctx, namespace); err != nil {
+		return err
+	}
+	err = e2eutil.WaitForDeployment(t, f.KubeClient, namespace, "minio", 1, retryInterval, timeout)
+	if err != nil {
+		return err
+	}
+
+	// create sonarqube custom resource
+	exampleSonarQube := &operator.SonarQube{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-sonarqube",
+			Namespace: namespace,
+		},
+		Spec: operator.SonarQubeSpec{
+			Size: 1,
+			Database: &operator.Database{
+				Managed: &operator.ManagedDatabase{},
+			},
+		},
+	}
+	err = f.Client.Create(goctx.TODO(), exampleSonarQube, &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
+	if err != nil {
+		return err
+	}
+
+	// create sonarqubebackup custom resource
+	exampleSonarQubeBackup := &operator.SonarQubeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-sonarqubebackup",
+			Namespace: namespace,
+		},
+		Spec: operator.SonarQubeBackupSpec{
+			ServerRef: operator.ServerReference{
+				Kind: &[]string{operator.ServerKindSonarQube}[0],
+				Name: exampleSonarQube.Name,
+			},
+			Storage: operator.BackupStorage{
+				S3: &operator.S3Storage{
+					Endpoint:          "http://minio:9000",
+					Bucket:            "sonarqube",
+					CredentialsSecret: "minio",
+				},
+			},
+		},
+	}
+	err = f.Client.Create(goctx.TODO(), exampleSonarQubeBackup, &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
+	if err != nil {
+		return err
+	}
+
+	// The backup waits on the managed database and the first application node
+	return wait.Poll(retryInterval, 10*time.Minute, func() (done bool, err error) {
+		err = f.Client.Get(goctx.TODO(), types.NamespacedName{Name: exampleSonarQubeBackup.Name, Namespace: namespace}, exampleSonarQubeBackup)
+		if err != nil {
+			return false, err
+		}
+
+		if exampleSonarQubeBackup.Status.Conditions.IsTrueFor(operator.ConditionInvalid) {
+			return false, fmt.Errorf("backup failed: %s", exampleSonarQubeBackup.Status.Conditions.GetCondition(operator.ConditionInvalid).Message)
+		}
+		if exampleSonarQubeBackup.Status.LastBackup != "" {
+			return true, nil
+		}
+		t.Logf("Waiting for backup %s to complete\n", exampleSonarQubeBackup.Name)
+		return false, nil
+	})
+}
+
+func SonarQubeBackup(t *testing.T) {
+	t.Parallel()
+	ctx := framework.NewContext(t)
+	defer ctx.Cleanup()
+	err := ctx.InitializeClusterResources(&framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
+	if err != nil {
+		t.Fatalf("failed to initialize cluster resources: %v", err)
+	}
+	t.Log("Initialized cluster resources")
+	namespace, err := ctx.GetWatchNamespace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// get global framework variables
+	f := framework.Global
+	// wait for sonarqube-operator to be ready
+	err = e2eutil.WaitForOperatorDeployment(t, f.KubeClient, namespace, "sonarqube-operator", 1, retryInterval, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sonarqubeBackupTest(t, f, ctx); err != nil {
+		t.Fatal(err)
+	}
+}